@@ -0,0 +1,287 @@
+// Command moonbird operates the predictor from the command line, using the same wiring as the server,
+// so operators can act without going through the admin pages. It requires standalone mode,
+// and the same config as the server it operates alongside.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"github.com/jbeshir/moonbird-predictor-frontend/forecasting"
+	"github.com/jbeshir/moonbird-predictor-frontend/localstore"
+	"github.com/jbeshir/moonbird-predictor-frontend/mlclient"
+	"github.com/jbeshir/moonbird-predictor-frontend/tracing"
+	"github.com/jbeshir/moonbird-predictor-frontend/wiring"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(ctx context.Context, c *wiring.Components, args []string) error
+}
+
+var commands = []*command{
+	{"predict", "P1 P2 ...", "combine the given probabilities into a prediction", predict},
+	{"update-examples", "", "update and archive the example predictions", updateExamples},
+	{"retrain", "[flags]", "run a retrain, or resume one interrupted", retrain},
+	{"versions", "", "list deployed model versions", versions},
+	{"runs", "", "list recent training runs", runs},
+	{"export", "-dir DIR [flags]", "export questions and forecasts to files", export},
+	{"flush-caches", "", "flush the example and prediction caches", flushCaches},
+}
+
+func main() {
+	configPath := flag.String("config", os.Getenv("MOONBIRD_CONFIG"), "config file; defaults to MOONBIRD_CONFIG")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == flag.Arg(0) {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := wiring.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	p := wiring.NewPlatform(cfg)
+	if !p.Standalone {
+		log.Fatal("moonbird requires standalone mode; App Engine's services are only available to the server")
+	}
+	c := wiring.NewComponents(p)
+
+	// Interrupting asks long-running work, such as a retrain, to wind up as shutting down the server does.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		sig := <-signals
+		log.Printf("Received %s, stopping", sig)
+		p.Stop()
+	}()
+
+	ctx := ctxlogrus.WithLogger(context.Background(), logrus.NewEntry(logrus.StandardLogger()))
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"command": cmd.name,
+	})
+	ctx = tracing.WithTracer(ctx, c.Tracer)
+	ctx, span := tracing.Start(ctx, "moonbird "+cmd.name)
+	err = cmd.run(ctx, c, flag.Args()[1:])
+	span.SetError(err)
+	span.End()
+
+	// Shadow predictions are made in the background, and their comparisons buffered; record them before exiting.
+	if !p.WaitForBackground(10 * time.Second) {
+		log.Print("Background work still running at exit")
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if flushErr := c.ShadowLog.Flush(shutdownCtx); flushErr != nil {
+		log.Printf("Unable to record remaining shadow comparisons: %s", flushErr)
+	}
+	if shutdownErr := c.Tracer.Shutdown(shutdownCtx); shutdownErr != nil {
+		log.Printf("Unable to export remaining spans: %s", shutdownErr)
+	}
+
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: moonbird [-config FILE] COMMAND [ARGS]\n\nCommands:\n")
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %s %s\t%s\n", c.name, c.usage, c.summary)
+	}
+	w.Flush()
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("moonbird "+name, flag.ExitOnError)
+}
+
+func predict(ctx context.Context, c *wiring.Components, args []string) error {
+	if len(args) == 0 {
+		return errors.New("predict needs at least one probability")
+	}
+
+	var probabilities []float64
+	for _, arg := range args {
+		p, err := strconv.ParseFloat(arg, 64)
+		if err != nil || p < 0 || p > 1 {
+			return errors.Errorf("probabilities must be numbers from 0 to 1, got %s", arg)
+		}
+		probabilities = append(probabilities, p)
+	}
+
+	result, version, err := c.PredictionMaker.PredictWithVersion(ctx, probabilities)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	if version == "" {
+		version = "default"
+	}
+	fmt.Printf("%.3f\t%s\n", result, version)
+	return nil
+}
+
+func updateExamples(ctx context.Context, c *wiring.Components, args []string) error {
+	warnUnsharedCache(ctx, c, "examples")
+	return errors.Wrap(c.ExamplesUpdate.Update(ctx, time.Now()), "")
+}
+
+func retrain(ctx context.Context, c *wiring.Components, args []string) error {
+	fs := newFlagSet("retrain")
+	input := &controllers.ModelRetrainManualInput{}
+	fs.StringVar(&input.CutoffStr, "cutoff", "", "incorporate predictions up to this time, in RFC 3339 form; defaults to now")
+	fs.StringVar(&input.BaseModelStr, "base", "", "train from this model, rather than the latest")
+	fs.BoolVar(&input.SkipPromotion, "skip-promotion", false, "leave the new version non-default")
+	fs.BoolVar(&input.FullRebuild, "full-rebuild", false, "train from scratch on every resolved prediction")
+	attach := fs.Bool("attach", false, "wait for any retrain in progress, rather than failing")
+	step := fs.Bool("step", false, "advance the retrain by one stage, then checkpoint it")
+	_ = fs.Parse(args)
+
+	opts, validationErrs := controllers.ParseRetrainOptions(input, time.Now())
+	if len(validationErrs) > 0 {
+		return errors.New(strings.Join(validationErrs, " "))
+	}
+	opts.AttachToRunning = *attach
+	if !opts.SkipPromotion {
+		warnUnsharedCache(ctx, c, "predictions from the previous model")
+	}
+
+	// Stopping before we start ends the retrain at the first opportunity, after a single stage.
+	if *step {
+		c.Platform.Stop()
+	}
+
+	err := c.Trainer.RetrainWithOptions(ctx, *opts)
+	if errors.Cause(err) == mlclient.ErrRetrainInterrupted {
+		ctxlogrus.Get(ctx).Info("Retrain checkpointed; run retrain again to continue it")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	if !opts.SkipPromotion {
+		return errors.Wrap(c.PredictionCache.Flush(ctx), "")
+	}
+	return nil
+}
+
+func versions(ctx context.Context, c *wiring.Components, args []string) error {
+	versions, err := c.Trainer.ListVersions(ctx)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATE\tCREATED\tDEFAULT")
+	for _, v := range versions {
+		isDefault := ""
+		if v.Default {
+			isDefault = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.Name, v.State, formatTime(v.Created), isDefault)
+	}
+	return errors.Wrap(w.Flush(), "")
+}
+
+func runs(ctx context.Context, c *wiring.Components, args []string) error {
+	runs, err := c.Trainer.RecentRuns(ctx)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tBASE\tSTARTED\tDURATION\tOUTCOME\tPROMOTED\tERROR")
+	for _, r := range runs {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%s\n", r.Model, r.BaseModel, formatTime(r.Started),
+			r.Duration().Round(time.Second), r.Outcome, r.PromotedVersion, r.Err)
+	}
+	return errors.Wrap(w.Flush(), "")
+}
+
+func export(ctx context.Context, c *wiring.Components, args []string) error {
+	fs := newFlagSet("export")
+	dir := fs.String("dir", "", "directory to write the export to")
+	format := fs.String("format", "csv", "export format, csv or json")
+	since := fs.String("since", "", "export all questions created after this RFC 3339 time; defaults to all")
+	pages := fs.Int64("pages", 10, "number of pages of open questions to export")
+	_ = fs.Parse(args)
+
+	if *dir == "" {
+		return errors.New("-dir is required")
+	}
+	var sinceTime time.Time
+	if *since != "" {
+		var err error
+		sinceTime, err = time.Parse(time.RFC3339, *since)
+		if err != nil {
+			return errors.Wrap(err, "invalid -since")
+		}
+	}
+
+	recorder := &forecasting.Recorder{
+		Source:    c.MergedSource,
+		FileStore: &localstore.FileStore{Dir: *dir},
+		Format:    forecasting.DumpFormat(*format),
+	}
+	return errors.Wrap(recorder.Record(ctx, sinceTime, *pages), "")
+}
+
+func flushCaches(ctx context.Context, c *wiring.Components, args []string) error {
+	if !sharesCache(c) {
+		return errors.New("caches are in memory, not shared with the server, so there is nothing to flush; " +
+			"configure Redis to share them, or restart the server to flush its own")
+	}
+	if err := c.ExampleCache.Flush(ctx); err != nil {
+		return errors.Wrap(err, "")
+	}
+	return errors.Wrap(c.PredictionCache.Flush(ctx), "")
+}
+
+// sharesCache returns whether our caches are the server's, rather than in our own memory.
+func sharesCache(c *wiring.Components) bool {
+	return c.Platform.Config.Cache.RedisAddr != ""
+}
+
+// warnUnsharedCache warns that the server won't see our cache flushes, if our caches aren't shared with it.
+func warnUnsharedCache(ctx context.Context, c *wiring.Components, cached string) {
+	if !sharesCache(c) {
+		ctxlogrus.Get(ctx).Warnf("Caches are in memory, not shared with the server, which will serve cached %s "+
+			"until restarted; configure Redis to share them", cached)
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04")
+}
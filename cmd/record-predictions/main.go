@@ -0,0 +1,49 @@
+// Command record-predictions captures questions and forecasts from PredictionBook into local files,
+// which can be replayed in place of live scraping by setting PREDICTION_SOURCE_DIR.
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/jbeshir/moonbird-predictor-frontend/forecasting"
+	"github.com/jbeshir/moonbird-predictor-frontend/localstore"
+	"github.com/jbeshir/predictionbook-extractor/htmlfetcher"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"golang.org/x/time/rate"
+	"log"
+	"time"
+)
+
+func main() {
+	dir := flag.String("dir", "", "directory to write the recording to")
+	format := flag.String("format", "csv", "recording format, csv or json")
+	since := flag.String("since", "", "record all predictions created after this RFC 3339 time; defaults to all")
+	pages := flag.Int64("pages", 10, "number of pages of open predictions to record")
+	flag.Parse()
+
+	if *dir == "" {
+		log.Fatal("-dir is required")
+	}
+	var sinceTime time.Time
+	if *since != "" {
+		var err error
+		sinceTime, err = time.Parse(time.RFC3339, *since)
+		if err != nil {
+			log.Fatalf("Invalid -since: %s", err)
+		}
+	}
+
+	recorder := &forecasting.Recorder{
+		Source: &forecasting.PredictionBook{
+			Source: predictions.NewSource(
+				htmlfetcher.NewFetcher(rate.NewLimiter(1, 2), 2),
+				"https://predictionbook.com"),
+		},
+		FileStore: &localstore.FileStore{Dir: *dir},
+		Format:    forecasting.DumpFormat(*format),
+	}
+	err := recorder.Record(context.Background(), sinceTime, *pages)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
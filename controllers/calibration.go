@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type Calibration struct {
+	TrackRecorder  TrackRecorder
+	ModelVersioner ModelVersioner
+}
+
+type CalibrationResult struct {
+	ModelVersion string
+
+	// Overall is set if the current model has no resolved forecasts yet,
+	// in which case Record covers all model versions instead.
+	Overall bool
+	Record  data.VersionTrackRecord
+}
+
+type WebCalibrationResponder interface {
+	OnContextError(w http.ResponseWriter, err error)
+	OnError(ctx context.Context, w http.ResponseWriter, err error)
+	OnResult(w http.ResponseWriter, r *CalibrationResult)
+}
+
+func (c *Calibration) HandleFunc(cm ContextMaker, resp WebCalibrationResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		result, err := c.handle(ctx)
+		if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnResult(w, result)
+		}
+	}
+}
+
+func (c *Calibration) handle(ctx context.Context) (*CalibrationResult, error) {
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"controller": "Calibration",
+	})
+
+	modelVersion, err := c.ModelVersioner.CurrentModelVersion(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	record, err := c.TrackRecorder.GetTrackRecord(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	for _, v := range record.Versions {
+		if v.ModelVersion == modelVersion && v.Count > 0 {
+			return &CalibrationResult{
+				ModelVersion: modelVersion,
+				Record:       v,
+			}, nil
+		}
+	}
+	return &CalibrationResult{
+		ModelVersion: modelVersion,
+		Overall:      true,
+		Record:       record.Overall,
+	}, nil
+}
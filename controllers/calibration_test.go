@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"net/http"
+	"testing"
+)
+
+func TestCalibration_HandleFunc_Success(t *testing.T) {
+	t.Parallel()
+
+	for _, currentVersion := range []string{"v600", "v700"} {
+		tr := newTestTrackRecorder(t)
+		tr.GetTrackRecordFunc = func(ctx context.Context) (*data.TrackRecord, error) {
+			return &data.TrackRecord{
+				Overall: data.VersionTrackRecord{Count: 3},
+				Versions: []data.VersionTrackRecord{
+					{ModelVersion: "v500", Count: 1},
+					{ModelVersion: "v600", Count: 2},
+				},
+			}, nil
+		}
+
+		mv := newTestModelVersioner(t)
+		mv.CurrentModelVersionFunc = func(ctx context.Context) (string, error) {
+			return currentVersion, nil
+		}
+
+		calledOnResult := false
+		r := newTestWebCalibrationResponder(t)
+		r.OnResultFunc = func(w http.ResponseWriter, result *CalibrationResult) {
+			calledOnResult = true
+			if result.ModelVersion != currentVersion {
+				t.Errorf("Expected model version %s, got %s", currentVersion, result.ModelVersion)
+			}
+
+			// Without resolved forecasts for the current version, we fall back to all versions.
+			expectOverall := currentVersion == "v700"
+			if result.Overall != expectOverall {
+				t.Errorf("For %s, expected overall to be %v, was %v", currentVersion, expectOverall, result.Overall)
+			}
+			if expectOverall && result.Record.Count != 3 || !expectOverall && result.Record.Count != 2 {
+				t.Errorf("For %s, got record with unexpected count %d", currentVersion, result.Record.Count)
+			}
+		}
+
+		cm := testhelpers.NewContextMaker(t)
+		cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+			return context.Background(), nil
+		}
+
+		c := &Calibration{
+			TrackRecorder:  tr,
+			ModelVersioner: mv,
+		}
+		handler := c.HandleFunc(cm, r)
+		handler(nil, &http.Request{})
+
+		if !calledOnResult {
+			t.Error("Expected responder's OnResult method to be called, was not called")
+		}
+	}
+}
+
+func TestCalibration_HandleFunc_Error(t *testing.T) {
+	t.Parallel()
+
+	mv := newTestModelVersioner(t)
+	mv.CurrentModelVersionFunc = func(ctx context.Context) (string, error) {
+		return "", errors.New("bluh")
+	}
+
+	calledOnError := false
+	r := newTestWebCalibrationResponder(t)
+	r.OnErrorFunc = func(ctx context.Context, w http.ResponseWriter, err error) {
+		calledOnError = true
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &Calibration{
+		TrackRecorder:  newTestTrackRecorder(t),
+		ModelVersioner: mv,
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnError {
+		t.Error("Expected responder's OnError method to be called, was not called")
+	}
+}
+
+func newTestWebCalibrationResponder(t *testing.T) *testWebCalibrationResponder {
+	return &testWebCalibrationResponder{
+		OnContextErrorFunc: func(w http.ResponseWriter, err error) {
+			t.Error("OnContextErrorFunc should not be called")
+		},
+		OnErrorFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+			t.Error("OnErrorFunc should not be called")
+		},
+		OnResultFunc: func(w http.ResponseWriter, r *CalibrationResult) {
+			t.Error("OnResultFunc should not be called")
+		},
+	}
+}
+
+type testWebCalibrationResponder struct {
+	OnContextErrorFunc func(w http.ResponseWriter, err error)
+	OnErrorFunc        func(ctx context.Context, w http.ResponseWriter, err error)
+	OnResultFunc       func(w http.ResponseWriter, r *CalibrationResult)
+}
+
+func (r *testWebCalibrationResponder) OnContextError(w http.ResponseWriter, err error) {
+	r.OnContextErrorFunc(w, err)
+}
+
+func (r *testWebCalibrationResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	r.OnErrorFunc(ctx, w, err)
+}
+
+func (r *testWebCalibrationResponder) OnResult(w http.ResponseWriter, result *CalibrationResult) {
+	r.OnResultFunc(w, result)
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type Config struct {
+	ConfigSource ConfigSource
+}
+
+type ConfigResult struct {
+	Content string
+}
+
+type WebConfigResponder interface {
+	OnContextError(w http.ResponseWriter, err error)
+	OnError(ctx context.Context, w http.ResponseWriter, err error)
+	OnResult(w http.ResponseWriter, r *ConfigResult)
+}
+
+func (c *Config) HandleFunc(cm ContextMaker, resp WebConfigResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		result, err := c.handle(ctx)
+		if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnResult(w, result)
+		}
+	}
+}
+
+func (c *Config) handle(ctx context.Context) (*ConfigResult, error) {
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"controller": "Config",
+	})
+
+	content, err := c.ConfigSource.RedactedYAML()
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	return &ConfigResult{Content: string(content)}, nil
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	"net/http"
+	"testing"
+)
+
+func TestConfig_HandleFunc_Success(t *testing.T) {
+	t.Parallel()
+
+	cs := newTestConfigSource(t)
+	cs.RedactedYAMLFunc = func() ([]byte, error) {
+		return []byte("mode: standalone\n"), nil
+	}
+
+	calledOnResult := false
+	r := newTestWebConfigResponder(t)
+	r.OnResultFunc = func(w http.ResponseWriter, result *ConfigResult) {
+		calledOnResult = true
+		if result.Content != "mode: standalone\n" {
+			t.Errorf("Expected result to contain the config, got %q", result.Content)
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &Config{
+		ConfigSource: cs,
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnResult {
+		t.Error("Expected responder's OnResult method to be called, was not called")
+	}
+}
+
+func TestConfig_HandleFunc_Error(t *testing.T) {
+	t.Parallel()
+
+	cs := newTestConfigSource(t)
+	cs.RedactedYAMLFunc = func() ([]byte, error) {
+		return nil, errors.New("bluh")
+	}
+
+	calledOnError := false
+	r := newTestWebConfigResponder(t)
+	r.OnErrorFunc = func(ctx context.Context, w http.ResponseWriter, err error) {
+		calledOnError = true
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &Config{
+		ConfigSource: cs,
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnError {
+		t.Error("Expected responder's OnError method to be called, was not called")
+	}
+}
+
+func TestConfig_HandleFunc_ContextError(t *testing.T) {
+	t.Parallel()
+
+	calledOnContextError := false
+	r := newTestWebConfigResponder(t)
+	r.OnContextErrorFunc = func(w http.ResponseWriter, err error) {
+		calledOnContextError = true
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return nil, errors.New("bluh")
+	}
+
+	c := &Config{}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnContextError {
+		t.Error("Expected responder's OnContextError method to be called, was not called")
+	}
+}
+
+func newTestWebConfigResponder(t *testing.T) *testWebConfigResponder {
+	return &testWebConfigResponder{
+		OnContextErrorFunc: func(w http.ResponseWriter, err error) {
+			t.Error("OnContextErrorFunc should not be called")
+		},
+		OnErrorFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+			t.Error("OnErrorFunc should not be called")
+		},
+		OnResultFunc: func(w http.ResponseWriter, r *ConfigResult) {
+			t.Error("OnResultFunc should not be called")
+		},
+	}
+}
+
+type testWebConfigResponder struct {
+	OnContextErrorFunc func(w http.ResponseWriter, err error)
+	OnErrorFunc        func(ctx context.Context, w http.ResponseWriter, err error)
+	OnResultFunc       func(w http.ResponseWriter, r *ConfigResult)
+}
+
+func (r *testWebConfigResponder) OnContextError(w http.ResponseWriter, err error) {
+	r.OnContextErrorFunc(w, err)
+}
+
+func (r *testWebConfigResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	r.OnErrorFunc(ctx, w, err)
+}
+
+func (r *testWebConfigResponder) OnResult(w http.ResponseWriter, result *ConfigResult) {
+	r.OnResultFunc(w, result)
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type ExampleDetail struct {
+	ExampleLister   ExampleLister
+	PredictionMaker PredictionMaker
+}
+
+type ExampleDetailResult struct {
+	Example   data.ExamplePrediction
+	Result    float64
+	ResultErr string
+
+	// Baselines the combined probability can be compared against.
+	Mean   float64
+	Median float64
+
+	History []ExampleHistoryPoint
+}
+
+type ExampleHistoryPoint struct {
+	Updated         time.Time
+	AssignmentCount int
+	Result          float64
+	ResultErr       string
+	ModelVersion    string
+}
+
+type WebExampleDetailResponder interface {
+	OnContextError(w http.ResponseWriter, err error)
+	OnError(ctx context.Context, w http.ResponseWriter, err error)
+	OnNotFound(w http.ResponseWriter)
+	OnResult(w http.ResponseWriter, r *ExampleDetailResult)
+}
+
+type ExampleDetailApiResponder interface {
+	WebApiResponder
+	OnNotFound(w http.ResponseWriter)
+}
+
+// HandleFunc serves the example whose ID is the last element of the request path.
+func (c *ExampleDetail) HandleFunc(cm ContextMaker, resp WebExampleDetailResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		result, err := c.handle(ctx, path.Base(r.URL.Path))
+		if errors.Cause(err) == data.ErrNoSuchExample {
+			resp.OnNotFound(w)
+		} else if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnResult(w, result)
+		}
+	}
+}
+
+func (c *ExampleDetail) HandleApiFunc(cm ContextMaker, resp ExampleDetailApiResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		result, err := c.handle(ctx, path.Base(r.URL.Path))
+		if errors.Cause(err) == data.ErrNoSuchExample {
+			resp.OnNotFound(w)
+		} else if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnSuccess(w, result)
+		}
+	}
+}
+
+func (c *ExampleDetail) handle(ctx context.Context, idStr string) (*ExampleDetailResult, error) {
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"controller": "ExampleDetail",
+	})
+	l := ctxlogrus.Get(ctx)
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, errors.WithStack(data.ErrNoSuchExample)
+	}
+
+	example, err := c.ExampleLister.GetExample(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	result := &ExampleDetailResult{
+		Example: *example,
+		Mean:    mean(example.Assignments),
+		Median:  median(example.Assignments),
+	}
+	result.Result, err = c.PredictionMaker.Predict(ctx, example.Assignments)
+	if err != nil {
+		l.Errorf("Unable to generate example prediction: %s", err)
+		result.ResultErr = err.Error()
+	}
+
+	// Failing to show the history shouldn't prevent showing the example itself.
+	history, err := c.ExampleLister.GetExampleHistory(ctx, id)
+	if err != nil {
+		l.Errorf("Unable to get example history: %s", err)
+	}
+	// History shows the combined probability as recorded at each update, by the model of the time.
+	for _, entry := range history {
+		point := ExampleHistoryPoint{
+			Updated:         entry.Updated,
+			AssignmentCount: len(entry.Assignments),
+			Result:          entry.Result,
+			ResultErr:       entry.ResultErr,
+			ModelVersion:    entry.ModelVersion,
+		}
+		if entry.ModelVersion == "" && entry.ResultErr == "" {
+			point.ResultErr = "Not recorded"
+		}
+		result.History = append(result.History, point)
+	}
+
+	return result, nil
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	if len(sorted)%2 == 1 {
+		return sorted[len(sorted)/2]
+	}
+	return (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+}
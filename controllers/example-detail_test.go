@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestExampleDetail_HandleFunc_Success(t *testing.T) {
+	t.Parallel()
+
+	l := newTestExamplesLister(t)
+	l.GetExampleFunc = func(ctx context.Context, id int64) (*data.ExamplePrediction, error) {
+		if id != 7 {
+			t.Errorf("Expected to get example %d, got %d", 7, id)
+		}
+		return &data.ExamplePrediction{
+			PredictionSummary: &predictions.PredictionSummary{Id: 7},
+			Assignments:       []float64{0.1, 0.9, 0.2},
+		}, nil
+	}
+	l.GetExampleHistoryFunc = func(ctx context.Context, id int64) ([]data.ExampleHistoryEntry, error) {
+		return []data.ExampleHistoryEntry{
+			{Updated: time.Unix(500, 0), Assignments: []float64{0.1}},
+			{Updated: time.Unix(1000, 0), Assignments: []float64{0.1}, ResultErr: "bluh"},
+			{Updated: time.Unix(2000, 0), Assignments: []float64{0.1, 0.9, 0.2}, Result: 0.3, ModelVersion: "v100"},
+		}, nil
+	}
+
+	pm := newTestPredictionMaker(t)
+	pm.PredictFunc = func(ctx context.Context, predictions []float64) (float64, error) {
+		if len(predictions) != 3 {
+			t.Errorf("Expected only the current assignments to be predicted, got %v", predictions)
+		}
+		return 0.25, nil
+	}
+
+	calledOnResult := false
+	r := newTestWebExampleDetailResponder(t)
+	r.OnResultFunc = func(w http.ResponseWriter, result *ExampleDetailResult) {
+		calledOnResult = true
+		if result.Example.Id != 7 {
+			t.Errorf("Expected example %d, got %d", 7, result.Example.Id)
+		}
+		if result.Result != 0.25 || result.ResultErr != "" {
+			t.Errorf("Expected combined result of 0.25, got %g (%s)", result.Result, result.ResultErr)
+		}
+		if result.Mean < 0.3999 || result.Mean > 0.4001 {
+			t.Errorf("Expected mean of 0.4, got %g", result.Mean)
+		}
+		if result.Median != 0.2 {
+			t.Errorf("Expected median of 0.2, got %g", result.Median)
+		}
+		if len(result.History) != 3 {
+			t.Fatalf("Expected 3 history points, got %d", len(result.History))
+		}
+		if result.History[0].ResultErr == "" || result.History[0].AssignmentCount != 1 {
+			t.Errorf("Expected history point without a recorded result to have 1 assignment and an error, got %+v", result.History[0])
+		}
+		if result.History[1].ResultErr != "bluh" || result.History[1].AssignmentCount != 1 {
+			t.Errorf("Expected second history point to have 1 assignment and its recorded error, got %+v", result.History[1])
+		}
+		if result.History[2].Result != 0.3 || result.History[2].ModelVersion != "v100" || result.History[2].AssignmentCount != 3 {
+			t.Errorf("Expected third history point to have 3 assignments and its recorded result 0.3 from v100, got %+v", result.History[2])
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &ExampleDetail{
+		ExampleLister:   l,
+		PredictionMaker: pm,
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{URL: &url.URL{Path: "/examples/7"}})
+
+	if !calledOnResult {
+		t.Error("Expected responder's OnResult method to be called, was not called")
+	}
+}
+
+func TestExampleDetail_HandleFunc_NotFound(t *testing.T) {
+	t.Parallel()
+
+	for _, p := range []string{"/examples/9", "/examples/bluh"} {
+		l := newTestExamplesLister(t)
+		l.GetExampleFunc = func(ctx context.Context, id int64) (*data.ExamplePrediction, error) {
+			return nil, data.ErrNoSuchExample
+		}
+
+		calledOnNotFound := false
+		r := newTestWebExampleDetailResponder(t)
+		r.OnNotFoundFunc = func(w http.ResponseWriter) {
+			calledOnNotFound = true
+		}
+
+		cm := testhelpers.NewContextMaker(t)
+		cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+			return context.Background(), nil
+		}
+
+		c := &ExampleDetail{
+			ExampleLister:   l,
+			PredictionMaker: newTestPredictionMaker(t),
+		}
+		handler := c.HandleFunc(cm, r)
+		handler(nil, &http.Request{URL: &url.URL{Path: p}})
+
+		if !calledOnNotFound {
+			t.Errorf("Expected responder's OnNotFound method to be called for %s, was not called", p)
+		}
+	}
+}
+
+func TestExampleDetail_HandleFunc_Error(t *testing.T) {
+	t.Parallel()
+
+	l := newTestExamplesLister(t)
+	l.GetExampleFunc = func(ctx context.Context, id int64) (*data.ExamplePrediction, error) {
+		return nil, errors.New("bluh")
+	}
+
+	calledOnError := false
+	r := newTestWebExampleDetailResponder(t)
+	r.OnErrorFunc = func(ctx context.Context, w http.ResponseWriter, err error) {
+		calledOnError = true
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &ExampleDetail{
+		ExampleLister:   l,
+		PredictionMaker: newTestPredictionMaker(t),
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{URL: &url.URL{Path: "/examples/7"}})
+
+	if !calledOnError {
+		t.Error("Expected responder's OnError method to be called, was not called")
+	}
+}
+
+func TestExampleDetail_HandleApiFunc_Success(t *testing.T) {
+	t.Parallel()
+
+	l := newTestExamplesLister(t)
+	l.GetExampleFunc = func(ctx context.Context, id int64) (*data.ExamplePrediction, error) {
+		return &data.ExamplePrediction{
+			PredictionSummary: &predictions.PredictionSummary{Id: id},
+			Assignments:       []float64{0.3},
+		}, nil
+	}
+	l.GetExampleHistoryFunc = func(ctx context.Context, id int64) ([]data.ExampleHistoryEntry, error) {
+		return nil, nil
+	}
+
+	pm := newTestPredictionMaker(t)
+	pm.PredictFunc = func(ctx context.Context, predictions []float64) (float64, error) {
+		return 0.3, nil
+	}
+
+	calledOnSuccess := false
+	r := newTestExampleDetailApiResponder(t)
+	r.OnSuccessFunc = func(w http.ResponseWriter, v interface{}) {
+		calledOnSuccess = true
+		result, ok := v.(*ExampleDetailResult)
+		if !ok {
+			t.Errorf("Expected result of type *ExampleDetailResult, got %T", v)
+		} else if result.Example.Id != 7 {
+			t.Errorf("Expected example %d, got %d", 7, result.Example.Id)
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &ExampleDetail{
+		ExampleLister:   l,
+		PredictionMaker: pm,
+	}
+	handler := c.HandleApiFunc(cm, r)
+	handler(nil, &http.Request{URL: &url.URL{Path: "/api/examples/7"}})
+
+	if !calledOnSuccess {
+		t.Error("Expected responder's OnSuccess method to be called, was not called")
+	}
+}
+
+func newTestWebExampleDetailResponder(t *testing.T) *testWebExampleDetailResponder {
+	return &testWebExampleDetailResponder{
+		OnContextErrorFunc: func(w http.ResponseWriter, err error) {
+			t.Error("OnContextErrorFunc should not be called")
+		},
+		OnErrorFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+			t.Error("OnErrorFunc should not be called")
+		},
+		OnNotFoundFunc: func(w http.ResponseWriter) {
+			t.Error("OnNotFoundFunc should not be called")
+		},
+		OnResultFunc: func(w http.ResponseWriter, r *ExampleDetailResult) {
+			t.Error("OnResultFunc should not be called")
+		},
+	}
+}
+
+type testWebExampleDetailResponder struct {
+	OnContextErrorFunc func(w http.ResponseWriter, err error)
+	OnErrorFunc        func(ctx context.Context, w http.ResponseWriter, err error)
+	OnNotFoundFunc     func(w http.ResponseWriter)
+	OnResultFunc       func(w http.ResponseWriter, r *ExampleDetailResult)
+}
+
+func (r *testWebExampleDetailResponder) OnContextError(w http.ResponseWriter, err error) {
+	r.OnContextErrorFunc(w, err)
+}
+
+func (r *testWebExampleDetailResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	r.OnErrorFunc(ctx, w, err)
+}
+
+func (r *testWebExampleDetailResponder) OnNotFound(w http.ResponseWriter) {
+	r.OnNotFoundFunc(w)
+}
+
+func (r *testWebExampleDetailResponder) OnResult(w http.ResponseWriter, result *ExampleDetailResult) {
+	r.OnResultFunc(w, result)
+}
+
+func newTestExampleDetailApiResponder(t *testing.T) *testExampleDetailApiResponder {
+	return &testExampleDetailApiResponder{
+		WebApiResponder: testhelpers.NewWebApiResponder(t),
+		OnNotFoundFunc: func(w http.ResponseWriter) {
+			t.Error("OnNotFoundFunc should not be called")
+		},
+	}
+}
+
+type testExampleDetailApiResponder struct {
+	*testhelpers.WebApiResponder
+	OnNotFoundFunc func(w http.ResponseWriter)
+}
+
+func (r *testExampleDetailApiResponder) OnNotFound(w http.ResponseWriter) {
+	r.OnNotFoundFunc(w)
+}
@@ -3,13 +3,23 @@ package controllers
 import (
 	"context"
 	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"time"
 )
 
 type ExamplesUpdate struct {
 	ExampleLister ExampleLister
+
+	// If Archive is set, a snapshot of the updated list is archived along with our predictions for it.
+	Archive         ExampleArchive
+	PredictionMaker VersionedPredictionMaker
+	ModelVersioner  ModelVersioner
+
+	// Metrics, if set, is told how long each update took, how many examples it found, and whether it failed.
+	Metrics ExamplesUpdateMetrics
 }
 
 type WebExamplesUpdateResponder interface {
@@ -26,7 +36,7 @@ func (c *ExamplesUpdate) HandleFunc(cm ContextMaker, resp WebExamplesUpdateRespo
 			return
 		}
 
-		err = c.handle(ctx)
+		err = c.handle(ctx, time.Now())
 		if err != nil {
 			resp.OnError(ctx, w, err)
 		} else {
@@ -35,11 +45,58 @@ func (c *ExamplesUpdate) HandleFunc(cm ContextMaker, resp WebExamplesUpdateRespo
 	}
 }
 
-func (c *ExamplesUpdate) handle(ctx context.Context) error {
+// Update updates the examples as a request to HandleFunc's handler would, for callers outside of one.
+func (c *ExamplesUpdate) Update(ctx context.Context, now time.Time) error {
+	return c.handle(ctx, now)
+}
+
+func (c *ExamplesUpdate) handle(ctx context.Context, now time.Time) (err error) {
 	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
 		"controller": "ExamplesUpdate",
 	})
 
-	_, err := c.ExampleLister.UpdateExamples(ctx)
-	return errors.Wrap(err, "")
+	var examples data.ExamplePredictions
+	if c.Metrics != nil {
+		start := time.Now()
+		defer func() {
+			c.Metrics.ExamplesUpdated(time.Since(start), len(examples), err)
+		}()
+	}
+
+	examples, err = c.ExampleLister.UpdateExamples(ctx)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	if c.Archive == nil {
+		return nil
+	}
+	return errors.Wrap(c.archive(ctx, examples, now), "")
+}
+
+func (c *ExamplesUpdate) archive(ctx context.Context, examples data.ExamplePredictions, now time.Time) error {
+	l := ctxlogrus.Get(ctx)
+
+	modelVersion, err := c.ModelVersioner.CurrentModelVersion(ctx)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	snapshot := &data.ExampleSnapshot{
+		Date:         now.UTC().Format(data.SnapshotDateFormat),
+		Taken:        now,
+		ModelVersion: modelVersion,
+	}
+	for _, example := range examples {
+		entry := data.ExampleSnapshotEntry{ExamplePrediction: example}
+		entry.Result, entry.ModelVersion, err = c.PredictionMaker.PredictWithVersion(ctx, example.Assignments)
+		if err != nil {
+			l.Warnf("Unable to predict example %d for snapshot: %s", example.Id, err)
+			entry.ResultErr = err.Error()
+		}
+		snapshot.Examples = append(snapshot.Examples, entry)
+	}
+
+	l.Infof("Archiving snapshot of %d examples for %s", len(snapshot.Examples), snapshot.Date)
+	return errors.Wrap(c.Archive.SaveSnapshot(ctx, snapshot), "")
 }
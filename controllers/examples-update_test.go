@@ -5,8 +5,10 @@ import (
 	"errors"
 	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
 	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestExamplesUpdate_HandleFunc_Success(t *testing.T) {
@@ -123,6 +125,129 @@ func TestExamplesUpdate_HandleFunc_UpdateError(t *testing.T) {
 	}
 }
 
+func TestExamplesUpdate_Update_Metrics(t *testing.T) {
+	t.Parallel()
+
+	updateErr := errors.New("bluh")
+	l := newTestExamplesLister(t)
+	l.UpdateExamplesFunc = func(ctx context.Context) (data.ExamplePredictions, error) {
+		if updateErr != nil {
+			return nil, updateErr
+		}
+		return make(data.ExamplePredictions, 2), nil
+	}
+
+	metrics := &testExamplesUpdateMetrics{}
+	c := &ExamplesUpdate{
+		ExampleLister: l,
+		Metrics:       metrics,
+	}
+	if err := c.Update(context.Background(), time.Now()); err == nil {
+		t.Error("Expected an error from the failing update, got nil")
+	}
+	updateErr = nil
+	if err := c.Update(context.Background(), time.Now()); err != nil {
+		t.Errorf("Expected nil err, got %s", err)
+	}
+
+	if len(metrics.errs) != 2 || metrics.errs[0] == nil || metrics.errs[1] != nil {
+		t.Errorf("Expected the first of two updates to be recorded as failed, got %v", metrics.errs)
+	}
+	if len(metrics.examples) != 2 || metrics.examples[0] != 0 || metrics.examples[1] != 2 {
+		t.Errorf("Expected 0 then 2 examples to be recorded, got %v", metrics.examples)
+	}
+}
+
+func TestExamplesUpdate_Archive(t *testing.T) {
+	t.Parallel()
+
+	l := newTestExamplesLister(t)
+	l.UpdateExamplesFunc = func(ctx context.Context) (data.ExamplePredictions, error) {
+		return data.ExamplePredictions{
+			{PredictionSummary: &predictions.PredictionSummary{Id: 7}, Assignments: []float64{0.3}},
+			{PredictionSummary: &predictions.PredictionSummary{Id: 9}, Assignments: []float64{0.5}},
+		}, nil
+	}
+
+	pm := newTestPredictionMaker(t)
+	pm.PredictWithVersionFunc = func(ctx context.Context, assignments []float64) (float64, string, error) {
+		if assignments[0] == 0.5 {
+			return 0, "v600", errors.New("bluh")
+		}
+		return 0.4, "", nil
+	}
+
+	mv := newTestModelVersioner(t)
+	mv.CurrentModelVersionFunc = func(ctx context.Context) (string, error) {
+		return "v500", nil
+	}
+
+	var saved *data.ExampleSnapshot
+	a := newTestExampleArchive(t)
+	a.SaveSnapshotFunc = func(ctx context.Context, snapshot *data.ExampleSnapshot) error {
+		saved = snapshot
+		return nil
+	}
+
+	c := &ExamplesUpdate{
+		ExampleLister:   l,
+		Archive:         a,
+		PredictionMaker: pm,
+		ModelVersioner:  mv,
+	}
+	now := time.Date(2019, 1, 2, 23, 0, 0, 0, time.UTC)
+	err := c.handle(context.Background(), now)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+
+	if saved == nil {
+		t.Fatal("Expected snapshot to be saved, was not saved")
+	}
+	if saved.Date != "2019-01-02" || !saved.Taken.Equal(now) || saved.ModelVersion != "v500" {
+		t.Errorf("Unexpected snapshot details: %s, %s, %s", saved.Date, saved.Taken, saved.ModelVersion)
+	}
+	if len(saved.Examples) != 2 {
+		t.Fatalf("Expected snapshot to contain 2 examples, contained %d", len(saved.Examples))
+	}
+	if saved.Examples[0].Result != 0.4 || saved.Examples[0].ModelVersion != "" || saved.Examples[0].ResultErr != "" {
+		t.Errorf("Unexpected first snapshot entry: %+v", saved.Examples[0])
+	}
+	if saved.Examples[1].ResultErr != "bluh" || saved.Examples[1].ModelVersion != "v600" {
+		t.Errorf("Unexpected second snapshot entry: %+v", saved.Examples[1])
+	}
+}
+
+func TestExamplesUpdate_Archive_Error(t *testing.T) {
+	t.Parallel()
+
+	l := newTestExamplesLister(t)
+	l.UpdateExamplesFunc = func(ctx context.Context) (data.ExamplePredictions, error) {
+		return nil, nil
+	}
+
+	mv := newTestModelVersioner(t)
+	mv.CurrentModelVersionFunc = func(ctx context.Context) (string, error) {
+		return "v500", nil
+	}
+
+	a := newTestExampleArchive(t)
+	a.SaveSnapshotFunc = func(ctx context.Context, snapshot *data.ExampleSnapshot) error {
+		return errors.New("bluh")
+	}
+
+	c := &ExamplesUpdate{
+		ExampleLister:   l,
+		Archive:         a,
+		PredictionMaker: newTestPredictionMaker(t),
+		ModelVersioner:  mv,
+	}
+	err := c.handle(context.Background(), time.Now())
+	if err == nil {
+		t.Error("Expected error, got nil error")
+	}
+}
+
 func newTestWebExamplesUpdateResponder(t *testing.T) *testWebExamplesUpdateResponder {
 	return &testWebExamplesUpdateResponder{
 		OnContextErrorFunc: func(w http.ResponseWriter, err error) {
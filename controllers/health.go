@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	HealthStatusOk   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// Health reports whether the service is up, and whether each of the dependencies in Checks is reachable.
+// With no checks, it serves for liveness, succeeding whenever the server is able to respond at all.
+type Health struct {
+	Checks []HealthCheck
+
+	// Timeout limits each check, so a hung dependency fails its check rather than the whole request.
+	Timeout time.Duration
+}
+
+type HealthCheck struct {
+	Name    string
+	Checker HealthChecker
+}
+
+type HealthResult struct {
+	Status string              `json:"status"`
+	Checks []HealthCheckResult `json:"checks,omitempty"`
+}
+
+type HealthCheckResult struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type WebHealthResponder interface {
+	OnContextError(w http.ResponseWriter, err error)
+	OnResult(w http.ResponseWriter, r *HealthResult)
+}
+
+func (c *Health) HandleFunc(cm ContextMaker, resp WebHealthResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		resp.OnResult(w, c.handle(ctx))
+	}
+}
+
+func (c *Health) handle(ctx context.Context) *HealthResult {
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"controller": "Health",
+	})
+
+	result := &HealthResult{
+		Status: HealthStatusOk,
+		Checks: make([]HealthCheckResult, len(c.Checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range c.Checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			result.Checks[i] = c.runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, checkResult := range result.Checks {
+		if checkResult.Status != HealthStatusOk {
+			result.Status = HealthStatusFail
+		}
+	}
+	return result
+}
+
+func (c *Health) runCheck(ctx context.Context, check HealthCheck) HealthCheckResult {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := c.checkWithin(ctx, check.Checker)
+	result := HealthCheckResult{
+		Name:       check.Name,
+		Status:     HealthStatusOk,
+		DurationMs: int64(time.Since(start) / time.Millisecond),
+	}
+	if err != nil {
+		ctxlogrus.Get(ctx).WithField("check", check.Name).Warn("Health check failed: " + err.Error())
+		result.Status = HealthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkWithin returns when the context is done, even if the checker doesn't respect its deadline.
+func (c *Health) checkWithin(ctx context.Context, checker HealthChecker) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- checker.CheckHealth(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
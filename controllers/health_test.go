@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHealth_HandleFunc_Live(t *testing.T) {
+	t.Parallel()
+
+	calledOnResult := false
+	r := newTestWebHealthResponder(t)
+	r.OnResultFunc = func(w http.ResponseWriter, result *HealthResult) {
+		calledOnResult = true
+		if result.Status != HealthStatusOk {
+			t.Errorf("Expected status %s, got %s", HealthStatusOk, result.Status)
+		}
+		if len(result.Checks) != 0 {
+			t.Errorf("Expected no checks, got %v", result.Checks)
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &Health{}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnResult {
+		t.Error("Expected responder's OnResult method to be called, was not called")
+	}
+}
+
+func TestHealth_HandleFunc_Ready(t *testing.T) {
+	t.Parallel()
+
+	cache := newTestHealthChecker(t)
+	cache.CheckHealthFunc = func(ctx context.Context) error {
+		return nil
+	}
+	store := newTestHealthChecker(t)
+	store.CheckHealthFunc = func(ctx context.Context) error {
+		return errors.New("bluh")
+	}
+
+	calledOnResult := false
+	r := newTestWebHealthResponder(t)
+	r.OnResultFunc = func(w http.ResponseWriter, result *HealthResult) {
+		calledOnResult = true
+		if result.Status != HealthStatusFail {
+			t.Errorf("Expected status %s, got %s", HealthStatusFail, result.Status)
+		}
+		if len(result.Checks) != 2 {
+			t.Fatalf("Expected 2 checks, got %v", result.Checks)
+		}
+		if result.Checks[0].Name != "cache" || result.Checks[0].Status != HealthStatusOk || result.Checks[0].Error != "" {
+			t.Errorf("Expected cache check to pass, got %+v", result.Checks[0])
+		}
+		if result.Checks[1].Name != "store" || result.Checks[1].Status != HealthStatusFail || result.Checks[1].Error != "bluh" {
+			t.Errorf("Expected store check to fail, got %+v", result.Checks[1])
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &Health{
+		Checks: []HealthCheck{
+			{Name: "cache", Checker: cache},
+			{Name: "store", Checker: store},
+		},
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnResult {
+		t.Error("Expected responder's OnResult method to be called, was not called")
+	}
+}
+
+func TestHealth_HandleFunc_Timeout(t *testing.T) {
+	t.Parallel()
+
+	unblock := make(chan struct{})
+	defer close(unblock)
+	hung := newTestHealthChecker(t)
+	hung.CheckHealthFunc = func(ctx context.Context) error {
+		// Ignores its deadline, as a misbehaving client might.
+		<-unblock
+		return nil
+	}
+
+	calledOnResult := false
+	r := newTestWebHealthResponder(t)
+	r.OnResultFunc = func(w http.ResponseWriter, result *HealthResult) {
+		calledOnResult = true
+		if result.Status != HealthStatusFail {
+			t.Errorf("Expected status %s, got %s", HealthStatusFail, result.Status)
+		}
+		if len(result.Checks) != 1 || result.Checks[0].Error != context.DeadlineExceeded.Error() {
+			t.Errorf("Expected check to time out, got %+v", result.Checks)
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &Health{
+		Checks: []HealthCheck{
+			{Name: "predictor", Checker: hung},
+		},
+		Timeout: 10 * time.Millisecond,
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnResult {
+		t.Error("Expected responder's OnResult method to be called, was not called")
+	}
+}
+
+func TestHealth_HandleFunc_ContextError(t *testing.T) {
+	t.Parallel()
+
+	calledOnContextError := false
+	r := newTestWebHealthResponder(t)
+	r.OnContextErrorFunc = func(w http.ResponseWriter, err error) {
+		calledOnContextError = true
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return nil, errors.New("bluh")
+	}
+
+	c := &Health{}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnContextError {
+		t.Error("Expected responder's OnContextError method to be called, was not called")
+	}
+}
+
+func newTestWebHealthResponder(t *testing.T) *testWebHealthResponder {
+	return &testWebHealthResponder{
+		OnContextErrorFunc: func(w http.ResponseWriter, err error) {
+			t.Error("OnContextErrorFunc should not be called")
+		},
+		OnResultFunc: func(w http.ResponseWriter, r *HealthResult) {
+			t.Error("OnResultFunc should not be called")
+		},
+	}
+}
+
+type testWebHealthResponder struct {
+	OnContextErrorFunc func(w http.ResponseWriter, err error)
+	OnResultFunc       func(w http.ResponseWriter, r *HealthResult)
+}
+
+func (r *testWebHealthResponder) OnContextError(w http.ResponseWriter, err error) {
+	r.OnContextErrorFunc(w, err)
+}
+
+func (r *testWebHealthResponder) OnResult(w http.ResponseWriter, result *HealthResult) {
+	r.OnResultFunc(w, result)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
 	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/moonbird-predictor-frontend/tracing"
 	"github.com/sirupsen/logrus"
 	"net/http"
 	"strconv"
@@ -52,6 +53,9 @@ func (c *Index) handle(ctx context.Context, input *IndexInput) *IndexResult {
 	})
 	l := ctxlogrus.Get(ctx)
 
+	ctx, span := tracing.Start(ctx, "Index.handle")
+	defer span.End()
+
 	var prediction *float64
 	var err error
 
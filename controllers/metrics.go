@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type Metrics struct {
+	MetricsSource MetricsSource
+}
+
+type MetricsResult struct {
+	Content []byte
+}
+
+type WebMetricsResponder interface {
+	OnContextError(w http.ResponseWriter, err error)
+	OnError(ctx context.Context, w http.ResponseWriter, err error)
+	OnResult(w http.ResponseWriter, r *MetricsResult)
+}
+
+func (c *Metrics) HandleFunc(cm ContextMaker, resp WebMetricsResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		result, err := c.handle(ctx)
+		if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnResult(w, result)
+		}
+	}
+}
+
+func (c *Metrics) handle(ctx context.Context) (*MetricsResult, error) {
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"controller": "Metrics",
+	})
+
+	var buf bytes.Buffer
+	err := c.MetricsSource.WriteText(&buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	return &MetricsResult{Content: buf.Bytes()}, nil
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestMetrics_HandleFunc_Success(t *testing.T) {
+	t.Parallel()
+
+	ms := newTestMetricsSource(t)
+	ms.WriteTextFunc = func(w io.Writer) error {
+		_, err := w.Write([]byte("test_total 3\n"))
+		return err
+	}
+
+	calledOnResult := false
+	r := newTestWebMetricsResponder(t)
+	r.OnResultFunc = func(w http.ResponseWriter, result *MetricsResult) {
+		calledOnResult = true
+		if string(result.Content) != "test_total 3\n" {
+			t.Errorf("Expected result to contain the metrics, got %q", result.Content)
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &Metrics{
+		MetricsSource: ms,
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnResult {
+		t.Error("Expected responder's OnResult method to be called, was not called")
+	}
+}
+
+func TestMetrics_HandleFunc_Error(t *testing.T) {
+	t.Parallel()
+
+	ms := newTestMetricsSource(t)
+	ms.WriteTextFunc = func(w io.Writer) error {
+		return errors.New("bluh")
+	}
+
+	calledOnError := false
+	r := newTestWebMetricsResponder(t)
+	r.OnErrorFunc = func(ctx context.Context, w http.ResponseWriter, err error) {
+		calledOnError = true
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &Metrics{
+		MetricsSource: ms,
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnError {
+		t.Error("Expected responder's OnError method to be called, was not called")
+	}
+}
+
+func TestMetrics_HandleFunc_ContextError(t *testing.T) {
+	t.Parallel()
+
+	calledOnContextError := false
+	r := newTestWebMetricsResponder(t)
+	r.OnContextErrorFunc = func(w http.ResponseWriter, err error) {
+		calledOnContextError = true
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return nil, errors.New("bluh")
+	}
+
+	c := &Metrics{}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnContextError {
+		t.Error("Expected responder's OnContextError method to be called, was not called")
+	}
+}
+
+func newTestWebMetricsResponder(t *testing.T) *testWebMetricsResponder {
+	return &testWebMetricsResponder{
+		OnContextErrorFunc: func(w http.ResponseWriter, err error) {
+			t.Error("OnContextErrorFunc should not be called")
+		},
+		OnErrorFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+			t.Error("OnErrorFunc should not be called")
+		},
+		OnResultFunc: func(w http.ResponseWriter, r *MetricsResult) {
+			t.Error("OnResultFunc should not be called")
+		},
+	}
+}
+
+type testWebMetricsResponder struct {
+	OnContextErrorFunc func(w http.ResponseWriter, err error)
+	OnErrorFunc        func(ctx context.Context, w http.ResponseWriter, err error)
+	OnResultFunc       func(w http.ResponseWriter, r *MetricsResult)
+}
+
+func (r *testWebMetricsResponder) OnContextError(w http.ResponseWriter, err error) {
+	r.OnContextErrorFunc(w, err)
+}
+
+func (r *testWebMetricsResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	r.OnErrorFunc(ctx, w, err)
+}
+
+func (r *testWebMetricsResponder) OnResult(w http.ResponseWriter, result *MetricsResult) {
+	r.OnResultFunc(w, result)
+}
@@ -3,15 +3,28 @@ package controllers
 import (
 	"context"
 	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// ModelRetrainTaskPath is where manual retrains enqueued on a TaskQueue are run.
+const ModelRetrainTaskPath = "/cron/ml-retrain-manual"
+
 type ModelRetrain struct {
 	Trainer         ModelTrainer
 	PredictionCache PredictionCache
+
+	// Background runs manual retrains, which take far longer than a request should.
+	Background BackgroundRunner
+
+	// TaskQueue, if set, runs manual retrains as tasks at ModelRetrainTaskPath, in place of Background.
+	TaskQueue TaskQueue
 }
 
 type WebModelRetrainResponder interface {
@@ -48,3 +61,202 @@ func (c *ModelRetrain) handle(ctx context.Context) error {
 	}
 	return errors.Wrap(c.PredictionCache.Flush(ctx), "")
 }
+
+// HandleTaskFunc runs a manual retrain enqueued as a task, taking the same form values as the admin page.
+func (c *ModelRetrain) HandleTaskFunc(cm ContextMaker, resp WebExamplesUpdateResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		err = c.handleTask(ctx, manualInputFromRequest(r), time.Now())
+		if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnSuccess(w)
+		}
+	}
+}
+
+func (c *ModelRetrain) handleTask(ctx context.Context, input *ModelRetrainManualInput, now time.Time) error {
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"controller": "ModelRetrainTask",
+	})
+
+	opts, validationErrs := ParseRetrainOptions(input, now)
+	if len(validationErrs) > 0 {
+		return errors.Errorf("invalid retrain options: %s", strings.Join(validationErrs, " "))
+	}
+	return errors.Wrap(c.runManual(ctx, *opts), "")
+}
+
+// runManual runs a manual retrain, flushing cached predictions if it promoted a new model.
+func (c *ModelRetrain) runManual(ctx context.Context, opts data.RetrainOptions) error {
+	err := c.Trainer.RetrainWithOptions(ctx, opts)
+	if err == nil && !opts.SkipPromotion {
+		err = c.PredictionCache.Flush(ctx)
+	}
+	return errors.Wrap(err, "")
+}
+
+type ModelRetrainManualInput struct {
+	Submitted     bool
+	Confirmed     bool
+	BaseModelStr  string
+	CutoffStr     string
+	SkipPromotion bool
+	FullRebuild   bool
+}
+
+type ModelRetrainManualResult struct {
+	Input            *ModelRetrainManualInput
+	Options          *data.RetrainOptions
+	ValidationErrs   []string
+	NeedConfirmation bool
+	Started          bool
+	StartErr         string
+}
+
+type WebModelRetrainManualResponder interface {
+	OnContextError(w http.ResponseWriter, err error)
+	OnResult(w http.ResponseWriter, r *ModelRetrainManualResult)
+}
+
+// Cutoffs may be given as from a datetime-local form input, or in full RFC 3339 form.
+var retrainCutoffFormats = []string{
+	"2006-01-02T15:04",
+	time.RFC3339,
+}
+
+func (c *ModelRetrain) HandleManualFunc(cm ContextMaker, resp WebModelRetrainManualResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		result := c.handleManual(ctx, manualInputFromRequest(r), time.Now())
+		resp.OnResult(w, result)
+	}
+}
+
+func manualInputFromRequest(r *http.Request) *ModelRetrainManualInput {
+	// Only POST requests may start a retrain; anything else just displays the form.
+	return &ModelRetrainManualInput{
+		Submitted:     r.Method == http.MethodPost,
+		Confirmed:     r.Method == http.MethodPost && r.FormValue("confirm") == "yes",
+		BaseModelStr:  strings.TrimSpace(r.FormValue("base-model")),
+		CutoffStr:     strings.TrimSpace(r.FormValue("cutoff")),
+		SkipPromotion: r.FormValue("skip-promotion") == "yes",
+		FullRebuild:   r.FormValue("full-rebuild") == "yes",
+	}
+}
+
+func (c *ModelRetrain) handleManual(ctx context.Context, input *ModelRetrainManualInput, now time.Time) *ModelRetrainManualResult {
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"controller": "ModelRetrainManual",
+	})
+	l := ctxlogrus.Get(ctx)
+
+	result := &ModelRetrainManualResult{
+		Input: input,
+	}
+	if !input.Submitted {
+		return result
+	}
+
+	result.Options, result.ValidationErrs = ParseRetrainOptions(input, now)
+	if len(result.ValidationErrs) > 0 {
+		result.Options = nil
+		return result
+	}
+	if !input.Confirmed {
+		result.NeedConfirmation = true
+		return result
+	}
+
+	l.Infof("Starting manual retrain with options %+v", *result.Options)
+	opts := *result.Options
+	if c.TaskQueue != nil {
+		err := c.TaskQueue.Enqueue(ctx, ModelRetrainTaskPath, retrainTaskParams(opts))
+		if err != nil {
+			l.Errorf("Unable to enqueue manual retrain: %s", err)
+			result.StartErr = err.Error()
+			return result
+		}
+		result.Started = true
+		return result
+	}
+
+	c.Background.RunInBackground(ctx, func(ctx context.Context) {
+		if err := c.runManual(ctx, opts); err != nil {
+			ctxlogrus.Get(ctx).Errorf("Manual retrain failed: %s", err)
+		}
+	})
+	result.Started = true
+	return result
+}
+
+// retrainTaskParams gives the form values a retrain task is run with, as the admin page's confirmation form submits them.
+func retrainTaskParams(opts data.RetrainOptions) url.Values {
+	params := url.Values{
+		"confirm": []string{"yes"},
+		"cutoff":  []string{opts.Cutoff.UTC().Format(time.RFC3339)},
+	}
+	if opts.BaseModel != 0 {
+		params.Set("base-model", strconv.FormatInt(opts.BaseModel, 10))
+	}
+	if opts.SkipPromotion {
+		params.Set("skip-promotion", "yes")
+	}
+	if opts.FullRebuild {
+		params.Set("full-rebuild", "yes")
+	}
+	return params
+}
+
+// ParseRetrainOptions validates the options for a manual retrain, given by the admin page or the moonbird command.
+func ParseRetrainOptions(input *ModelRetrainManualInput, now time.Time) (opts *data.RetrainOptions, validationErrs []string) {
+	opts = &data.RetrainOptions{
+		Cutoff:        now,
+		SkipPromotion: input.SkipPromotion,
+		FullRebuild:   input.FullRebuild,
+	}
+
+	if input.CutoffStr != "" {
+		parsed := false
+		for _, format := range retrainCutoffFormats {
+			cutoff, err := time.ParseInLocation(format, input.CutoffStr, time.UTC)
+			if err == nil {
+				opts.Cutoff = cutoff
+				parsed = true
+				break
+			}
+		}
+		if !parsed {
+			validationErrs = append(validationErrs, "Cutoff must be a time in the form YYYY-MM-DDTHH:MM.")
+		} else if opts.Cutoff.After(now) {
+			validationErrs = append(validationErrs, "Cutoff must not be in the future.")
+		}
+	}
+
+	if input.BaseModelStr != "" {
+		baseModel, err := strconv.ParseInt(input.BaseModelStr, 10, 64)
+		if err != nil || baseModel <= 0 {
+			validationErrs = append(validationErrs, "Base model must be a positive model number.")
+		} else if baseModel >= opts.Cutoff.Unix() {
+			validationErrs = append(validationErrs, "Base model must be from before the cutoff.")
+		} else {
+			opts.BaseModel = baseModel
+		}
+
+		if input.FullRebuild {
+			validationErrs = append(validationErrs, "A full rebuild does not use a base model.")
+		}
+	}
+
+	return opts, validationErrs
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
 	"net/http"
+	"net/url"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -185,3 +188,370 @@ type testPredictionCache struct {
 func (c *testPredictionCache) Flush(ctx context.Context) error {
 	return c.FlushFunc(ctx)
 }
+
+func TestModelRetrain_HandleManualFunc_Form(t *testing.T) {
+	t.Parallel()
+
+	calledOnResult := false
+	r := newTestWebModelRetrainManualResponder(t)
+	r.OnResultFunc = func(w http.ResponseWriter, result *ModelRetrainManualResult) {
+		calledOnResult = true
+		if result.Input.Submitted {
+			t.Error("Expected GET request not to count as a submission")
+		}
+		if result.NeedConfirmation || result.Started {
+			t.Error("Expected form to be displayed without confirmation or retraining")
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &ModelRetrain{
+		Trainer: newTestModelTrainer(t),
+	}
+	handler := c.HandleManualFunc(cm, r)
+	handler(nil, &http.Request{
+		Method: "GET",
+		Form: url.Values{
+			"confirm": []string{"yes"},
+		},
+	})
+
+	if !calledOnResult {
+		t.Error("Expected responder's OnResult method to be called, was not called")
+	}
+}
+
+func TestModelRetrain_HandleManual_Invalid(t *testing.T) {
+	t.Parallel()
+
+	c := &ModelRetrain{
+		Trainer: newTestModelTrainer(t),
+	}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		input *ModelRetrainManualInput
+	}{
+		{"bad cutoff", &ModelRetrainManualInput{CutoffStr: "bluh"}},
+		{"future cutoff", &ModelRetrainManualInput{CutoffStr: "2020-01-02T00:00"}},
+		{"bad base model", &ModelRetrainManualInput{BaseModelStr: "bluh"}},
+		{"negative base model", &ModelRetrainManualInput{BaseModelStr: "-5"}},
+		{"base model after cutoff", &ModelRetrainManualInput{BaseModelStr: "1577836800", CutoffStr: "2019-12-31T00:00"}},
+		{"base model with full rebuild", &ModelRetrainManualInput{BaseModelStr: "123", FullRebuild: true}},
+	}
+	for _, test := range tests {
+		test.input.Submitted = true
+		test.input.Confirmed = true
+
+		result := c.handleManual(context.Background(), test.input, now)
+		if len(result.ValidationErrs) != 1 {
+			t.Errorf("%s: expected one validation error, got %v", test.name, result.ValidationErrs)
+		}
+		if result.Options != nil || result.NeedConfirmation || result.Started {
+			t.Errorf("%s: expected invalid input to be neither confirmed nor run", test.name)
+		}
+	}
+}
+
+func TestModelRetrain_HandleManual_NeedConfirmation(t *testing.T) {
+	t.Parallel()
+
+	c := &ModelRetrain{
+		Trainer: newTestModelTrainer(t),
+	}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	result := c.handleManual(context.Background(), &ModelRetrainManualInput{
+		Submitted:     true,
+		BaseModelStr:  "123",
+		CutoffStr:     "2019-12-01T12:30",
+		SkipPromotion: true,
+	}, now)
+
+	if len(result.ValidationErrs) != 0 {
+		t.Errorf("Expected no validation errors, got %v", result.ValidationErrs)
+	}
+	if !result.NeedConfirmation {
+		t.Error("Expected unconfirmed submission to need confirmation")
+	}
+	if result.Started {
+		t.Error("Expected unconfirmed submission not to start a retrain")
+	}
+
+	wantOpts := data.RetrainOptions{
+		Cutoff:        time.Date(2019, 12, 1, 12, 30, 0, 0, time.UTC),
+		BaseModel:     123,
+		SkipPromotion: true,
+	}
+	if result.Options == nil || !reflect.DeepEqual(*result.Options, wantOpts) {
+		t.Errorf("Expected options %+v, got %+v", wantOpts, result.Options)
+	}
+}
+
+func TestModelRetrain_HandleManualFunc_Confirmed(t *testing.T) {
+	t.Parallel()
+
+	calledRetrain := false
+	tr := newTestModelTrainer(t)
+	tr.RetrainWithOptionsFunc = func(ctx context.Context, opts data.RetrainOptions) error {
+		calledRetrain = true
+		if !opts.FullRebuild || opts.SkipPromotion || opts.BaseModel != 0 {
+			t.Errorf("Unexpected retrain options: %+v", opts)
+		}
+		return nil
+	}
+
+	calledFlush := false
+	cache := newTestPredictionCache(t)
+	cache.FlushFunc = func(ctx context.Context) error {
+		calledFlush = true
+		return nil
+	}
+
+	calledOnResult := false
+	r := newTestWebModelRetrainManualResponder(t)
+	r.OnResultFunc = func(w http.ResponseWriter, result *ModelRetrainManualResult) {
+		calledOnResult = true
+		if !result.Started {
+			t.Error("Expected result to be marked started")
+		}
+		if calledRetrain {
+			t.Error("Expected retrain not to run within the request")
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	var background func(ctx context.Context)
+	br := newTestBackgroundRunner(t)
+	br.RunInBackgroundFunc = func(ctx context.Context, f func(ctx context.Context)) {
+		background = f
+	}
+
+	c := &ModelRetrain{
+		Trainer:         tr,
+		PredictionCache: cache,
+		Background:      br,
+	}
+	form := url.Values{
+		"confirm":      []string{"yes"},
+		"full-rebuild": []string{"yes"},
+	}
+	handler := c.HandleManualFunc(cm, r)
+	handler(nil, &http.Request{
+		Method:   "POST",
+		Form:     form,
+		PostForm: form,
+	})
+
+	if background == nil {
+		t.Fatal("Expected retrain to be run in the background, was not")
+	}
+	background(context.Background())
+	if !calledRetrain {
+		t.Error("Expected retrain to be called, was not called")
+	}
+	if !calledFlush {
+		t.Error("Expected cache flush to be called, was not called")
+	}
+	if !calledOnResult {
+		t.Error("Expected responder's OnResult method to be called, was not called")
+	}
+}
+
+func TestModelRetrain_HandleManual_SkipPromotionError(t *testing.T) {
+	t.Parallel()
+
+	tr := newTestModelTrainer(t)
+	tr.RetrainWithOptionsFunc = func(ctx context.Context, opts data.RetrainOptions) error {
+		if !opts.SkipPromotion {
+			t.Error("Expected retrain to skip promotion")
+		}
+		return errors.New("bluh")
+	}
+
+	calledRetrain := false
+	br := newTestBackgroundRunner(t)
+	br.RunInBackgroundFunc = func(ctx context.Context, f func(ctx context.Context)) {
+		f(ctx)
+		calledRetrain = true
+	}
+
+	c := &ModelRetrain{
+		Trainer:         tr,
+		PredictionCache: newTestPredictionCache(t),
+		Background:      br,
+	}
+	result := c.handleManual(context.Background(), &ModelRetrainManualInput{
+		Submitted:     true,
+		Confirmed:     true,
+		SkipPromotion: true,
+	}, time.Now())
+
+	if !calledRetrain {
+		t.Error("Expected retrain to be run in the background, was not")
+	}
+	if !result.Started {
+		t.Error("Expected result to be marked started")
+	}
+}
+
+func TestModelRetrain_HandleManual_TaskQueue(t *testing.T) {
+	t.Parallel()
+
+	var enqueued url.Values
+	q := newTestTaskQueue(t)
+	q.EnqueueFunc = func(ctx context.Context, path string, params url.Values) error {
+		if path != ModelRetrainTaskPath {
+			t.Errorf("Expected task for %s, got %s", ModelRetrainTaskPath, path)
+		}
+		enqueued = params
+		return nil
+	}
+
+	c := &ModelRetrain{
+		Trainer:    newTestModelTrainer(t),
+		Background: newTestBackgroundRunner(t),
+		TaskQueue:  q,
+	}
+	now := time.Date(2019, time.March, 1, 12, 30, 0, 0, time.UTC)
+	result := c.handleManual(context.Background(), &ModelRetrainManualInput{
+		Submitted:     true,
+		Confirmed:     true,
+		BaseModelStr:  "123",
+		SkipPromotion: true,
+	}, now)
+
+	if !result.Started {
+		t.Error("Expected result to be marked started")
+	}
+	want := url.Values{
+		"confirm":        []string{"yes"},
+		"cutoff":         []string{"2019-03-01T12:30:00Z"},
+		"base-model":     []string{"123"},
+		"skip-promotion": []string{"yes"},
+	}
+	if !reflect.DeepEqual(enqueued, want) {
+		t.Errorf("Expected task params %v, got %v", want, enqueued)
+	}
+}
+
+func TestModelRetrain_HandleManual_TaskQueueError(t *testing.T) {
+	t.Parallel()
+
+	q := newTestTaskQueue(t)
+	q.EnqueueFunc = func(ctx context.Context, path string, params url.Values) error {
+		return errors.New("bluh")
+	}
+
+	c := &ModelRetrain{
+		Trainer:    newTestModelTrainer(t),
+		Background: newTestBackgroundRunner(t),
+		TaskQueue:  q,
+	}
+	result := c.handleManual(context.Background(), &ModelRetrainManualInput{
+		Submitted: true,
+		Confirmed: true,
+	}, time.Now())
+
+	if result.Started {
+		t.Error("Expected result not to be marked started")
+	}
+	if result.StartErr == "" {
+		t.Error("Expected an error starting the retrain, got none")
+	}
+}
+
+func TestModelRetrain_HandleTaskFunc(t *testing.T) {
+	t.Parallel()
+
+	calledRetrain := false
+	tr := newTestModelTrainer(t)
+	tr.RetrainWithOptionsFunc = func(ctx context.Context, opts data.RetrainOptions) error {
+		calledRetrain = true
+		want := data.RetrainOptions{
+			Cutoff:      time.Date(2019, time.March, 1, 12, 30, 0, 0, time.UTC),
+			FullRebuild: true,
+		}
+		if !reflect.DeepEqual(opts, want) {
+			t.Errorf("Expected retrain options %+v, got %+v", want, opts)
+		}
+		return nil
+	}
+
+	calledFlush := false
+	cache := newTestPredictionCache(t)
+	cache.FlushFunc = func(ctx context.Context) error {
+		calledFlush = true
+		return nil
+	}
+
+	calledOnSuccess := false
+	r := newTestWebModelRetrainResponder(t)
+	r.OnSuccessFunc = func(w http.ResponseWriter) {
+		calledOnSuccess = true
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &ModelRetrain{
+		Trainer:         tr,
+		PredictionCache: cache,
+	}
+	form := url.Values{
+		"confirm":      []string{"yes"},
+		"cutoff":       []string{"2019-03-01T12:30:00Z"},
+		"full-rebuild": []string{"yes"},
+	}
+	handler := c.HandleTaskFunc(cm, r)
+	handler(nil, &http.Request{
+		Method:   "POST",
+		Form:     form,
+		PostForm: form,
+	})
+
+	if !calledRetrain {
+		t.Error("Expected retrain to be called within the task, was not called")
+	}
+	if !calledFlush {
+		t.Error("Expected cache flush to be called, was not called")
+	}
+	if !calledOnSuccess {
+		t.Error("Expected responder's OnSuccess method to be called, was not called")
+	}
+}
+
+func newTestWebModelRetrainManualResponder(t *testing.T) *testWebModelRetrainManualResponder {
+	return &testWebModelRetrainManualResponder{
+		OnContextErrorFunc: func(w http.ResponseWriter, err error) {
+			t.Error("OnContextErrorFunc should not be called")
+		},
+		OnResultFunc: func(w http.ResponseWriter, r *ModelRetrainManualResult) {
+			t.Error("OnResultFunc should not be called")
+		},
+	}
+}
+
+type testWebModelRetrainManualResponder struct {
+	OnContextErrorFunc func(w http.ResponseWriter, err error)
+	OnResultFunc       func(w http.ResponseWriter, r *ModelRetrainManualResult)
+}
+
+func (r *testWebModelRetrainManualResponder) OnContextError(w http.ResponseWriter, err error) {
+	r.OnContextErrorFunc(w, err)
+}
+
+func (r *testWebModelRetrainManualResponder) OnResult(w http.ResponseWriter, result *ModelRetrainManualResult) {
+	r.OnResultFunc(w, result)
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSRFCookieName is the cookie holding the token each form on the questions page must echo,
+// so other sites can't submit them on a signed in user's behalf.
+const CSRFCookieName = "moonbird-csrf"
+
+// Questions lets signed in users ask their own questions, forecast on them, and resolve the questions they asked.
+// Admins may resolve any question.
+type Questions struct {
+	QuestionStore QuestionStore
+	UserService   UserService
+}
+
+type QuestionsInput struct {
+	Submitted      bool
+	Action         string
+	Title          string
+	DeadlineStr    string
+	QuestionStr    string
+	ProbabilityStr string
+	OutcomeStr     string
+
+	// CSRFCookie is the token from the user's cookie, and CSRFToken the one submitted with the form.
+	CSRFCookie string
+	CSRFToken  string
+}
+
+type QuestionsResult struct {
+	User           string
+	Input          *QuestionsInput
+	ValidationErrs []string
+	Questions      []QuestionView
+
+	// CSRFToken is to be included in each form. If NewCSRFToken is set, the user had none,
+	// and it must also be set as their CSRFCookieName cookie.
+	CSRFToken    string
+	NewCSRFToken bool
+}
+
+type QuestionView struct {
+	data.InternalQuestion
+
+	// UserForecast is the signed in user's latest forecast on the question, if any.
+	UserForecast *data.Forecast
+
+	CanResolve bool
+}
+
+type WebQuestionsResponder interface {
+	OnContextError(w http.ResponseWriter, err error)
+	OnError(ctx context.Context, w http.ResponseWriter, err error)
+	OnUnauthenticated(w http.ResponseWriter)
+	OnResult(w http.ResponseWriter, r *QuestionsResult)
+}
+
+func (c *Questions) HandleFunc(cm ContextMaker, resp WebQuestionsResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		user := c.UserService.ContextUser(ctx)
+		if user == "" {
+			resp.OnUnauthenticated(w)
+			return
+		}
+
+		// Only POST requests may change questions; anything else just lists them.
+		input := &QuestionsInput{
+			Submitted:      r.Method == http.MethodPost,
+			Action:         r.FormValue("action"),
+			Title:          strings.TrimSpace(r.FormValue("title")),
+			DeadlineStr:    strings.TrimSpace(r.FormValue("deadline")),
+			QuestionStr:    r.FormValue("question"),
+			ProbabilityStr: strings.TrimSpace(r.FormValue("probability")),
+			OutcomeStr:     r.FormValue("outcome"),
+			CSRFToken:      r.PostFormValue("csrf_token"),
+		}
+		if cookie, err := r.Cookie(CSRFCookieName); err == nil {
+			input.CSRFCookie = cookie.Value
+		}
+		result, err := c.handle(ctx, user, c.UserService.ContextUserIsAdmin(ctx), input)
+		if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnResult(w, result)
+		}
+	}
+}
+
+func (c *Questions) handle(ctx context.Context, user string, admin bool, input *QuestionsInput) (*QuestionsResult, error) {
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"controller": "Questions",
+	})
+
+	result := &QuestionsResult{
+		User:      user,
+		Input:     input,
+		CSRFToken: input.CSRFCookie,
+	}
+	if result.CSRFToken == "" {
+		result.CSRFToken = newCSRFToken()
+		result.NewCSRFToken = true
+	}
+
+	if input.Submitted {
+		if input.CSRFCookie == "" || subtle.ConstantTimeCompare([]byte(input.CSRFCookie), []byte(input.CSRFToken)) != 1 {
+			result.ValidationErrs = []string{"Your form expired; please try again."}
+		} else {
+			validationErrs, err := c.apply(ctx, user, admin, input)
+			if err != nil {
+				return nil, errors.Wrap(err, "")
+			}
+			result.ValidationErrs = validationErrs
+		}
+	}
+
+	questions, err := c.QuestionStore.ListQuestions(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	// Newest questions first.
+	for i := len(questions) - 1; i >= 0; i-- {
+		view := QuestionView{
+			InternalQuestion: questions[i],
+			CanResolve:       admin || questions[i].Creator == user,
+		}
+		for j := range questions[i].Forecasts {
+			if questions[i].Forecasts[j].User == user {
+				view.UserForecast = &questions[i].Forecasts[j]
+			}
+		}
+		result.Questions = append(result.Questions, view)
+	}
+	return result, nil
+}
+
+// apply makes the change the user submitted, returning any problems with their input.
+func (c *Questions) apply(ctx context.Context, user string, admin bool, input *QuestionsInput) ([]string, error) {
+	var err error
+	switch input.Action {
+	case "create":
+		if input.Title == "" {
+			return []string{"Questions must have a title."}, nil
+		}
+		if input.DeadlineStr == "" {
+			return []string{"Questions must have a deadline."}, nil
+		}
+		deadline, parseErr := time.ParseInLocation("2006-01-02", input.DeadlineStr, time.UTC)
+		if parseErr != nil {
+			return []string{"Deadline must be a date in the form YYYY-MM-DD."}, nil
+		}
+		_, err = c.QuestionStore.CreateQuestion(ctx, user, input.Title, deadline)
+
+	case "forecast":
+		id, parseErr := strconv.ParseInt(input.QuestionStr, 10, 64)
+		if parseErr != nil {
+			return []string{"No such question."}, nil
+		}
+		probability, parseErr := strconv.ParseFloat(input.ProbabilityStr, 64)
+		if parseErr != nil || !(probability >= 0 && probability <= 1) {
+			return []string{"Probability must be a number between 0 and 1."}, nil
+		}
+		err = c.QuestionStore.SubmitForecast(ctx, id, user, probability)
+
+	case "resolve":
+		id, parseErr := strconv.ParseInt(input.QuestionStr, 10, 64)
+		if parseErr != nil {
+			return []string{"No such question."}, nil
+		}
+		var outcome data.QuestionOutcome
+		switch input.OutcomeStr {
+		case "yes":
+			outcome = data.ResolvedYes
+		case "no":
+			outcome = data.ResolvedNo
+		default:
+			return []string{"Outcome must be yes or no."}, nil
+		}
+		err = c.QuestionStore.ResolveQuestion(ctx, id, user, admin, outcome)
+
+	default:
+		return []string{"Unknown action."}, nil
+	}
+
+	switch errors.Cause(err) {
+	case nil:
+		return nil, nil
+	case data.ErrNoSuchQuestion:
+		return []string{"No such question."}, nil
+	case data.ErrQuestionResolved:
+		return []string{"That question has already been resolved."}, nil
+	case data.ErrNotQuestionCreator:
+		return []string{"Only the question's creator or an admin may resolve it."}, nil
+	default:
+		return nil, errors.Wrap(err, "")
+	}
+}
+
+func newCSRFToken() string {
+	var token [16]byte
+	_, _ = rand.Read(token[:])
+	return hex.EncodeToString(token[:])
+}
@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newQuestionsRequest(form url.Values) *http.Request {
+	if form == nil {
+		return httptest.NewRequest(http.MethodGet, "/questions", nil)
+	}
+	if _, ok := form["csrf_token"]; !ok {
+		form.Set("csrf_token", testCSRFToken)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/questions", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: testCSRFToken})
+	return r
+}
+
+const testCSRFToken = "0123456789abcdef"
+
+func TestQuestions_HandleFunc_List(t *testing.T) {
+	t.Parallel()
+
+	qs := newTestQuestionStore(t)
+	qs.ListQuestionsFunc = func(ctx context.Context) ([]data.InternalQuestion, error) {
+		return []data.InternalQuestion{
+			{Question: data.Question{Id: -1}, Forecasts: []data.Forecast{
+				{User: "bob", Probability: 0.2},
+				{User: "alice", Probability: 0.3},
+				{User: "bob", Probability: 0.4},
+			}},
+			{Question: data.Question{Id: -2}},
+		}, nil
+	}
+
+	calledOnResult := false
+	resp := newTestWebQuestionsResponder(t)
+	resp.OnResultFunc = func(w http.ResponseWriter, r *QuestionsResult) {
+		calledOnResult = true
+		if r.User != "bob" {
+			t.Errorf("Expected user bob, got %s", r.User)
+		}
+		if len(r.Questions) != 2 || r.Questions[0].Id != -2 || r.Questions[1].Id != -1 {
+			t.Fatalf("Expected questions -2 then -1, got %v", r.Questions)
+		}
+		if r.Questions[0].UserForecast != nil {
+			t.Errorf("Expected no user forecast on question -2, got %v", r.Questions[0].UserForecast)
+		}
+		if f := r.Questions[1].UserForecast; f == nil || f.Probability != 0.4 {
+			t.Errorf("Expected bob's latest forecast of 0.4 on question -1, got %v", f)
+		}
+	}
+
+	c := &Questions{
+		QuestionStore: qs,
+		UserService:   &testUserService{User: "bob"},
+	}
+	c.HandleFunc(newBackgroundContextMaker(t), resp)(nil, newQuestionsRequest(nil))
+
+	if !calledOnResult {
+		t.Error("Expected responder's OnResult method to be called, was not called")
+	}
+}
+
+func TestQuestions_HandleFunc_Unauthenticated(t *testing.T) {
+	t.Parallel()
+
+	calledOnUnauthenticated := false
+	resp := newTestWebQuestionsResponder(t)
+	resp.OnUnauthenticatedFunc = func(w http.ResponseWriter) {
+		calledOnUnauthenticated = true
+	}
+
+	c := &Questions{
+		QuestionStore: newTestQuestionStore(t),
+		UserService:   &testUserService{},
+	}
+	c.HandleFunc(newBackgroundContextMaker(t), resp)(nil, newQuestionsRequest(url.Values{"action": {"create"}, "title": {"Bluh"}}))
+
+	if !calledOnUnauthenticated {
+		t.Error("Expected responder's OnUnauthenticated method to be called, was not called")
+	}
+}
+
+func TestQuestions_HandleFunc_Actions(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		form          url.Values
+		storeErr      error
+		expectCall    string
+		expectInvalid bool
+	}{
+		{form: url.Values{"action": {"create"}, "title": {" Will it rain? "}, "deadline": {"2019-05-01"}}, expectCall: "create"},
+		{form: url.Values{"action": {"create"}, "title": {" "}}, expectInvalid: true},
+		{form: url.Values{"action": {"create"}, "title": {"Bluh"}, "deadline": {"May"}}, expectInvalid: true},
+		{form: url.Values{"action": {"create"}, "title": {"Bluh"}}, expectInvalid: true},
+		{form: url.Values{"action": {"create"}, "title": {"Bluh"}, "deadline": {"2019-05-01"}, "csrf_token": {"forged"}}, expectInvalid: true},
+		{form: url.Values{"action": {"forecast"}, "question": {"-3"}, "probability": {"0.25"}, "csrf_token": {""}}, expectInvalid: true},
+		{form: url.Values{"action": {"forecast"}, "question": {"-3"}, "probability": {"0.25"}}, expectCall: "forecast"},
+		{form: url.Values{"action": {"forecast"}, "question": {"-3"}, "probability": {"1.5"}}, expectInvalid: true},
+		{form: url.Values{"action": {"forecast"}, "question": {"-3"}, "probability": {"NaN"}}, expectInvalid: true},
+		{form: url.Values{"action": {"forecast"}, "question": {"-3"}, "probability": {"0.5"}}, storeErr: data.ErrQuestionResolved, expectCall: "forecast", expectInvalid: true},
+		{form: url.Values{"action": {"resolve"}, "question": {"-3"}, "outcome": {"yes"}}, expectCall: "resolve"},
+		{form: url.Values{"action": {"resolve"}, "question": {"-3"}, "outcome": {"maybe"}}, expectInvalid: true},
+		{form: url.Values{"action": {"resolve"}, "question": {"-9"}, "outcome": {"no"}}, storeErr: data.ErrNoSuchQuestion, expectCall: "resolve", expectInvalid: true},
+		{form: url.Values{"action": {"resolve"}, "question": {"-3"}, "outcome": {"no"}}, storeErr: data.ErrNotQuestionCreator, expectCall: "resolve", expectInvalid: true},
+		{form: url.Values{"action": {"bluh"}}, expectInvalid: true},
+	}
+
+	for i, tc := range testCases {
+		called := ""
+		qs := newTestQuestionStore(t)
+		qs.ListQuestionsFunc = func(ctx context.Context) ([]data.InternalQuestion, error) {
+			return nil, nil
+		}
+		qs.CreateQuestionFunc = func(ctx context.Context, user, title string, deadline time.Time) (*data.Question, error) {
+			called = "create"
+			if user != "bob" || title != "Will it rain?" || !deadline.Equal(time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("Test case %d: unexpected question %s, %s, %v", i, user, title, deadline)
+			}
+			return &data.Question{}, errors.WithStack(tc.storeErr)
+		}
+		qs.SubmitForecastFunc = func(ctx context.Context, id int64, user string, probability float64) error {
+			called = "forecast"
+			if id != -3 || user != "bob" || (tc.storeErr == nil && probability != 0.25) {
+				t.Errorf("Test case %d: unexpected forecast %d, %s, %f", i, id, user, probability)
+			}
+			return errors.WithStack(tc.storeErr)
+		}
+		qs.ResolveQuestionFunc = func(ctx context.Context, id int64, user string, admin bool, outcome data.QuestionOutcome) error {
+			called = "resolve"
+			if user != "bob" || admin {
+				t.Errorf("Test case %d: unexpected resolution by %s, admin %v", i, user, admin)
+			}
+			if tc.storeErr == nil && (id != -3 || outcome != data.ResolvedYes) {
+				t.Errorf("Test case %d: unexpected resolution %d, %v", i, id, outcome)
+			}
+			return errors.WithStack(tc.storeErr)
+		}
+
+		calledOnResult := false
+		resp := newTestWebQuestionsResponder(t)
+		resp.OnResultFunc = func(w http.ResponseWriter, r *QuestionsResult) {
+			calledOnResult = true
+			if (len(r.ValidationErrs) > 0) != tc.expectInvalid {
+				t.Errorf("Test case %d: expected invalid to be %v, got validation errors %v", i, tc.expectInvalid, r.ValidationErrs)
+			}
+		}
+
+		c := &Questions{
+			QuestionStore: qs,
+			UserService:   &testUserService{User: "bob"},
+		}
+		c.HandleFunc(newBackgroundContextMaker(t), resp)(nil, newQuestionsRequest(tc.form))
+
+		if called != tc.expectCall {
+			t.Errorf("Test case %d: expected call '%s', got '%s'", i, tc.expectCall, called)
+		}
+		if !calledOnResult {
+			t.Errorf("Test case %d: expected responder's OnResult method to be called, was not called", i)
+		}
+	}
+}
+
+func TestQuestions_HandleFunc_Permissions(t *testing.T) {
+	t.Parallel()
+
+	qs := newTestQuestionStore(t)
+	qs.ListQuestionsFunc = func(ctx context.Context) ([]data.InternalQuestion, error) {
+		return []data.InternalQuestion{
+			{Question: data.Question{Id: -1, Creator: "bob"}},
+			{Question: data.Question{Id: -2, Creator: "alice"}},
+		}, nil
+	}
+
+	var result *QuestionsResult
+	resp := newTestWebQuestionsResponder(t)
+	resp.OnResultFunc = func(w http.ResponseWriter, r *QuestionsResult) {
+		result = r
+	}
+
+	us := &testUserService{User: "bob"}
+	c := &Questions{
+		QuestionStore: qs,
+		UserService:   us,
+	}
+	c.HandleFunc(newBackgroundContextMaker(t), resp)(nil, newQuestionsRequest(nil))
+
+	if !result.Questions[1].CanResolve || result.Questions[0].CanResolve {
+		t.Errorf("Expected bob to be able to resolve only his own question, got %v", result.Questions)
+	}
+	if !result.NewCSRFToken || len(result.CSRFToken) != 32 {
+		t.Errorf("Expected a new CSRF token for a user without one, got %q", result.CSRFToken)
+	}
+
+	us.Admin = true
+	resolvedAsAdmin := false
+	qs.ResolveQuestionFunc = func(ctx context.Context, id int64, user string, admin bool, outcome data.QuestionOutcome) error {
+		resolvedAsAdmin = admin
+		return nil
+	}
+	c.HandleFunc(newBackgroundContextMaker(t), resp)(nil, newQuestionsRequest(url.Values{"action": {"resolve"}, "question": {"-2"}, "outcome": {"yes"}}))
+
+	if !resolvedAsAdmin {
+		t.Error("Expected an admin's resolution to be made as admin")
+	}
+	if !result.Questions[0].CanResolve || !result.Questions[1].CanResolve {
+		t.Errorf("Expected an admin to be able to resolve every question, got %v", result.Questions)
+	}
+	if result.NewCSRFToken || result.CSRFToken != testCSRFToken {
+		t.Errorf("Expected the user's CSRF token to be kept, got %q", result.CSRFToken)
+	}
+}
+
+func TestQuestions_HandleFunc_StoreErr(t *testing.T) {
+	t.Parallel()
+
+	qs := newTestQuestionStore(t)
+	qs.SubmitForecastFunc = func(ctx context.Context, id int64, user string, probability float64) error {
+		return errors.New("bluh")
+	}
+
+	calledOnError := false
+	resp := newTestWebQuestionsResponder(t)
+	resp.OnErrorFunc = func(ctx context.Context, w http.ResponseWriter, err error) {
+		calledOnError = true
+	}
+
+	c := &Questions{
+		QuestionStore: qs,
+		UserService:   &testUserService{User: "bob"},
+	}
+	c.HandleFunc(newBackgroundContextMaker(t), resp)(nil, newQuestionsRequest(url.Values{"action": {"forecast"}, "question": {"-1"}, "probability": {"0.5"}}))
+
+	if !calledOnError {
+		t.Error("Expected responder's OnError method to be called, was not called")
+	}
+}
+
+func newBackgroundContextMaker(t *testing.T) *testhelpers.ContextMaker {
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (context.Context, error) {
+		return context.Background(), nil
+	}
+	return cm
+}
+
+func newTestWebQuestionsResponder(t *testing.T) *testWebQuestionsResponder {
+	return &testWebQuestionsResponder{
+		OnContextErrorFunc: func(w http.ResponseWriter, err error) {
+			t.Error("OnContextErrorFunc should not be called")
+		},
+		OnErrorFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+			t.Error("OnErrorFunc should not be called")
+		},
+		OnUnauthenticatedFunc: func(w http.ResponseWriter) {
+			t.Error("OnUnauthenticatedFunc should not be called")
+		},
+		OnResultFunc: func(w http.ResponseWriter, r *QuestionsResult) {
+			t.Error("OnResultFunc should not be called")
+		},
+	}
+}
+
+type testWebQuestionsResponder struct {
+	OnContextErrorFunc    func(w http.ResponseWriter, err error)
+	OnErrorFunc           func(ctx context.Context, w http.ResponseWriter, err error)
+	OnUnauthenticatedFunc func(w http.ResponseWriter)
+	OnResultFunc          func(w http.ResponseWriter, r *QuestionsResult)
+}
+
+func (r *testWebQuestionsResponder) OnContextError(w http.ResponseWriter, err error) {
+	r.OnContextErrorFunc(w, err)
+}
+
+func (r *testWebQuestionsResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	r.OnErrorFunc(ctx, w, err)
+}
+
+func (r *testWebQuestionsResponder) OnUnauthenticated(w http.ResponseWriter) {
+	r.OnUnauthenticatedFunc(w)
+}
+
+func (r *testWebQuestionsResponder) OnResult(w http.ResponseWriter, result *QuestionsResult) {
+	r.OnResultFunc(w, result)
+}
@@ -3,7 +3,9 @@ package controllers
 import (
 	"context"
 	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"io"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -15,9 +17,28 @@ type PredictionMaker interface {
 	Predict(ctx context.Context, predictions []float64) (p float64, err error)
 }
 
+type VersionedPredictionMaker interface {
+	PredictWithVersion(ctx context.Context, predictions []float64) (p float64, version string, err error)
+}
+
+type ModelVersioner interface {
+	CurrentModelVersion(ctx context.Context) (string, error)
+}
+
+type ExampleArchive interface {
+	SaveSnapshot(ctx context.Context, snapshot *data.ExampleSnapshot) error
+}
+
+// ExamplesUpdateMetrics is told how each examples update ends, for monitoring.
+type ExamplesUpdateMetrics interface {
+	ExamplesUpdated(duration time.Duration, examples int, err error)
+}
+
 type ExampleLister interface {
 	GetExamples(ctx context.Context) (data.ExamplePredictions, error)
 	UpdateExamples(ctx context.Context) (data.ExamplePredictions, error)
+	GetExample(ctx context.Context, id int64) (*data.ExamplePrediction, error)
+	GetExampleHistory(ctx context.Context, id int64) ([]data.ExampleHistoryEntry, error)
 }
 
 type PredictionCache interface {
@@ -26,4 +47,62 @@ type PredictionCache interface {
 
 type ModelTrainer interface {
 	Retrain(ctx context.Context, now time.Time) error
+	RetrainWithOptions(ctx context.Context, opts data.RetrainOptions) error
+}
+
+// BackgroundRunner runs work which may outlive the request starting it.
+type BackgroundRunner interface {
+	RunInBackground(ctx context.Context, f func(ctx context.Context))
+}
+
+// TaskQueue runs work as a later request to the given path, with the given form values,
+// which may run far longer than the request enqueuing it.
+type TaskQueue interface {
+	Enqueue(ctx context.Context, path string, params url.Values) error
+}
+
+// ConfigSource provides the running configuration, with secrets redacted.
+type ConfigSource interface {
+	RedactedYAML() ([]byte, error)
+}
+
+// HealthChecker reports whether a dependency is reachable, returning an error describing why if not.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// MetricsSource provides the current metrics, in the Prometheus text exposition format.
+type MetricsSource interface {
+	WriteText(w io.Writer) error
+}
+
+type TrainingRunLister interface {
+	RecentRuns(ctx context.Context) ([]data.TrainingRunReport, error)
+}
+
+type WebApiResponder interface {
+	OnContextError(w http.ResponseWriter, err error)
+	OnError(ctx context.Context, w http.ResponseWriter, err error)
+	OnSuccess(w http.ResponseWriter, v interface{})
+}
+
+type ShadowComparisonLister interface {
+	RecentComparisons(ctx context.Context) ([]data.ShadowComparison, error)
+}
+
+type TrackRecorder interface {
+	GetTrackRecord(ctx context.Context) (*data.TrackRecord, error)
+	UpdateTrackRecord(ctx context.Context) (*data.TrackRecord, error)
+}
+
+type QuestionStore interface {
+	ListQuestions(ctx context.Context) ([]data.InternalQuestion, error)
+	CreateQuestion(ctx context.Context, user, title string, deadline time.Time) (*data.Question, error)
+	SubmitForecast(ctx context.Context, id int64, user string, probability float64) error
+	ResolveQuestion(ctx context.Context, id int64, user string, admin bool, outcome data.QuestionOutcome) error
+}
+
+type UserService interface {
+	ContextUser(ctx context.Context) string
+	ContextUserIsAdmin(ctx context.Context) bool
 }
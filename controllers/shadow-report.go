@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type ShadowReport struct {
+	ComparisonLister ShadowComparisonLister
+}
+
+type ShadowReportResult struct {
+	// Summaries has one summary per version which made live predictions, as each is compared separately.
+	Summaries   []data.ShadowSummary
+	Comparisons []data.ShadowComparison
+}
+
+type WebShadowReportResponder interface {
+	OnContextError(w http.ResponseWriter, err error)
+	OnError(ctx context.Context, w http.ResponseWriter, err error)
+	OnResult(w http.ResponseWriter, r *ShadowReportResult)
+}
+
+func (c *ShadowReport) HandleFunc(cm ContextMaker, resp WebShadowReportResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		result, err := c.handle(ctx)
+		if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnResult(w, result)
+		}
+	}
+}
+
+func (c *ShadowReport) HandleApiFunc(cm ContextMaker, resp WebApiResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		result, err := c.handle(ctx)
+		if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnSuccess(w, result)
+		}
+	}
+}
+
+func (c *ShadowReport) handle(ctx context.Context) (*ShadowReportResult, error) {
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"controller": "ShadowReport",
+	})
+
+	comparisons, err := c.ComparisonLister.RecentComparisons(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	return &ShadowReportResult{
+		Summaries:   data.SummarizeShadowComparisonsByPrimary(comparisons),
+		Comparisons: comparisons,
+	}, nil
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"net/http"
+	"testing"
+)
+
+func TestShadowReport_HandleFunc_Success(t *testing.T) {
+	t.Parallel()
+
+	cl := newTestShadowComparisonLister(t)
+	cl.RecentComparisonsFunc = func(ctx context.Context) ([]data.ShadowComparison, error) {
+		if ctx == nil {
+			t.Error("Got nil context, expected non-nil context")
+		}
+		return []data.ShadowComparison{
+			{Primary: 0.3, Shadow: 0.5},
+			{Primary: 0.3, Shadow: 0.32},
+			{Primary: 0.3, Err: "bluh"},
+			{PrimaryVersion: "v700", Primary: 0.4, Shadow: 0.4},
+		}, nil
+	}
+
+	calledOnResult := false
+	r := newTestWebShadowReportResponder(t)
+	r.OnResultFunc = func(w http.ResponseWriter, result *ShadowReportResult) {
+		calledOnResult = true
+		if len(result.Comparisons) != 4 {
+			t.Errorf("Expected result to contain 4 comparisons, contained %d", len(result.Comparisons))
+		}
+		if len(result.Summaries) != 2 || result.Summaries[0].PrimaryVersion != "" || result.Summaries[1].PrimaryVersion != "v700" {
+			t.Fatalf("Expected summaries for the default and canary versions, got %+v", result.Summaries)
+		}
+		if canary := result.Summaries[1]; canary.Count != 1 || canary.MaxDivergence != 0 {
+			t.Errorf("Expected canary comparisons to be summarised apart, got %+v", canary)
+		}
+		s := result.Summaries[0]
+		if s.Count != 2 || s.ErrorCount != 1 || s.DivergentCount != 1 {
+			t.Errorf("Expected 2 compared, 1 error, 1 divergent, got %+v", s)
+		}
+		if s.MaxDivergence < 0.1999 || s.MaxDivergence > 0.2001 {
+			t.Errorf("Expected max divergence of 0.2, got %g", s.MaxDivergence)
+		}
+		if s.MeanDivergence < 0.1099 || s.MeanDivergence > 0.1101 {
+			t.Errorf("Expected mean divergence of 0.11, got %g", s.MeanDivergence)
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &ShadowReport{
+		ComparisonLister: cl,
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnResult {
+		t.Error("Expected responder's OnResult method to be called, was not called")
+	}
+}
+
+func TestShadowReport_HandleFunc_Error(t *testing.T) {
+	t.Parallel()
+
+	cl := newTestShadowComparisonLister(t)
+	cl.RecentComparisonsFunc = func(ctx context.Context) ([]data.ShadowComparison, error) {
+		return nil, errors.New("bluh")
+	}
+
+	calledOnError := false
+	r := newTestWebShadowReportResponder(t)
+	r.OnErrorFunc = func(ctx context.Context, w http.ResponseWriter, err error) {
+		calledOnError = true
+		if err == nil {
+			t.Error("Expected non-nil error, got nil error")
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &ShadowReport{
+		ComparisonLister: cl,
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnError {
+		t.Error("Expected responder's OnError method to be called, was not called")
+	}
+}
+
+func TestShadowReport_HandleApiFunc_Success(t *testing.T) {
+	t.Parallel()
+
+	cl := newTestShadowComparisonLister(t)
+	cl.RecentComparisonsFunc = func(ctx context.Context) ([]data.ShadowComparison, error) {
+		return nil, nil
+	}
+
+	calledOnSuccess := false
+	r := testhelpers.NewWebApiResponder(t)
+	r.OnSuccessFunc = func(w http.ResponseWriter, v interface{}) {
+		calledOnSuccess = true
+		if _, ok := v.(*ShadowReportResult); !ok {
+			t.Errorf("Expected result of type *ShadowReportResult, got %T", v)
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &ShadowReport{
+		ComparisonLister: cl,
+	}
+	handler := c.HandleApiFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnSuccess {
+		t.Error("Expected responder's OnSuccess method to be called, was not called")
+	}
+}
+
+func newTestWebShadowReportResponder(t *testing.T) *testWebShadowReportResponder {
+	return &testWebShadowReportResponder{
+		OnContextErrorFunc: func(w http.ResponseWriter, err error) {
+			t.Error("OnContextErrorFunc should not be called")
+		},
+		OnErrorFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+			t.Error("OnErrorFunc should not be called")
+		},
+		OnResultFunc: func(w http.ResponseWriter, r *ShadowReportResult) {
+			t.Error("OnResultFunc should not be called")
+		},
+	}
+}
+
+type testWebShadowReportResponder struct {
+	OnContextErrorFunc func(w http.ResponseWriter, err error)
+	OnErrorFunc        func(ctx context.Context, w http.ResponseWriter, err error)
+	OnResultFunc       func(w http.ResponseWriter, r *ShadowReportResult)
+}
+
+func (r *testWebShadowReportResponder) OnContextError(w http.ResponseWriter, err error) {
+	r.OnContextErrorFunc(w, err)
+}
+
+func (r *testWebShadowReportResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	r.OnErrorFunc(ctx, w, err)
+}
+
+func (r *testWebShadowReportResponder) OnResult(w http.ResponseWriter, result *ShadowReportResult) {
+	r.OnResultFunc(w, result)
+}
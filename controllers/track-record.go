@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type TrackRecord struct {
+	TrackRecorder TrackRecorder
+}
+
+type TrackRecordUpdate struct {
+	TrackRecorder TrackRecorder
+}
+
+type WebTrackRecordResponder interface {
+	OnContextError(w http.ResponseWriter, err error)
+	OnError(ctx context.Context, w http.ResponseWriter, err error)
+	OnResult(w http.ResponseWriter, r *data.TrackRecord)
+}
+
+type WebTrackRecordUpdateResponder interface {
+	OnContextError(w http.ResponseWriter, err error)
+	OnError(ctx context.Context, w http.ResponseWriter, err error)
+	OnSuccess(w http.ResponseWriter)
+}
+
+func (c *TrackRecord) HandleFunc(cm ContextMaker, resp WebTrackRecordResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		result, err := c.handle(ctx)
+		if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnResult(w, result)
+		}
+	}
+}
+
+func (c *TrackRecord) HandleApiFunc(cm ContextMaker, resp WebApiResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		result, err := c.handle(ctx)
+		if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnSuccess(w, result)
+		}
+	}
+}
+
+func (c *TrackRecord) handle(ctx context.Context) (*data.TrackRecord, error) {
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"controller": "TrackRecord",
+	})
+
+	record, err := c.TrackRecorder.GetTrackRecord(ctx)
+	return record, errors.Wrap(err, "")
+}
+
+func (c *TrackRecordUpdate) HandleFunc(cm ContextMaker, resp WebTrackRecordUpdateResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		err = c.handle(ctx)
+		if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnSuccess(w)
+		}
+	}
+}
+
+func (c *TrackRecordUpdate) handle(ctx context.Context) error {
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"controller": "TrackRecordUpdate",
+	})
+
+	_, err := c.TrackRecorder.UpdateTrackRecord(ctx)
+	return errors.Wrap(err, "")
+}
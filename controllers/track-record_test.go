@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"net/http"
+	"testing"
+)
+
+func TestTrackRecord_HandleFunc_Success(t *testing.T) {
+	t.Parallel()
+
+	record := &data.TrackRecord{Overall: data.VersionTrackRecord{Count: 3}}
+	tr := newTestTrackRecorder(t)
+	tr.GetTrackRecordFunc = func(ctx context.Context) (*data.TrackRecord, error) {
+		if ctx == nil {
+			t.Error("Got nil context, expected non-nil context")
+		}
+		return record, nil
+	}
+
+	calledOnResult := false
+	r := newTestWebTrackRecordResponder(t)
+	r.OnResultFunc = func(w http.ResponseWriter, result *data.TrackRecord) {
+		calledOnResult = true
+		if result != record {
+			t.Error("Expected result to be the stored track record")
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &TrackRecord{
+		TrackRecorder: tr,
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnResult {
+		t.Error("Expected responder's OnResult method to be called, was not called")
+	}
+}
+
+func TestTrackRecord_HandleFunc_Error(t *testing.T) {
+	t.Parallel()
+
+	tr := newTestTrackRecorder(t)
+	tr.GetTrackRecordFunc = func(ctx context.Context) (*data.TrackRecord, error) {
+		return nil, errors.New("bluh")
+	}
+
+	calledOnError := false
+	r := newTestWebTrackRecordResponder(t)
+	r.OnErrorFunc = func(ctx context.Context, w http.ResponseWriter, err error) {
+		calledOnError = true
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &TrackRecord{
+		TrackRecorder: tr,
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnError {
+		t.Error("Expected responder's OnError method to be called, was not called")
+	}
+}
+
+func TestTrackRecord_HandleApiFunc_Success(t *testing.T) {
+	t.Parallel()
+
+	tr := newTestTrackRecorder(t)
+	tr.GetTrackRecordFunc = func(ctx context.Context) (*data.TrackRecord, error) {
+		return &data.TrackRecord{}, nil
+	}
+
+	calledOnSuccess := false
+	r := testhelpers.NewWebApiResponder(t)
+	r.OnSuccessFunc = func(w http.ResponseWriter, v interface{}) {
+		calledOnSuccess = true
+		if _, ok := v.(*data.TrackRecord); !ok {
+			t.Errorf("Expected result of type *data.TrackRecord, got %T", v)
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &TrackRecord{
+		TrackRecorder: tr,
+	}
+	handler := c.HandleApiFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnSuccess {
+		t.Error("Expected responder's OnSuccess method to be called, was not called")
+	}
+}
+
+func TestTrackRecordUpdate_HandleFunc(t *testing.T) {
+	t.Parallel()
+
+	for _, updateErr := range []error{nil, errors.New("bluh")} {
+		tr := newTestTrackRecorder(t)
+		tr.UpdateTrackRecordFunc = func(ctx context.Context) (*data.TrackRecord, error) {
+			return &data.TrackRecord{}, updateErr
+		}
+
+		calledOnSuccess, calledOnError := false, false
+		r := newTestWebExamplesUpdateResponder(t)
+		r.OnSuccessFunc = func(w http.ResponseWriter) {
+			calledOnSuccess = true
+		}
+		r.OnErrorFunc = func(ctx context.Context, w http.ResponseWriter, err error) {
+			calledOnError = true
+		}
+
+		cm := testhelpers.NewContextMaker(t)
+		cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+			return context.Background(), nil
+		}
+
+		c := &TrackRecordUpdate{
+			TrackRecorder: tr,
+		}
+		handler := c.HandleFunc(cm, r)
+		handler(nil, &http.Request{})
+
+		if calledOnSuccess != (updateErr == nil) || calledOnError != (updateErr != nil) {
+			t.Errorf("With update error %v, expected success %v, got success %v and error %v",
+				updateErr, updateErr == nil, calledOnSuccess, calledOnError)
+		}
+	}
+}
+
+func newTestWebTrackRecordResponder(t *testing.T) *testWebTrackRecordResponder {
+	return &testWebTrackRecordResponder{
+		OnContextErrorFunc: func(w http.ResponseWriter, err error) {
+			t.Error("OnContextErrorFunc should not be called")
+		},
+		OnErrorFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+			t.Error("OnErrorFunc should not be called")
+		},
+		OnResultFunc: func(w http.ResponseWriter, r *data.TrackRecord) {
+			t.Error("OnResultFunc should not be called")
+		},
+	}
+}
+
+type testWebTrackRecordResponder struct {
+	OnContextErrorFunc func(w http.ResponseWriter, err error)
+	OnErrorFunc        func(ctx context.Context, w http.ResponseWriter, err error)
+	OnResultFunc       func(w http.ResponseWriter, r *data.TrackRecord)
+}
+
+func (r *testWebTrackRecordResponder) OnContextError(w http.ResponseWriter, err error) {
+	r.OnContextErrorFunc(w, err)
+}
+
+func (r *testWebTrackRecordResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	r.OnErrorFunc(ctx, w, err)
+}
+
+func (r *testWebTrackRecordResponder) OnResult(w http.ResponseWriter, result *data.TrackRecord) {
+	r.OnResultFunc(w, result)
+}
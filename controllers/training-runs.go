@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type TrainingRuns struct {
+	RunLister TrainingRunLister
+}
+
+type TrainingRunsResult struct {
+	Runs []data.TrainingRunReport
+}
+
+type WebTrainingRunsResponder interface {
+	OnContextError(w http.ResponseWriter, err error)
+	OnError(ctx context.Context, w http.ResponseWriter, err error)
+	OnResult(w http.ResponseWriter, r *TrainingRunsResult)
+}
+
+func (c *TrainingRuns) HandleFunc(cm ContextMaker, resp WebTrainingRunsResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		result, err := c.handle(ctx)
+		if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnResult(w, result)
+		}
+	}
+}
+
+func (c *TrainingRuns) HandleApiFunc(cm ContextMaker, resp WebApiResponder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			resp.OnContextError(w, err)
+			return
+		}
+
+		result, err := c.handle(ctx)
+		if err != nil {
+			resp.OnError(ctx, w, err)
+		} else {
+			resp.OnSuccess(w, result)
+		}
+	}
+}
+
+func (c *TrainingRuns) handle(ctx context.Context) (*TrainingRunsResult, error) {
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"controller": "TrainingRuns",
+	})
+
+	runs, err := c.RunLister.RecentRuns(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	return &TrainingRunsResult{Runs: runs}, nil
+}
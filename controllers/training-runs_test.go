@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"net/http"
+	"testing"
+)
+
+func TestTrainingRuns_HandleFunc_Success(t *testing.T) {
+	t.Parallel()
+
+	rl := newTestTrainingRunLister(t)
+	rl.RecentRunsFunc = func(ctx context.Context) ([]data.TrainingRunReport, error) {
+		if ctx == nil {
+			t.Error("Got nil context, expected non-nil context")
+		}
+		return []data.TrainingRunReport{{Model: 500}}, nil
+	}
+
+	calledOnResult := false
+	r := newTestWebTrainingRunsResponder(t)
+	r.OnResultFunc = func(w http.ResponseWriter, result *TrainingRunsResult) {
+		calledOnResult = true
+		if len(result.Runs) != 1 || result.Runs[0].Model != 500 {
+			t.Errorf("Expected result to contain the single run for model 500, got %v", result.Runs)
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &TrainingRuns{
+		RunLister: rl,
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnResult {
+		t.Error("Expected responder's OnResult method to be called, was not called")
+	}
+}
+
+func TestTrainingRuns_HandleFunc_Error(t *testing.T) {
+	t.Parallel()
+
+	rl := newTestTrainingRunLister(t)
+	rl.RecentRunsFunc = func(ctx context.Context) ([]data.TrainingRunReport, error) {
+		return nil, errors.New("bluh")
+	}
+
+	calledOnError := false
+	r := newTestWebTrainingRunsResponder(t)
+	r.OnErrorFunc = func(ctx context.Context, w http.ResponseWriter, err error) {
+		calledOnError = true
+		if err == nil {
+			t.Error("Expected non-nil error in OnError, got nil error")
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &TrainingRuns{
+		RunLister: rl,
+	}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnError {
+		t.Error("Expected responder's OnError method to be called, was not called")
+	}
+}
+
+func TestTrainingRuns_HandleFunc_ContextError(t *testing.T) {
+	t.Parallel()
+
+	calledOnContextError := false
+	r := newTestWebTrainingRunsResponder(t)
+	r.OnContextErrorFunc = func(w http.ResponseWriter, err error) {
+		calledOnContextError = true
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return nil, errors.New("bluh")
+	}
+
+	c := &TrainingRuns{}
+	handler := c.HandleFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnContextError {
+		t.Error("Expected responder's OnContextError method to be called, was not called")
+	}
+}
+
+func TestTrainingRuns_HandleApiFunc_Success(t *testing.T) {
+	t.Parallel()
+
+	rl := newTestTrainingRunLister(t)
+	rl.RecentRunsFunc = func(ctx context.Context) ([]data.TrainingRunReport, error) {
+		return []data.TrainingRunReport{{Model: 500}}, nil
+	}
+
+	calledOnSuccess := false
+	r := testhelpers.NewWebApiResponder(t)
+	r.OnSuccessFunc = func(w http.ResponseWriter, v interface{}) {
+		calledOnSuccess = true
+		result, ok := v.(*TrainingRunsResult)
+		if !ok {
+			t.Errorf("Expected result of type *TrainingRunsResult, got %T", v)
+		} else if len(result.Runs) != 1 {
+			t.Errorf("Expected result to contain 1 run, contained %d", len(result.Runs))
+		}
+	}
+
+	cm := testhelpers.NewContextMaker(t)
+	cm.MakeContextFunc = func(r *http.Request) (i context.Context, e error) {
+		return context.Background(), nil
+	}
+
+	c := &TrainingRuns{
+		RunLister: rl,
+	}
+	handler := c.HandleApiFunc(cm, r)
+	handler(nil, &http.Request{})
+
+	if !calledOnSuccess {
+		t.Error("Expected responder's OnSuccess method to be called, was not called")
+	}
+}
+
+func newTestWebTrainingRunsResponder(t *testing.T) *testWebTrainingRunsResponder {
+	return &testWebTrainingRunsResponder{
+		OnContextErrorFunc: func(w http.ResponseWriter, err error) {
+			t.Error("OnContextErrorFunc should not be called")
+		},
+		OnErrorFunc: func(ctx context.Context, w http.ResponseWriter, err error) {
+			t.Error("OnErrorFunc should not be called")
+		},
+		OnResultFunc: func(w http.ResponseWriter, r *TrainingRunsResult) {
+			t.Error("OnResultFunc should not be called")
+		},
+	}
+}
+
+type testWebTrainingRunsResponder struct {
+	OnContextErrorFunc func(w http.ResponseWriter, err error)
+	OnErrorFunc        func(ctx context.Context, w http.ResponseWriter, err error)
+	OnResultFunc       func(w http.ResponseWriter, r *TrainingRunsResult)
+}
+
+func (r *testWebTrainingRunsResponder) OnContextError(w http.ResponseWriter, err error) {
+	r.OnContextErrorFunc(w, err)
+}
+
+func (r *testWebTrainingRunsResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	r.OnErrorFunc(ctx, w, err)
+}
+
+func (r *testWebTrainingRunsResponder) OnResult(w http.ResponseWriter, result *TrainingRunsResult) {
+	r.OnResultFunc(w, result)
+}
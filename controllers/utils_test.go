@@ -3,6 +3,8 @@ package controllers
 import (
 	"context"
 	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"io"
+	"net/url"
 	"testing"
 	"time"
 )
@@ -17,12 +19,22 @@ func newTestExamplesLister(t *testing.T) *testExamplesLister {
 			t.Error("UpdateExamplesFunc should not be called")
 			return nil, nil
 		},
+		GetExampleFunc: func(ctx context.Context, id int64) (*data.ExamplePrediction, error) {
+			t.Error("GetExampleFunc should not be called")
+			return nil, nil
+		},
+		GetExampleHistoryFunc: func(ctx context.Context, id int64) ([]data.ExampleHistoryEntry, error) {
+			t.Error("GetExampleHistoryFunc should not be called")
+			return nil, nil
+		},
 	}
 }
 
 type testExamplesLister struct {
-	GetExamplesFunc    func(ctx context.Context) (data.ExamplePredictions, error)
-	UpdateExamplesFunc func(ctx context.Context) (data.ExamplePredictions, error)
+	GetExamplesFunc       func(ctx context.Context) (data.ExamplePredictions, error)
+	UpdateExamplesFunc    func(ctx context.Context) (data.ExamplePredictions, error)
+	GetExampleFunc        func(ctx context.Context, id int64) (*data.ExamplePrediction, error)
+	GetExampleHistoryFunc func(ctx context.Context, id int64) ([]data.ExampleHistoryEntry, error)
 }
 
 func (l *testExamplesLister) GetExamples(ctx context.Context) (data.ExamplePredictions, error) {
@@ -33,36 +45,309 @@ func (l *testExamplesLister) UpdateExamples(ctx context.Context) (data.ExamplePr
 	return l.UpdateExamplesFunc(ctx)
 }
 
+func (l *testExamplesLister) GetExample(ctx context.Context, id int64) (*data.ExamplePrediction, error) {
+	return l.GetExampleFunc(ctx, id)
+}
+
+func (l *testExamplesLister) GetExampleHistory(ctx context.Context, id int64) ([]data.ExampleHistoryEntry, error) {
+	return l.GetExampleHistoryFunc(ctx, id)
+}
+
 func newTestPredictionMaker(t *testing.T) *testPredictionMaker {
 	return &testPredictionMaker{
 		PredictFunc: func(ctx context.Context, predictions []float64) (p float64, err error) {
 			t.Error("Predict should not be called")
 			return 0, nil
 		},
+		PredictWithVersionFunc: func(ctx context.Context, predictions []float64) (p float64, version string, err error) {
+			t.Error("PredictWithVersion should not be called")
+			return 0, "", nil
+		},
 	}
 }
 
 type testPredictionMaker struct {
-	PredictFunc func(ctx context.Context, predictions []float64) (p float64, err error)
+	PredictFunc            func(ctx context.Context, predictions []float64) (p float64, err error)
+	PredictWithVersionFunc func(ctx context.Context, predictions []float64) (p float64, version string, err error)
 }
 
 func (pm *testPredictionMaker) Predict(ctx context.Context, predictions []float64) (p float64, err error) {
 	return pm.PredictFunc(ctx, predictions)
 }
 
+func (pm *testPredictionMaker) PredictWithVersion(ctx context.Context, predictions []float64) (p float64, version string, err error) {
+	return pm.PredictWithVersionFunc(ctx, predictions)
+}
+
 func newTestModelTrainer(t *testing.T) *testModelTrainer {
 	return &testModelTrainer{
 		RetrainFunc: func(ctx context.Context, now time.Time) error {
 			t.Error("RetrainFunc should not be called")
 			return nil
 		},
+		RetrainWithOptionsFunc: func(ctx context.Context, opts data.RetrainOptions) error {
+			t.Error("RetrainWithOptionsFunc should not be called")
+			return nil
+		},
 	}
 }
 
 type testModelTrainer struct {
-	RetrainFunc func(ctx context.Context, now time.Time) error
+	RetrainFunc            func(ctx context.Context, now time.Time) error
+	RetrainWithOptionsFunc func(ctx context.Context, opts data.RetrainOptions) error
 }
 
 func (tr *testModelTrainer) Retrain(ctx context.Context, now time.Time) error {
 	return tr.RetrainFunc(ctx, now)
 }
+
+func (tr *testModelTrainer) RetrainWithOptions(ctx context.Context, opts data.RetrainOptions) error {
+	return tr.RetrainWithOptionsFunc(ctx, opts)
+}
+
+func newTestBackgroundRunner(t *testing.T) *testBackgroundRunner {
+	return &testBackgroundRunner{
+		RunInBackgroundFunc: func(ctx context.Context, f func(ctx context.Context)) {
+			t.Error("RunInBackgroundFunc should not be called")
+		},
+	}
+}
+
+type testBackgroundRunner struct {
+	RunInBackgroundFunc func(ctx context.Context, f func(ctx context.Context))
+}
+
+func (br *testBackgroundRunner) RunInBackground(ctx context.Context, f func(ctx context.Context)) {
+	br.RunInBackgroundFunc(ctx, f)
+}
+
+func newTestTaskQueue(t *testing.T) *testTaskQueue {
+	return &testTaskQueue{
+		EnqueueFunc: func(ctx context.Context, path string, params url.Values) error {
+			t.Error("EnqueueFunc should not be called")
+			return nil
+		},
+	}
+}
+
+type testTaskQueue struct {
+	EnqueueFunc func(ctx context.Context, path string, params url.Values) error
+}
+
+func (q *testTaskQueue) Enqueue(ctx context.Context, path string, params url.Values) error {
+	return q.EnqueueFunc(ctx, path, params)
+}
+
+func newTestConfigSource(t *testing.T) *testConfigSource {
+	return &testConfigSource{
+		RedactedYAMLFunc: func() ([]byte, error) {
+			t.Error("RedactedYAMLFunc should not be called")
+			return nil, nil
+		},
+	}
+}
+
+type testConfigSource struct {
+	RedactedYAMLFunc func() ([]byte, error)
+}
+
+func (cs *testConfigSource) RedactedYAML() ([]byte, error) {
+	return cs.RedactedYAMLFunc()
+}
+
+type testExamplesUpdateMetrics struct {
+	durations []time.Duration
+	examples  []int
+	errs      []error
+}
+
+func (m *testExamplesUpdateMetrics) ExamplesUpdated(duration time.Duration, examples int, err error) {
+	m.durations = append(m.durations, duration)
+	m.examples = append(m.examples, examples)
+	m.errs = append(m.errs, err)
+}
+
+func newTestMetricsSource(t *testing.T) *testMetricsSource {
+	return &testMetricsSource{
+		WriteTextFunc: func(w io.Writer) error {
+			t.Error("WriteTextFunc should not be called")
+			return nil
+		},
+	}
+}
+
+type testMetricsSource struct {
+	WriteTextFunc func(w io.Writer) error
+}
+
+func (ms *testMetricsSource) WriteText(w io.Writer) error {
+	return ms.WriteTextFunc(w)
+}
+
+func newTestHealthChecker(t *testing.T) *testHealthChecker {
+	return &testHealthChecker{
+		CheckHealthFunc: func(ctx context.Context) error {
+			t.Error("CheckHealthFunc should not be called")
+			return nil
+		},
+	}
+}
+
+type testHealthChecker struct {
+	CheckHealthFunc func(ctx context.Context) error
+}
+
+func (hc *testHealthChecker) CheckHealth(ctx context.Context) error {
+	return hc.CheckHealthFunc(ctx)
+}
+
+func newTestTrainingRunLister(t *testing.T) *testTrainingRunLister {
+	return &testTrainingRunLister{
+		RecentRunsFunc: func(ctx context.Context) ([]data.TrainingRunReport, error) {
+			t.Error("RecentRunsFunc should not be called")
+			return nil, nil
+		},
+	}
+}
+
+type testTrainingRunLister struct {
+	RecentRunsFunc func(ctx context.Context) ([]data.TrainingRunReport, error)
+}
+
+func (rl *testTrainingRunLister) RecentRuns(ctx context.Context) ([]data.TrainingRunReport, error) {
+	return rl.RecentRunsFunc(ctx)
+}
+
+func newTestShadowComparisonLister(t *testing.T) *testShadowComparisonLister {
+	return &testShadowComparisonLister{
+		RecentComparisonsFunc: func(ctx context.Context) ([]data.ShadowComparison, error) {
+			t.Error("RecentComparisonsFunc should not be called")
+			return nil, nil
+		},
+	}
+}
+
+type testShadowComparisonLister struct {
+	RecentComparisonsFunc func(ctx context.Context) ([]data.ShadowComparison, error)
+}
+
+func (cl *testShadowComparisonLister) RecentComparisons(ctx context.Context) ([]data.ShadowComparison, error) {
+	return cl.RecentComparisonsFunc(ctx)
+}
+
+func newTestModelVersioner(t *testing.T) *testModelVersioner {
+	return &testModelVersioner{
+		CurrentModelVersionFunc: func(ctx context.Context) (string, error) {
+			t.Error("CurrentModelVersionFunc should not be called")
+			return "", nil
+		},
+	}
+}
+
+type testModelVersioner struct {
+	CurrentModelVersionFunc func(ctx context.Context) (string, error)
+}
+
+func (mv *testModelVersioner) CurrentModelVersion(ctx context.Context) (string, error) {
+	return mv.CurrentModelVersionFunc(ctx)
+}
+
+func newTestExampleArchive(t *testing.T) *testExampleArchive {
+	return &testExampleArchive{
+		SaveSnapshotFunc: func(ctx context.Context, snapshot *data.ExampleSnapshot) error {
+			t.Error("SaveSnapshotFunc should not be called")
+			return nil
+		},
+	}
+}
+
+type testExampleArchive struct {
+	SaveSnapshotFunc func(ctx context.Context, snapshot *data.ExampleSnapshot) error
+}
+
+func (a *testExampleArchive) SaveSnapshot(ctx context.Context, snapshot *data.ExampleSnapshot) error {
+	return a.SaveSnapshotFunc(ctx, snapshot)
+}
+
+func newTestTrackRecorder(t *testing.T) *testTrackRecorder {
+	return &testTrackRecorder{
+		GetTrackRecordFunc: func(ctx context.Context) (*data.TrackRecord, error) {
+			t.Error("GetTrackRecordFunc should not be called")
+			return nil, nil
+		},
+		UpdateTrackRecordFunc: func(ctx context.Context) (*data.TrackRecord, error) {
+			t.Error("UpdateTrackRecordFunc should not be called")
+			return nil, nil
+		},
+	}
+}
+
+type testTrackRecorder struct {
+	GetTrackRecordFunc    func(ctx context.Context) (*data.TrackRecord, error)
+	UpdateTrackRecordFunc func(ctx context.Context) (*data.TrackRecord, error)
+}
+
+func (tr *testTrackRecorder) GetTrackRecord(ctx context.Context) (*data.TrackRecord, error) {
+	return tr.GetTrackRecordFunc(ctx)
+}
+
+func (tr *testTrackRecorder) UpdateTrackRecord(ctx context.Context) (*data.TrackRecord, error) {
+	return tr.UpdateTrackRecordFunc(ctx)
+}
+
+func newTestQuestionStore(t *testing.T) *testQuestionStore {
+	return &testQuestionStore{
+		ListQuestionsFunc: func(ctx context.Context) ([]data.InternalQuestion, error) {
+			t.Error("ListQuestionsFunc should not be called")
+			return nil, nil
+		},
+		CreateQuestionFunc: func(ctx context.Context, user, title string, deadline time.Time) (*data.Question, error) {
+			t.Error("CreateQuestionFunc should not be called")
+			return nil, nil
+		},
+		SubmitForecastFunc: func(ctx context.Context, id int64, user string, probability float64) error {
+			t.Error("SubmitForecastFunc should not be called")
+			return nil
+		},
+		ResolveQuestionFunc: func(ctx context.Context, id int64, user string, admin bool, outcome data.QuestionOutcome) error {
+			t.Error("ResolveQuestionFunc should not be called")
+			return nil
+		},
+	}
+}
+
+type testQuestionStore struct {
+	ListQuestionsFunc   func(ctx context.Context) ([]data.InternalQuestion, error)
+	CreateQuestionFunc  func(ctx context.Context, user, title string, deadline time.Time) (*data.Question, error)
+	SubmitForecastFunc  func(ctx context.Context, id int64, user string, probability float64) error
+	ResolveQuestionFunc func(ctx context.Context, id int64, user string, admin bool, outcome data.QuestionOutcome) error
+}
+
+func (qs *testQuestionStore) ListQuestions(ctx context.Context) ([]data.InternalQuestion, error) {
+	return qs.ListQuestionsFunc(ctx)
+}
+
+func (qs *testQuestionStore) CreateQuestion(ctx context.Context, user, title string, deadline time.Time) (*data.Question, error) {
+	return qs.CreateQuestionFunc(ctx, user, title, deadline)
+}
+
+func (qs *testQuestionStore) SubmitForecast(ctx context.Context, id int64, user string, probability float64) error {
+	return qs.SubmitForecastFunc(ctx, id, user, probability)
+}
+
+func (qs *testQuestionStore) ResolveQuestion(ctx context.Context, id int64, user string, admin bool, outcome data.QuestionOutcome) error {
+	return qs.ResolveQuestionFunc(ctx, id, user, admin, outcome)
+}
+
+type testUserService struct {
+	User  string
+	Admin bool
+}
+
+func (us *testUserService) ContextUser(ctx context.Context) string {
+	return us.User
+}
+
+func (us *testUserService) ContextUserIsAdmin(ctx context.Context) bool {
+	return us.Admin
+}
@@ -0,0 +1,88 @@
+package data
+
+import (
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"math"
+)
+
+// DuplicateResponsePolicy is how repeated probability assignments by the same user
+// to the same prediction are combined.
+type DuplicateResponsePolicy string
+
+const (
+	KeepAllResponses   DuplicateResponsePolicy = ""
+	KeepLatestResponse DuplicateResponsePolicy = "latest"
+	KeepFirstResponse  DuplicateResponsePolicy = "first"
+	AverageResponses   DuplicateResponsePolicy = "average"
+)
+
+// Valid returns whether the policy is one of those above.
+func (p DuplicateResponsePolicy) Valid() bool {
+	switch p {
+	case KeepAllResponses, KeepLatestResponse, KeepFirstResponse, AverageResponses:
+		return true
+	}
+	return false
+}
+
+// Apply returns the responses with each user's assignments to each prediction combined into one,
+// kept in the position of the user's latest assignment. Responses without a confidence or a user
+// are passed through unchanged. The passed responses are not modified.
+// Invalid policies, which configuration rejects, keep all responses.
+func (p DuplicateResponsePolicy) Apply(responses []*predictions.PredictionResponse) []*predictions.PredictionResponse {
+	if p == KeepAllResponses || !p.Valid() {
+		return responses
+	}
+
+	type responseKey struct {
+		prediction int64
+		user       string
+	}
+	groups := make(map[responseKey][]*predictions.PredictionResponse)
+	for _, r := range responses {
+		if r.User == "" || math.IsNaN(r.Confidence) {
+			continue
+		}
+		key := responseKey{r.Prediction, r.User}
+		groups[key] = append(groups[key], r)
+	}
+
+	var result []*predictions.PredictionResponse
+	for _, r := range responses {
+		if r.User == "" || math.IsNaN(r.Confidence) {
+			result = append(result, r)
+			continue
+		}
+
+		group := groups[responseKey{r.Prediction, r.User}]
+		latest := group[0]
+		for _, candidate := range group[1:] {
+			if !candidate.Time.Before(latest.Time) {
+				latest = candidate
+			}
+		}
+		if r != latest {
+			continue
+		}
+
+		combined := *latest
+		switch p {
+		case KeepFirstResponse:
+			first := group[0]
+			for _, candidate := range group[1:] {
+				if candidate.Time.Before(first.Time) {
+					first = candidate
+				}
+			}
+			combined = *first
+		case AverageResponses:
+			var total float64
+			for _, candidate := range group {
+				total += candidate.Confidence
+			}
+			combined.Confidence = total / float64(len(group))
+		}
+		result = append(result, &combined)
+	}
+	return result
+}
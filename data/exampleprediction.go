@@ -1,12 +1,27 @@
 package data
 
-import "github.com/jbeshir/predictionbook-extractor/predictions"
+import (
+	"errors"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"time"
+)
+
+var ErrNoSuchExample = errors.New("no such example prediction")
 
 type ExamplePredictions []ExamplePrediction
 
 type ExamplePrediction struct {
 	*predictions.PredictionSummary
 	Assignments []float64
+
+	// Responses holds who made each assignment and when, in the same order as Assignments.
+	Responses []ExampleResponse
+}
+
+type ExampleResponse struct {
+	User       string
+	Time       time.Time
+	Confidence float64
 }
 
 type ExamplePredictionResult struct {
@@ -14,3 +29,16 @@ type ExamplePredictionResult struct {
 	Result    float64
 	ResultErr error
 }
+
+// ExampleHistoryEntry is an example's assignments as of one update of the example list,
+// and our combined probability for them at the time.
+type ExampleHistoryEntry struct {
+	Updated     time.Time
+	Assignments []float64
+
+	// Result is made by ModelVersion; ResultErr is set instead if it couldn't be made.
+	// Entries recorded before combined probabilities were kept have neither.
+	Result       float64
+	ResultErr    string
+	ModelVersion string
+}
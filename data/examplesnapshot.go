@@ -0,0 +1,35 @@
+package data
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNoSuchSnapshot = errors.New("no such example snapshot")
+
+// ExampleSnapshot is the example list as of one day, with what Moonbird predicted for each example,
+// so its forecasts can be scored once the examples resolve.
+type ExampleSnapshot struct {
+	Date         string
+	Taken        time.Time
+	ModelVersion string
+	Examples     []ExampleSnapshotEntry
+}
+
+type ExampleSnapshotEntry struct {
+	ExamplePrediction
+	Result    float64
+	ResultErr string
+
+	// ModelVersion is the version which made this prediction, if it wasn't the snapshot's default version.
+	ModelVersion string
+}
+
+type ExampleSnapshotInfo struct {
+	Date         string
+	Taken        time.Time
+	ModelVersion string
+	ExampleCount int
+}
+
+const SnapshotDateFormat = "2006-01-02"
@@ -0,0 +1,14 @@
+package data
+
+import "errors"
+
+var ErrNoSuchQuestion = errors.New("no such question")
+var ErrQuestionResolved = errors.New("question already resolved")
+var ErrNotQuestionCreator = errors.New("only the question's creator or an admin may resolve it")
+
+// InternalQuestion is a question asked on Moonbird itself, along with every forecast made on it.
+// Internal questions have negative ids, so they never collide with PredictionBook's.
+type InternalQuestion struct {
+	Question
+	Forecasts []Forecast
+}
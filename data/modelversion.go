@@ -0,0 +1,11 @@
+package data
+
+import "time"
+
+// ModelVersion is a version of the model deployed to ML Engine.
+type ModelVersion struct {
+	Name    string
+	State   string
+	Default bool
+	Created time.Time
+}
@@ -0,0 +1,44 @@
+package data
+
+import "time"
+
+// QuestionOutcome is how a forecasting question resolved, if it has.
+type QuestionOutcome int64
+
+const (
+	Unresolved QuestionOutcome = iota
+	ResolvedYes
+	ResolvedNo
+)
+
+// Question is a binary forecasting question, independent of the platform it was asked on.
+type Question struct {
+	Id       int64
+	Title    string
+	Creator  string
+	Created  time.Time
+	Deadline time.Time
+
+	// MeanProbability and ForecastCount summarise the forecasts made on the question,
+	// as reported by the platform.
+	MeanProbability float64
+	ForecastCount   int64
+
+	Outcome QuestionOutcome
+}
+
+// Forecast is a probability assigned to a question by a user.
+// Probability is NaN for forecasts which only comment on the question.
+type Forecast struct {
+	Question    int64
+	Time        time.Time
+	User        string
+	Probability float64
+	Comment     string
+}
+
+// QuestionPageInfo describes a page of a platform's list of open questions.
+type QuestionPageInfo struct {
+	Index    int64
+	LastPage int64
+}
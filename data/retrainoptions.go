@@ -0,0 +1,25 @@
+package data
+
+import "time"
+
+type RetrainOptions struct {
+	// Cutoff is the time up to which predictions are incorporated, and identifies the new model.
+	Cutoff time.Time
+
+	// BaseModel overrides the model trained from; zero means the current latest model.
+	BaseModel int64
+
+	// SkipPromotion leaves the new version non-default and the latest model unchanged.
+	SkipPromotion bool
+
+	// FullRebuild trains from scratch on every resolved prediction, rather than from a base model.
+	FullRebuild bool
+
+	// TrainOnInternalQuestions records whether the training data includes questions asked on Moonbird itself.
+	// It is set by the trainer, from its configuration, so a retrain is never resumed with the other setting.
+	TrainOnInternalQuestions bool
+
+	// AttachToRunning waits for any retrain already in progress and reports its outcome,
+	// instead of failing immediately.
+	AttachToRunning bool
+}
@@ -0,0 +1,85 @@
+package data
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+type ShadowComparison struct {
+	InputHash string
+	Inputs    []float64
+	Time      time.Time
+
+	// PrimaryVersion made the live prediction, such as a canary; empty if the default version did.
+	PrimaryVersion string
+	ShadowVersion  string
+
+	Primary float64
+	Shadow  float64
+	Err     string
+}
+
+func (c ShadowComparison) Divergence() float64 {
+	return math.Abs(c.Primary - c.Shadow)
+}
+
+type ShadowSummary struct {
+	// PrimaryVersion is the version the summarised comparisons' live predictions were made by, if grouped by it.
+	PrimaryVersion string
+
+	Count          int
+	ErrorCount     int
+	MeanDivergence float64
+	MaxDivergence  float64
+
+	// How many comparisons differed by more than ShadowDivergenceThreshold.
+	DivergentCount int
+}
+
+const ShadowDivergenceThreshold = 0.05
+
+func SummarizeShadowComparisons(comparisons []ShadowComparison) ShadowSummary {
+	var s ShadowSummary
+	var total float64
+	for _, c := range comparisons {
+		if c.Err != "" {
+			s.ErrorCount++
+			continue
+		}
+
+		d := c.Divergence()
+		s.Count++
+		total += d
+		if d > s.MaxDivergence {
+			s.MaxDivergence = d
+		}
+		if d > ShadowDivergenceThreshold {
+			s.DivergentCount++
+		}
+	}
+	if s.Count > 0 {
+		s.MeanDivergence = total / float64(s.Count)
+	}
+	return s
+}
+
+// SummarizeShadowComparisonsByPrimary summarises comparisons separately for each version which made
+// their live predictions, the default version first, as each is compared against a different model.
+func SummarizeShadowComparisonsByPrimary(comparisons []ShadowComparison) []ShadowSummary {
+	byVersion := make(map[string][]ShadowComparison)
+	for _, c := range comparisons {
+		byVersion[c.PrimaryVersion] = append(byVersion[c.PrimaryVersion], c)
+	}
+
+	var summaries []ShadowSummary
+	for version, versionComparisons := range byVersion {
+		s := SummarizeShadowComparisons(versionComparisons)
+		s.PrimaryVersion = version
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].PrimaryVersion < summaries[j].PrimaryVersion
+	})
+	return summaries
+}
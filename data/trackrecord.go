@@ -0,0 +1,33 @@
+package data
+
+import "time"
+
+// TrackRecord scores Moonbird's archived example forecasts against their outcomes.
+type TrackRecord struct {
+	Updated  time.Time
+	Overall  VersionTrackRecord
+	Versions []VersionTrackRecord
+}
+
+type VersionTrackRecord struct {
+	ModelVersion string
+	Count        int
+	Moonbird     ForecastScores
+
+	// Baseline is the mean of the human assignments each forecast was made from.
+	Baseline ForecastScores
+}
+
+type ForecastScores struct {
+	Brier       float64
+	LogLoss     float64
+	Calibration []CalibrationBin
+}
+
+type CalibrationBin struct {
+	Lower             float64
+	Upper             float64
+	Count             int
+	MeanPredicted     float64
+	ObservedFrequency float64
+}
@@ -0,0 +1,56 @@
+package data
+
+import "time"
+
+type TrainingRunOutcome string
+
+const (
+	TrainingRunRunning   TrainingRunOutcome = "running"
+	TrainingRunSucceeded TrainingRunOutcome = "succeeded"
+	TrainingRunFailed    TrainingRunOutcome = "failed"
+)
+
+type TrainingRunReport struct {
+	Model     int64
+	BaseModel int64
+	Started   time.Time
+	Finished  time.Time
+	Outcome   TrainingRunOutcome
+	Err       string
+	Stages    []TrainingRunStage
+
+	SkipPromotion bool
+	FullRebuild   bool
+
+	PotentiallyResolvedCount int
+	ResolvedCount            int
+	UnresolvedCount          int
+	OutstandingCount         int
+	ResponseCount            int
+	TrainCount               int
+	CvCount                  int
+	TestCount                int
+
+	PromotedVersion string
+}
+
+type TrainingRunStage struct {
+	Name     string
+	Started  time.Time
+	Finished time.Time
+	Err      string
+}
+
+func (s TrainingRunStage) Duration() time.Duration {
+	if s.Finished.IsZero() {
+		return 0
+	}
+	return s.Finished.Sub(s.Started)
+}
+
+func (r TrainingRunReport) Duration() time.Duration {
+	if r.Finished.IsZero() {
+		return 0
+	}
+	return r.Finished.Sub(r.Started)
+}
@@ -0,0 +1,46 @@
+package forecasting
+
+import (
+	"context"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// SourceAdapter presents any Source in the PredictionBook-shaped form
+// consumed by the example lister, trainer and track recorder.
+type SourceAdapter struct {
+	Source Source
+}
+
+func (a *SourceAdapter) RetrievePredictionListPage(ctx context.Context, index int64) ([]*predictions.PredictionSummary, *predictions.PredictionListPageInfo, error) {
+	questions, pageInfo, err := a.Source.OpenQuestionsPage(ctx, index)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+
+	var summaryPageInfo *predictions.PredictionListPageInfo
+	if pageInfo != nil {
+		summaryPageInfo = &predictions.PredictionListPageInfo{
+			Index:    pageInfo.Index,
+			LastPage: pageInfo.LastPage,
+		}
+	}
+	return summariesFromQuestions(questions), summaryPageInfo, nil
+}
+
+func (a *SourceAdapter) AllPredictionsSince(ctx context.Context, t time.Time) ([]*predictions.PredictionSummary, error) {
+	questions, err := a.Source.QuestionsSince(ctx, t)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return summariesFromQuestions(questions), nil
+}
+
+func (a *SourceAdapter) AllPredictionResponses(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+	questions, forecasts, err := a.Source.Forecasts(ctx, questionsFromSummaries(summaries))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+	return summariesFromQuestions(questions), responsesFromForecasts(forecasts), nil
+}
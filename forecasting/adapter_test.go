@@ -0,0 +1,95 @@
+package forecasting
+
+import (
+	"context"
+	"errors"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSourceAdapter_RetrievePredictionListPage(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSource(t)
+	s.OpenQuestionsPageFunc = func(ctx context.Context, index int64) ([]*data.Question, *data.QuestionPageInfo, error) {
+		return []*data.Question{{Id: 1, Title: "One", ForecastCount: 2}}, nil, nil
+	}
+
+	a := &SourceAdapter{Source: s}
+	summaries, pageInfo, err := a.RetrievePredictionListPage(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if pageInfo != nil {
+		t.Errorf("Expected no page info, got %v", pageInfo)
+	}
+	expected := []*predictions.PredictionSummary{{Id: 1, Title: "One", WagerCount: 2}}
+	if !reflect.DeepEqual(summaries, expected) {
+		t.Errorf("Expected summaries %v, got %v", expected, summaries)
+	}
+}
+
+func TestSourceAdapter_AllPredictionsSince(t *testing.T) {
+	t.Parallel()
+
+	since := time.Unix(5000, 0)
+	s := newTestSource(t)
+	s.QuestionsSinceFunc = func(ctx context.Context, t2 time.Time) ([]*data.Question, error) {
+		if !t2.Equal(since) {
+			t.Errorf("Expected questions since %v, got %v", since, t2)
+		}
+		return []*data.Question{{Id: 1, Outcome: data.ResolvedNo}}, nil
+	}
+
+	a := &SourceAdapter{Source: s}
+	summaries, err := a.AllPredictionsSince(context.Background(), since)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if len(summaries) != 1 || summaries[0].Outcome != predictions.Wrong {
+		t.Errorf("Expected one summary with outcome wrong, got %v", summaries)
+	}
+}
+
+func TestSourceAdapter_AllPredictionResponses(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSource(t)
+	s.ForecastsFunc = func(ctx context.Context, questions []*data.Question) ([]*data.Question, []*data.Forecast, error) {
+		if len(questions) != 1 || questions[0].Id != 3 {
+			t.Errorf("Expected question 3, got %v", questions)
+		}
+		return []*data.Question{{Id: 3, Outcome: data.ResolvedYes}},
+			[]*data.Forecast{{Question: 3, User: "carol", Probability: 0.9}}, nil
+	}
+
+	a := &SourceAdapter{Source: s}
+	summaries, responses, err := a.AllPredictionResponses(context.Background(), []*predictions.PredictionSummary{{Id: 3}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if len(summaries) != 1 || summaries[0].Outcome != predictions.Right {
+		t.Errorf("Expected one summary with outcome right, got %v", summaries)
+	}
+	expected := []*predictions.PredictionResponse{{Prediction: 3, User: "carol", Confidence: 0.9}}
+	if !reflect.DeepEqual(responses, expected) {
+		t.Errorf("Expected responses %v, got %v", expected, responses)
+	}
+}
+
+func TestSourceAdapter_AllPredictionResponses_Err(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSource(t)
+	s.ForecastsFunc = func(ctx context.Context, questions []*data.Question) ([]*data.Question, []*data.Forecast, error) {
+		return nil, nil, errors.New("bluh")
+	}
+
+	a := &SourceAdapter{Source: s}
+	if _, _, err := a.AllPredictionResponses(context.Background(), nil); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -0,0 +1,378 @@
+package forecasting
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+// DumpFormat is the file format questions and forecasts are recorded in.
+// A dump is a pair of files, questions and forecasts, named by the format's extension.
+// The empty format is CSV.
+type DumpFormat string
+
+const (
+	JSONDump DumpFormat = "json"
+	CSVDump  DumpFormat = "csv"
+)
+
+var questionsHeader = []string{"id", "title", "creator", "created", "deadline", "mean_probability", "forecast_count", "outcome"}
+var forecastsHeader = []string{"question", "time", "user", "probability", "comment"}
+
+// jsonQuestion replaces the mean probability with a pointer,
+// because JSON cannot represent the NaN used for questions without forecasts.
+type jsonQuestion struct {
+	Id              int64
+	Title           string
+	Creator         string
+	Created         time.Time
+	Deadline        time.Time
+	MeanProbability *float64
+	ForecastCount   int64
+	Outcome         data.QuestionOutcome
+}
+
+// jsonForecast replaces the probability with a pointer,
+// because JSON cannot represent the NaN used for forecasts without one.
+type jsonForecast struct {
+	Question    int64
+	Time        time.Time
+	User        string
+	Probability *float64
+	Comment     string
+}
+
+func dumpPaths(prefix string, format DumpFormat) (questionsPath, forecastsPath string) {
+	return prefix + "questions." + string(format), prefix + "forecasts." + string(format)
+}
+
+func loadDump(ctx context.Context, fs FileStore, prefix string, format DumpFormat) ([]*data.Question, []*data.Forecast, error) {
+	if format == "" {
+		format = CSVDump
+	}
+
+	questionsPath, forecastsPath := dumpPaths(prefix, format)
+	questionsContent, err := fs.Load(ctx, questionsPath)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+	forecastsContent, err := fs.Load(ctx, forecastsPath)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+
+	switch format {
+	case JSONDump:
+		var jsonQuestions []jsonQuestion
+		err = json.Unmarshal(questionsContent, &jsonQuestions)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "")
+		}
+		questions := make([]*data.Question, 0, len(jsonQuestions))
+		for _, q := range jsonQuestions {
+			meanProbability := math.NaN()
+			if q.MeanProbability != nil {
+				meanProbability = *q.MeanProbability
+			}
+			questions = append(questions, &data.Question{
+				Id:              q.Id,
+				Title:           q.Title,
+				Creator:         q.Creator,
+				Created:         q.Created,
+				Deadline:        q.Deadline,
+				MeanProbability: meanProbability,
+				ForecastCount:   q.ForecastCount,
+				Outcome:         q.Outcome,
+			})
+		}
+
+		var jsonForecasts []jsonForecast
+		err = json.Unmarshal(forecastsContent, &jsonForecasts)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "")
+		}
+		forecasts := make([]*data.Forecast, 0, len(jsonForecasts))
+		for _, f := range jsonForecasts {
+			probability := math.NaN()
+			if f.Probability != nil {
+				probability = *f.Probability
+			}
+			forecasts = append(forecasts, &data.Forecast{
+				Question:    f.Question,
+				Time:        f.Time,
+				User:        f.User,
+				Probability: probability,
+				Comment:     f.Comment,
+			})
+		}
+		return questions, forecasts, nil
+
+	case CSVDump:
+		questions, err := parseQuestionRecords(questionsContent)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, questionsPath)
+		}
+		forecasts, err := parseForecastRecords(forecastsContent)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, forecastsPath)
+		}
+		return questions, forecasts, nil
+
+	default:
+		return nil, nil, errors.Errorf("unknown dump format %q", format)
+	}
+}
+
+func saveDump(ctx context.Context, fs FileStore, prefix string, format DumpFormat, questions []*data.Question, forecasts []*data.Forecast) error {
+	if format == "" {
+		format = CSVDump
+	}
+
+	var questionsContent, forecastsContent []byte
+	var err error
+	switch format {
+	case JSONDump:
+		jsonQuestions := make([]jsonQuestion, 0, len(questions))
+		for _, q := range questions {
+			jq := jsonQuestion{
+				Id:            q.Id,
+				Title:         q.Title,
+				Creator:       q.Creator,
+				Created:       q.Created,
+				Deadline:      q.Deadline,
+				ForecastCount: q.ForecastCount,
+				Outcome:       q.Outcome,
+			}
+			if !math.IsNaN(q.MeanProbability) {
+				meanProbability := q.MeanProbability
+				jq.MeanProbability = &meanProbability
+			}
+			jsonQuestions = append(jsonQuestions, jq)
+		}
+		questionsContent, err = json.Marshal(jsonQuestions)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+
+		jsonForecasts := make([]jsonForecast, 0, len(forecasts))
+		for _, f := range forecasts {
+			jf := jsonForecast{
+				Question: f.Question,
+				Time:     f.Time,
+				User:     f.User,
+				Comment:  f.Comment,
+			}
+			if !math.IsNaN(f.Probability) {
+				probability := f.Probability
+				jf.Probability = &probability
+			}
+			jsonForecasts = append(jsonForecasts, jf)
+		}
+		forecastsContent, err = json.Marshal(jsonForecasts)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+
+	case CSVDump:
+		var questionRecords [][]string
+		questionRecords = append(questionRecords, questionsHeader)
+		for _, q := range questions {
+			meanProbability := ""
+			if !math.IsNaN(q.MeanProbability) {
+				meanProbability = strconv.FormatFloat(q.MeanProbability, 'f', -1, 64)
+			}
+			questionRecords = append(questionRecords, []string{
+				strconv.FormatInt(q.Id, 10),
+				q.Title,
+				q.Creator,
+				formatDumpTime(q.Created),
+				formatDumpTime(q.Deadline),
+				meanProbability,
+				strconv.FormatInt(q.ForecastCount, 10),
+				formatOutcome(q.Outcome),
+			})
+		}
+		questionsContent, err = writeRecords(questionRecords)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+
+		var forecastRecords [][]string
+		forecastRecords = append(forecastRecords, forecastsHeader)
+		for _, f := range forecasts {
+			probability := ""
+			if !math.IsNaN(f.Probability) {
+				probability = strconv.FormatFloat(f.Probability, 'f', -1, 64)
+			}
+			forecastRecords = append(forecastRecords, []string{
+				strconv.FormatInt(f.Question, 10),
+				formatDumpTime(f.Time),
+				f.User,
+				probability,
+				f.Comment,
+			})
+		}
+		forecastsContent, err = writeRecords(forecastRecords)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+
+	default:
+		return errors.Errorf("unknown dump format %q", format)
+	}
+
+	questionsPath, forecastsPath := dumpPaths(prefix, format)
+	err = fs.Save(ctx, questionsPath, questionsContent)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	err = fs.Save(ctx, forecastsPath, forecastsContent)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	return nil
+}
+
+func parseQuestionRecords(content []byte) ([]*data.Question, error) {
+	records, err := readRecords(content, len(questionsHeader))
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	questions := make([]*data.Question, 0, len(records))
+	for _, r := range records {
+		q := &data.Question{
+			Title:           r[1],
+			Creator:         r[2],
+			MeanProbability: math.NaN(),
+		}
+		q.Id, err = strconv.ParseInt(r[0], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		q.Created, err = parseDumpTime(r[3])
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		q.Deadline, err = parseDumpTime(r[4])
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		if r[5] != "" {
+			q.MeanProbability, err = strconv.ParseFloat(r[5], 64)
+			if err != nil {
+				return nil, errors.Wrap(err, "")
+			}
+		}
+		q.ForecastCount, err = strconv.ParseInt(r[6], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		q.Outcome, err = parseOutcome(r[7])
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		questions = append(questions, q)
+	}
+	return questions, nil
+}
+
+func parseForecastRecords(content []byte) ([]*data.Forecast, error) {
+	records, err := readRecords(content, len(forecastsHeader))
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	forecasts := make([]*data.Forecast, 0, len(records))
+	for _, r := range records {
+		f := &data.Forecast{
+			User:        r[2],
+			Probability: math.NaN(),
+			Comment:     r[4],
+		}
+		f.Question, err = strconv.ParseInt(r[0], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		f.Time, err = parseDumpTime(r[1])
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		if r[3] != "" {
+			f.Probability, err = strconv.ParseFloat(r[3], 64)
+			if err != nil {
+				return nil, errors.Wrap(err, "")
+			}
+		}
+		forecasts = append(forecasts, f)
+	}
+	return forecasts, nil
+}
+
+// readRecords parses CSV content, skipping its header row.
+func readRecords(content []byte, fields int) ([][]string, error) {
+	csvReader := csv.NewReader(bytes.NewReader(content))
+	csvReader.FieldsPerRecord = fields
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return records[1:], nil
+}
+
+func writeRecords(records [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	csvWriter := csv.NewWriter(&buf)
+	err := csvWriter.WriteAll(records)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return buf.Bytes(), nil
+}
+
+func formatDumpTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseDumpTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func formatOutcome(o data.QuestionOutcome) string {
+	switch o {
+	case data.ResolvedYes:
+		return "yes"
+	case data.ResolvedNo:
+		return "no"
+	default:
+		return ""
+	}
+}
+
+func parseOutcome(s string) (data.QuestionOutcome, error) {
+	switch s {
+	case "yes":
+		return data.ResolvedYes, nil
+	case "no":
+		return data.ResolvedNo, nil
+	case "":
+		return data.Unresolved, nil
+	default:
+		return data.Unresolved, errors.Errorf("unknown outcome %q", s)
+	}
+}
@@ -0,0 +1,108 @@
+package forecasting
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+const defaultFilePageSize = 20
+
+// FileSource reads questions and forecasts from a dump written by a Recorder,
+// for offline development and backfills. The dump is loaded afresh on each call.
+type FileSource struct {
+	FileStore FileStore
+	Prefix    string
+	Format    DumpFormat
+
+	// PageSize is the number of open questions listed per page; defaults to 20.
+	PageSize int
+}
+
+// OpenQuestionsPage lists unresolved questions newest first, as PredictionBook does.
+// Pages are numbered from 1.
+func (s *FileSource) OpenQuestionsPage(ctx context.Context, index int64) ([]*data.Question, *data.QuestionPageInfo, error) {
+	questions, _, err := loadDump(ctx, s.FileStore, s.Prefix, s.Format)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+
+	var open []*data.Question
+	for _, q := range questions {
+		if q.Outcome == data.Unresolved {
+			open = append(open, q)
+		}
+	}
+	sort.SliceStable(open, func(i, j int) bool {
+		return open[i].Created.After(open[j].Created)
+	})
+
+	pageSize := int64(s.PageSize)
+	if pageSize <= 0 {
+		pageSize = defaultFilePageSize
+	}
+	lastPage := (int64(len(open)) + pageSize - 1) / pageSize
+	if lastPage == 0 {
+		lastPage = 1
+	}
+
+	var page []*data.Question
+	if start := (index - 1) * pageSize; index >= 1 && start < int64(len(open)) {
+		end := start + pageSize
+		if end > int64(len(open)) {
+			end = int64(len(open))
+		}
+		page = open[start:end]
+	}
+
+	return page, &data.QuestionPageInfo{
+		Index:    index,
+		LastPage: lastPage,
+	}, nil
+}
+
+func (s *FileSource) QuestionsSince(ctx context.Context, t time.Time) ([]*data.Question, error) {
+	questions, _, err := loadDump(ctx, s.FileStore, s.Prefix, s.Format)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	var since []*data.Question
+	for _, q := range questions {
+		if q.Created.After(t) {
+			since = append(since, q)
+		}
+	}
+	return since, nil
+}
+
+// Forecasts returns the recorded state of each question and its forecasts.
+// Questions missing from the dump are returned as passed, with no forecasts.
+func (s *FileSource) Forecasts(ctx context.Context, questions []*data.Question) ([]*data.Question, []*data.Forecast, error) {
+	recordedQuestions, recordedForecasts, err := loadDump(ctx, s.FileStore, s.Prefix, s.Format)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+
+	recorded := make(map[int64]*data.Question)
+	for _, q := range recordedQuestions {
+		recorded[q.Id] = q
+	}
+	forecastsByQuestion := make(map[int64][]*data.Forecast)
+	for _, f := range recordedForecasts {
+		forecastsByQuestion[f.Question] = append(forecastsByQuestion[f.Question], f)
+	}
+
+	var resultQuestions []*data.Question
+	var resultForecasts []*data.Forecast
+	for _, q := range questions {
+		if r, ok := recorded[q.Id]; ok {
+			q = r
+		}
+		resultQuestions = append(resultQuestions, q)
+		resultForecasts = append(resultForecasts, forecastsByQuestion[q.Id]...)
+	}
+	return resultQuestions, resultForecasts, nil
+}
@@ -0,0 +1,193 @@
+package forecasting
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testDump() ([]*data.Question, []*data.Forecast) {
+	questions := []*data.Question{
+		{Id: 1, Title: "One, with a comma", Creator: "bob", Created: time.Unix(1000, 0).UTC(), Deadline: time.Unix(9000, 0).UTC(), MeanProbability: 0.5, ForecastCount: 2},
+		{Id: 2, Title: "Two", Created: time.Unix(2000, 0).UTC(), Outcome: data.ResolvedYes},
+		{Id: 3, Title: "Three", Created: time.Unix(3000, 0).UTC(), MeanProbability: math.NaN()},
+		{Id: 4, Title: "Four", Created: time.Unix(4000, 0).UTC(), Outcome: data.ResolvedNo},
+	}
+	forecasts := []*data.Forecast{
+		{Question: 1, Time: time.Unix(1100, 0).UTC(), User: "bob", Probability: 0.4},
+		{Question: 1, Time: time.Unix(1200, 0).UTC(), User: "alice", Probability: math.NaN(), Comment: "I \"doubt\" it."},
+		{Question: 2, Time: time.Unix(2100, 0).UTC(), User: "bob", Probability: 0.9},
+	}
+	return questions, forecasts
+}
+
+func TestFileSource_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []DumpFormat{JSONDump, CSVDump} {
+		questions, forecasts := testDump()
+
+		fs := newMemoryFileStore()
+		err := saveDump(context.Background(), fs, "dump/", format, questions, forecasts)
+		if err != nil {
+			t.Fatalf("%s: expected no error saving, got %s", format, err)
+		}
+		if _, ok := fs.Files["dump/questions."+string(format)]; !ok {
+			t.Errorf("%s: expected questions file to be saved", format)
+		}
+
+		loadedQuestions, loadedForecasts, err := loadDump(context.Background(), fs, "dump/", format)
+		if err != nil {
+			t.Fatalf("%s: expected no error loading, got %s", format, err)
+		}
+		if len(loadedQuestions) != len(questions) {
+			t.Fatalf("%s: expected %d questions, got %d", format, len(questions), len(loadedQuestions))
+		}
+		if !math.IsNaN(loadedQuestions[2].MeanProbability) {
+			t.Errorf("%s: expected question without forecasts to round trip, got %v", format, loadedQuestions[2])
+		}
+		loadedQuestions[2].MeanProbability, questions[2].MeanProbability = 0, 0
+		if !reflect.DeepEqual(loadedQuestions, questions) {
+			t.Errorf("%s: expected questions %v, got %v", format, questions, loadedQuestions)
+		}
+		if len(loadedForecasts) != len(forecasts) {
+			t.Fatalf("%s: expected %d forecasts, got %d", format, len(forecasts), len(loadedForecasts))
+		}
+		if !math.IsNaN(loadedForecasts[1].Probability) || loadedForecasts[1].Comment != forecasts[1].Comment {
+			t.Errorf("%s: expected comment-only forecast to round trip, got %v", format, loadedForecasts[1])
+		}
+		loadedForecasts[1].Probability, forecasts[1].Probability = 0, 0
+		if !reflect.DeepEqual(loadedForecasts, forecasts) {
+			t.Errorf("%s: expected forecasts %v, got %v", format, forecasts, loadedForecasts)
+		}
+	}
+}
+
+func TestFileSource_OpenQuestionsPage(t *testing.T) {
+	t.Parallel()
+
+	questions, forecasts := testDump()
+	fs := newMemoryFileStore()
+	err := saveDump(context.Background(), fs, "", CSVDump, questions, forecasts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &FileSource{FileStore: fs, Format: CSVDump, PageSize: 1}
+	var ids []int64
+	for index := int64(1); ; index++ {
+		page, pageInfo, err := s.OpenQuestionsPage(context.Background(), index)
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		if pageInfo.LastPage != 2 {
+			t.Errorf("Expected 2 pages, got %d", pageInfo.LastPage)
+		}
+		for _, q := range page {
+			ids = append(ids, q.Id)
+		}
+		if pageInfo.Index >= pageInfo.LastPage {
+			break
+		}
+	}
+
+	// Only unresolved questions are listed, newest first.
+	if !reflect.DeepEqual(ids, []int64{3, 1}) {
+		t.Errorf("Expected questions 3 then 1, got %v", ids)
+	}
+}
+
+func TestFileSource_QuestionsSince(t *testing.T) {
+	t.Parallel()
+
+	questions, forecasts := testDump()
+	fs := newMemoryFileStore()
+	err := saveDump(context.Background(), fs, "", JSONDump, questions, forecasts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &FileSource{FileStore: fs, Format: JSONDump}
+	since, err := s.QuestionsSince(context.Background(), time.Unix(2000, 0))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if len(since) != 2 || since[0].Id != 3 || since[1].Id != 4 {
+		t.Errorf("Expected questions 3 and 4, got %v", since)
+	}
+}
+
+func TestFileSource_Forecasts(t *testing.T) {
+	t.Parallel()
+
+	questions, forecasts := testDump()
+	fs := newMemoryFileStore()
+	err := saveDump(context.Background(), fs, "", JSONDump, questions, forecasts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &FileSource{FileStore: fs, Format: JSONDump}
+	missing := &data.Question{Id: 10}
+	resultQuestions, resultForecasts, err := s.Forecasts(context.Background(), []*data.Question{{Id: 2}, missing})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if len(resultQuestions) != 2 || resultQuestions[0].Outcome != data.ResolvedYes || resultQuestions[1] != missing {
+		t.Errorf("Expected recorded question 2 and the missing question as passed, got %v", resultQuestions)
+	}
+	if len(resultForecasts) != 1 || resultForecasts[0].Question != 2 || resultForecasts[0].Probability != 0.9 {
+		t.Errorf("Expected question 2's forecast, got %v", resultForecasts)
+	}
+}
+
+func TestFileSource_MissingDump(t *testing.T) {
+	t.Parallel()
+
+	s := &FileSource{FileStore: newMemoryFileStore(), Format: CSVDump}
+	if _, err := s.QuestionsSince(context.Background(), time.Time{}); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestRecorder_Record(t *testing.T) {
+	t.Parallel()
+
+	questions, forecasts := testDump()
+
+	source := newTestSource(t)
+	source.QuestionsSinceFunc = func(ctx context.Context, t2 time.Time) ([]*data.Question, error) {
+		return questions[:2], nil
+	}
+	source.OpenQuestionsPageFunc = func(ctx context.Context, index int64) ([]*data.Question, *data.QuestionPageInfo, error) {
+		if index != 1 {
+			t.Errorf("Expected only page 1 to be requested, got %d", index)
+		}
+		return []*data.Question{questions[0], questions[2]}, &data.QuestionPageInfo{Index: 1, LastPage: 1}, nil
+	}
+	source.ForecastsFunc = func(ctx context.Context, qs []*data.Question) ([]*data.Question, []*data.Forecast, error) {
+		if len(qs) != 3 {
+			t.Errorf("Expected 3 distinct questions, got %d", len(qs))
+		}
+		return qs, forecasts, nil
+	}
+
+	fs := newMemoryFileStore()
+	r := &Recorder{Source: source, FileStore: fs, Prefix: "rec/", Format: CSVDump}
+	err := r.Record(context.Background(), time.Unix(0, 0), 5)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	replay := &FileSource{FileStore: fs, Prefix: "rec/", Format: CSVDump}
+	replayed, err := replay.QuestionsSince(context.Background(), time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("Expected no error replaying, got %s", err)
+	}
+	if len(replayed) != 3 {
+		t.Errorf("Expected 3 recorded questions, got %d", len(replayed))
+	}
+}
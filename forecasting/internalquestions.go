@@ -0,0 +1,217 @@
+package forecasting
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const storeInternalQuestionsKind = "InternalQuestions"
+const storeInternalQuestionsKey = "all"
+
+// InternalQuestions stores questions asked on Moonbird itself, and the forecasts our users make on them.
+// All questions are kept in a single entity, which suits the number a team asks and lets every change
+// be made in one transaction. It is also a Source, so internal questions can feed the example list
+// and training data.
+type InternalQuestions struct {
+	PersistentStore PersistentStore
+	NowFunc         func() time.Time
+}
+
+type internalQuestionsState struct {
+	LastId    int64
+	Questions []data2.InternalQuestion
+}
+
+func (iq *InternalQuestions) ListQuestions(ctx context.Context) ([]data2.InternalQuestion, error) {
+	state, err := iq.getState(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return state.Questions, nil
+}
+
+func (iq *InternalQuestions) CreateQuestion(ctx context.Context, user, title string, deadline time.Time) (*data2.Question, error) {
+	var q *data2.Question
+	err := iq.update(ctx, func(state *internalQuestionsState) error {
+		state.LastId++
+		state.Questions = append(state.Questions, data2.InternalQuestion{
+			Question: data2.Question{
+				Id:       -state.LastId,
+				Title:    title,
+				Creator:  user,
+				Created:  iq.now(),
+				Deadline: deadline,
+			},
+		})
+		q = &state.Questions[len(state.Questions)-1].Question
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return q, nil
+}
+
+// SubmitForecast records a user's probability for a question.
+// A user may submit repeatedly to update their forecast; each submission is kept.
+func (iq *InternalQuestions) SubmitForecast(ctx context.Context, id int64, user string, probability float64) error {
+	return iq.updateQuestion(ctx, id, func(q *data2.InternalQuestion) error {
+		if q.Outcome != data2.Unresolved {
+			return errors.WithStack(data2.ErrQuestionResolved)
+		}
+
+		q.Forecasts = append(q.Forecasts, data2.Forecast{
+			Question:    q.Id,
+			Time:        iq.now(),
+			User:        user,
+			Probability: probability,
+		})
+		q.ForecastCount = int64(len(q.Forecasts))
+		q.MeanProbability = meanLatestProbability(q.Forecasts)
+		return nil
+	})
+}
+
+// ResolveQuestion records a question's outcome, on behalf of user. Only the question's creator may resolve it,
+// unless the user is an admin.
+func (iq *InternalQuestions) ResolveQuestion(ctx context.Context, id int64, user string, admin bool, outcome data2.QuestionOutcome) error {
+	return iq.updateQuestion(ctx, id, func(q *data2.InternalQuestion) error {
+		if !admin && q.Creator != user {
+			return errors.WithStack(data2.ErrNotQuestionCreator)
+		}
+		if q.Outcome != data2.Unresolved {
+			return errors.WithStack(data2.ErrQuestionResolved)
+		}
+		q.Outcome = outcome
+		return nil
+	})
+}
+
+// OpenQuestionsPage lists every unresolved internal question on the first page.
+func (iq *InternalQuestions) OpenQuestionsPage(ctx context.Context, index int64) ([]*data2.Question, *data2.QuestionPageInfo, error) {
+	state, err := iq.getState(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+
+	var open []*data2.Question
+	if index == 1 {
+		for i := range state.Questions {
+			if state.Questions[i].Outcome == data2.Unresolved {
+				open = append(open, &state.Questions[i].Question)
+			}
+		}
+	}
+	return open, &data2.QuestionPageInfo{
+		Index:    index,
+		LastPage: 1,
+	}, nil
+}
+
+func (iq *InternalQuestions) QuestionsSince(ctx context.Context, t time.Time) ([]*data2.Question, error) {
+	state, err := iq.getState(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	var since []*data2.Question
+	for i := range state.Questions {
+		if state.Questions[i].Created.After(t) {
+			since = append(since, &state.Questions[i].Question)
+		}
+	}
+	return since, nil
+}
+
+// Forecasts returns the current state of each question and its forecasts.
+// Unknown questions are returned as passed, with no forecasts.
+func (iq *InternalQuestions) Forecasts(ctx context.Context, questions []*data2.Question) ([]*data2.Question, []*data2.Forecast, error) {
+	state, err := iq.getState(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+
+	byId := make(map[int64]*data2.InternalQuestion)
+	for i := range state.Questions {
+		byId[state.Questions[i].Id] = &state.Questions[i]
+	}
+
+	var resultQuestions []*data2.Question
+	var resultForecasts []*data2.Forecast
+	for _, q := range questions {
+		stored, ok := byId[q.Id]
+		if !ok {
+			resultQuestions = append(resultQuestions, q)
+			continue
+		}
+
+		resultQuestions = append(resultQuestions, &stored.Question)
+		for i := range stored.Forecasts {
+			resultForecasts = append(resultForecasts, &stored.Forecasts[i])
+		}
+	}
+	return resultQuestions, resultForecasts, nil
+}
+
+func (iq *InternalQuestions) updateQuestion(ctx context.Context, id int64, f func(q *data2.InternalQuestion) error) error {
+	return iq.update(ctx, func(state *internalQuestionsState) error {
+		for i := range state.Questions {
+			if state.Questions[i].Id == id {
+				return f(&state.Questions[i])
+			}
+		}
+		return errors.WithStack(data2.ErrNoSuchQuestion)
+	})
+}
+
+func (iq *InternalQuestions) update(ctx context.Context, f func(state *internalQuestionsState) error) error {
+	return iq.PersistentStore.Transact(ctx, func(ctx context.Context) error {
+		state, err := iq.getState(ctx)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+
+		err = f(state)
+		if err != nil {
+			return err
+		}
+
+		return errors.Wrap(iq.PersistentStore.Set(ctx, storeInternalQuestionsKind, storeInternalQuestionsKey, nil, state), "")
+	})
+}
+
+func (iq *InternalQuestions) getState(ctx context.Context) (*internalQuestionsState, error) {
+	state := new(internalQuestionsState)
+	_, err := iq.PersistentStore.Get(ctx, storeInternalQuestionsKind, storeInternalQuestionsKey, state)
+	if err != nil && errors.Cause(err) != data.ErrNoSuchEntity {
+		return nil, errors.Wrap(err, "")
+	}
+	return state, nil
+}
+
+func (iq *InternalQuestions) now() time.Time {
+	if iq.NowFunc != nil {
+		return iq.NowFunc()
+	}
+	return time.Now()
+}
+
+// meanLatestProbability averages each user's most recent forecast.
+func meanLatestProbability(forecasts []data2.Forecast) float64 {
+	latest := make(map[string]float64)
+	for _, f := range forecasts {
+		latest[f.User] = f.Probability
+	}
+	if len(latest) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, p := range latest {
+		total += p
+	}
+	return total / float64(len(latest))
+}
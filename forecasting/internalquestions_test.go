@@ -0,0 +1,166 @@
+package forecasting
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+func newTestInternalQuestionsStore(t *testing.T) *testhelpers.PersistentStore {
+	var stored []byte
+
+	ps := testhelpers.NewPersistentStore(t)
+	ps.TransactFunc = func(ctx context.Context, f func(ctx context.Context) error) error {
+		return f(ctx)
+	}
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		if kind != storeInternalQuestionsKind || key != storeInternalQuestionsKey {
+			t.Errorf("Unexpected retrieval of %s/%s", kind, key)
+		}
+		if stored == nil {
+			return nil, data.ErrNoSuchEntity
+		}
+		return nil, json.Unmarshal(stored, v)
+	}
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		if kind != storeInternalQuestionsKind || key != storeInternalQuestionsKey {
+			t.Errorf("Unexpected write of %s/%s", kind, key)
+		}
+		var err error
+		stored, err = json.Marshal(v)
+		return err
+	}
+	return ps
+}
+
+func TestInternalQuestions_CreateQuestion(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0).UTC()
+	iq := &InternalQuestions{
+		PersistentStore: newTestInternalQuestionsStore(t),
+		NowFunc:         func() time.Time { return now },
+	}
+	c := context.Background()
+
+	first, err := iq.CreateQuestion(c, "bob", "Will it rain?", time.Unix(5000, 0).UTC())
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	second, err := iq.CreateQuestion(c, "alice", "Will it snow?", time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if first.Id != -1 || second.Id != -2 {
+		t.Errorf("Expected ids -1 and -2, got %d and %d", first.Id, second.Id)
+	}
+	if first.Creator != "bob" || !first.Created.Equal(now) {
+		t.Errorf("Expected question created by bob at %v, got %v", now, first)
+	}
+
+	questions, err := iq.ListQuestions(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if len(questions) != 2 || questions[1].Title != "Will it snow?" {
+		t.Errorf("Expected both questions listed, got %v", questions)
+	}
+}
+
+func TestInternalQuestions_SubmitForecast(t *testing.T) {
+	t.Parallel()
+
+	iq := &InternalQuestions{
+		PersistentStore: newTestInternalQuestionsStore(t),
+	}
+	c := context.Background()
+
+	q, err := iq.CreateQuestion(c, "bob", "Will it rain?", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []struct {
+		user        string
+		probability float64
+	}{{"bob", 0.2}, {"alice", 0.6}, {"bob", 0.4}} {
+		err = iq.SubmitForecast(c, q.Id, f.user, f.probability)
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+	}
+
+	questions, forecasts, err := iq.Forecasts(c, []*data2.Question{{Id: q.Id}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if len(forecasts) != 3 {
+		t.Errorf("Expected all 3 forecasts to be kept, got %d", len(forecasts))
+	}
+
+	// The mean uses each user's latest forecast.
+	if questions[0].ForecastCount != 3 || questions[0].MeanProbability != 0.5 {
+		t.Errorf("Expected 3 forecasts with mean 0.5, got %d with mean %f", questions[0].ForecastCount, questions[0].MeanProbability)
+	}
+
+	err = iq.SubmitForecast(c, -5, "bob", 0.5)
+	if errors.Cause(err) != data2.ErrNoSuchQuestion {
+		t.Errorf("Expected ErrNoSuchQuestion for unknown question, got %v", err)
+	}
+}
+
+func TestInternalQuestions_ResolveQuestion(t *testing.T) {
+	t.Parallel()
+
+	iq := &InternalQuestions{
+		PersistentStore: newTestInternalQuestionsStore(t),
+	}
+	c := context.Background()
+
+	open, err := iq.CreateQuestion(c, "bob", "Open", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := iq.CreateQuestion(c, "bob", "Resolved", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = iq.ResolveQuestion(c, resolved.Id, "alice", false, data2.ResolvedYes)
+	if errors.Cause(err) != data2.ErrNotQuestionCreator {
+		t.Errorf("Expected ErrNotQuestionCreator resolving someone else's question, got %v", err)
+	}
+	err = iq.ResolveQuestion(c, resolved.Id, "bob", false, data2.ResolvedYes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	err = iq.ResolveQuestion(c, resolved.Id, "alice", true, data2.ResolvedNo)
+	if errors.Cause(err) != data2.ErrQuestionResolved {
+		t.Errorf("Expected ErrQuestionResolved resolving twice, got %v", err)
+	}
+	err = iq.SubmitForecast(c, resolved.Id, "bob", 0.5)
+	if errors.Cause(err) != data2.ErrQuestionResolved {
+		t.Errorf("Expected ErrQuestionResolved forecasting on a resolved question, got %v", err)
+	}
+
+	page, pageInfo, err := iq.OpenQuestionsPage(c, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if len(page) != 1 || page[0].Id != open.Id {
+		t.Errorf("Expected only the open question to be listed, got %v", page)
+	}
+	if pageInfo.LastPage != 1 {
+		t.Errorf("Expected a single page, got %d", pageInfo.LastPage)
+	}
+
+	page, _, err = iq.OpenQuestionsPage(c, 2)
+	if err != nil || len(page) != 0 {
+		t.Errorf("Expected an empty second page, got %v, %v", page, err)
+	}
+}
@@ -0,0 +1,74 @@
+package forecasting
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// MergedSource combines an external source's questions with our internal questions,
+// telling them apart by the internal questions' negative ids.
+type MergedSource struct {
+	External Source
+	Internal Source
+}
+
+// OpenQuestionsPage lists the external source's page, preceded on the first page by all open internal questions.
+func (s *MergedSource) OpenQuestionsPage(ctx context.Context, index int64) ([]*data.Question, *data.QuestionPageInfo, error) {
+	questions, pageInfo, err := s.External.OpenQuestionsPage(ctx, index)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+	if index != 1 {
+		return questions, pageInfo, nil
+	}
+
+	internal, _, err := s.Internal.OpenQuestionsPage(ctx, 1)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+	return append(internal, questions...), pageInfo, nil
+}
+
+func (s *MergedSource) QuestionsSince(ctx context.Context, t time.Time) ([]*data.Question, error) {
+	external, err := s.External.QuestionsSince(ctx, t)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	internal, err := s.Internal.QuestionsSince(ctx, t)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return append(external, internal...), nil
+}
+
+func (s *MergedSource) Forecasts(ctx context.Context, questions []*data.Question) ([]*data.Question, []*data.Forecast, error) {
+	var external, internal []*data.Question
+	for _, q := range questions {
+		if q.Id < 0 {
+			internal = append(internal, q)
+		} else {
+			external = append(external, q)
+		}
+	}
+
+	var resultQuestions []*data.Question
+	var resultForecasts []*data.Forecast
+	for _, part := range []struct {
+		source    Source
+		questions []*data.Question
+	}{{s.External, external}, {s.Internal, internal}} {
+		if len(part.questions) == 0 {
+			continue
+		}
+
+		partQuestions, partForecasts, err := part.source.Forecasts(ctx, part.questions)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "")
+		}
+		resultQuestions = append(resultQuestions, partQuestions...)
+		resultForecasts = append(resultForecasts, partForecasts...)
+	}
+	return resultQuestions, resultForecasts, nil
+}
@@ -0,0 +1,101 @@
+package forecasting
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"testing"
+	"time"
+)
+
+func TestMergedSource_OpenQuestionsPage(t *testing.T) {
+	t.Parallel()
+
+	external := newTestSource(t)
+	external.OpenQuestionsPageFunc = func(ctx context.Context, index int64) ([]*data.Question, *data.QuestionPageInfo, error) {
+		return []*data.Question{{Id: index * 10}}, &data.QuestionPageInfo{Index: index, LastPage: 3}, nil
+	}
+	internal := newTestSource(t)
+	internal.OpenQuestionsPageFunc = func(ctx context.Context, index int64) ([]*data.Question, *data.QuestionPageInfo, error) {
+		return []*data.Question{{Id: -1}}, &data.QuestionPageInfo{Index: 1, LastPage: 1}, nil
+	}
+
+	s := &MergedSource{External: external, Internal: internal}
+	page, pageInfo, err := s.OpenQuestionsPage(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if len(page) != 2 || page[0].Id != -1 || page[1].Id != 10 {
+		t.Errorf("Expected internal question then external question on first page, got %v", page)
+	}
+	if pageInfo.LastPage != 3 {
+		t.Errorf("Expected the external source's page count, got %d", pageInfo.LastPage)
+	}
+
+	page, _, err = s.OpenQuestionsPage(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if len(page) != 1 || page[0].Id != 20 {
+		t.Errorf("Expected only the external question on the second page, got %v", page)
+	}
+}
+
+func TestMergedSource_Forecasts(t *testing.T) {
+	t.Parallel()
+
+	external := newTestSource(t)
+	external.ForecastsFunc = func(ctx context.Context, questions []*data.Question) ([]*data.Question, []*data.Forecast, error) {
+		if len(questions) != 1 || questions[0].Id != 5 {
+			t.Errorf("Expected only question 5 sent to the external source, got %v", questions)
+		}
+		return questions, []*data.Forecast{{Question: 5}}, nil
+	}
+	internal := newTestSource(t)
+	internal.ForecastsFunc = func(ctx context.Context, questions []*data.Question) ([]*data.Question, []*data.Forecast, error) {
+		if len(questions) != 1 || questions[0].Id != -2 {
+			t.Errorf("Expected only question -2 sent to the internal source, got %v", questions)
+		}
+		return questions, []*data.Forecast{{Question: -2}}, nil
+	}
+
+	s := &MergedSource{External: external, Internal: internal}
+	questions, forecasts, err := s.Forecasts(context.Background(), []*data.Question{{Id: -2}, {Id: 5}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if len(questions) != 2 || len(forecasts) != 2 {
+		t.Errorf("Expected 2 questions and 2 forecasts, got %v and %v", questions, forecasts)
+	}
+
+	// Only the internal source need be consulted for internal questions alone.
+	external.ForecastsFunc = func(ctx context.Context, questions []*data.Question) ([]*data.Question, []*data.Forecast, error) {
+		t.Error("Expected external source not to be called")
+		return nil, nil, nil
+	}
+	_, _, err = s.Forecasts(context.Background(), []*data.Question{{Id: -2}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+}
+
+func TestMergedSource_QuestionsSince(t *testing.T) {
+	t.Parallel()
+
+	external := newTestSource(t)
+	external.QuestionsSinceFunc = func(ctx context.Context, t2 time.Time) ([]*data.Question, error) {
+		return []*data.Question{{Id: 1}}, nil
+	}
+	internal := newTestSource(t)
+	internal.QuestionsSinceFunc = func(ctx context.Context, t2 time.Time) ([]*data.Question, error) {
+		return []*data.Question{{Id: -1}}, nil
+	}
+
+	s := &MergedSource{External: external, Internal: internal}
+	questions, err := s.QuestionsSince(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if len(questions) != 2 {
+		t.Errorf("Expected questions from both sources, got %v", questions)
+	}
+}
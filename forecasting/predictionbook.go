@@ -0,0 +1,117 @@
+package forecasting
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// PredictionBook reads questions and forecasts from PredictionBook.
+type PredictionBook struct {
+	Source PredictionBookSource
+}
+
+func (pb *PredictionBook) OpenQuestionsPage(ctx context.Context, index int64) ([]*data.Question, *data.QuestionPageInfo, error) {
+	summaries, pageInfo, err := pb.Source.RetrievePredictionListPage(ctx, index)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+
+	var questionPageInfo *data.QuestionPageInfo
+	if pageInfo != nil {
+		questionPageInfo = &data.QuestionPageInfo{
+			Index:    pageInfo.Index,
+			LastPage: pageInfo.LastPage,
+		}
+	}
+	return questionsFromSummaries(summaries), questionPageInfo, nil
+}
+
+func (pb *PredictionBook) QuestionsSince(ctx context.Context, t time.Time) ([]*data.Question, error) {
+	summaries, err := pb.Source.AllPredictionsSince(ctx, t)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return questionsFromSummaries(summaries), nil
+}
+
+func (pb *PredictionBook) Forecasts(ctx context.Context, questions []*data.Question) ([]*data.Question, []*data.Forecast, error) {
+	summaries, responses, err := pb.Source.AllPredictionResponses(ctx, summariesFromQuestions(questions))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+
+	forecasts := make([]*data.Forecast, 0, len(responses))
+	for _, r := range responses {
+		forecasts = append(forecasts, &data.Forecast{
+			Question:    r.Prediction,
+			Time:        r.Time,
+			User:        r.User,
+			Probability: r.Confidence,
+			Comment:     r.Comment,
+		})
+	}
+	return questionsFromSummaries(summaries), forecasts, nil
+}
+
+func questionsFromSummaries(summaries []*predictions.PredictionSummary) []*data.Question {
+	questions := make([]*data.Question, 0, len(summaries))
+	for _, s := range summaries {
+		q := &data.Question{
+			Id:              s.Id,
+			Title:           s.Title,
+			Creator:         s.Creator,
+			Created:         s.Created,
+			Deadline:        s.Deadline,
+			MeanProbability: s.MeanConfidence,
+			ForecastCount:   s.WagerCount,
+		}
+		switch s.Outcome {
+		case predictions.Right:
+			q.Outcome = data.ResolvedYes
+		case predictions.Wrong:
+			q.Outcome = data.ResolvedNo
+		}
+		questions = append(questions, q)
+	}
+	return questions
+}
+
+func summariesFromQuestions(questions []*data.Question) []*predictions.PredictionSummary {
+	summaries := make([]*predictions.PredictionSummary, 0, len(questions))
+	for _, q := range questions {
+		s := &predictions.PredictionSummary{
+			Id:             q.Id,
+			Title:          q.Title,
+			Creator:        q.Creator,
+			Created:        q.Created,
+			Deadline:       q.Deadline,
+			MeanConfidence: q.MeanProbability,
+			WagerCount:     q.ForecastCount,
+		}
+		switch q.Outcome {
+		case data.ResolvedYes:
+			s.Outcome = predictions.Right
+		case data.ResolvedNo:
+			s.Outcome = predictions.Wrong
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries
+}
+
+func responsesFromForecasts(forecasts []*data.Forecast) []*predictions.PredictionResponse {
+	responses := make([]*predictions.PredictionResponse, 0, len(forecasts))
+	for _, f := range forecasts {
+		responses = append(responses, &predictions.PredictionResponse{
+			Prediction: f.Question,
+			Time:       f.Time,
+			User:       f.User,
+			Confidence: f.Probability,
+			Comment:    f.Comment,
+		})
+	}
+	return responses
+}
@@ -0,0 +1,100 @@
+package forecasting
+
+import (
+	"context"
+	"errors"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/moonbird-predictor-frontend/testhelpers"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPredictionBook_OpenQuestionsPage(t *testing.T) {
+	t.Parallel()
+
+	created := time.Unix(1000, 0)
+	ps := testhelpers.NewPredictionSource(t)
+	ps.RetrievePredictionListPageFunc = func(ctx context.Context, index int64) ([]*predictions.PredictionSummary, *predictions.PredictionListPageInfo, error) {
+		if index != 2 {
+			t.Errorf("Expected page 2 to be requested, got %d", index)
+		}
+		return []*predictions.PredictionSummary{
+			{Id: 5, Title: "Five", Creator: "bob", Created: created, MeanConfidence: 0.4, WagerCount: 3},
+			{Id: 6, Outcome: predictions.Right},
+			{Id: 7, Outcome: predictions.Wrong},
+		}, &predictions.PredictionListPageInfo{
+			Index:    2,
+			LastPage: 4,
+		}, nil
+	}
+
+	pb := &PredictionBook{Source: ps}
+	questions, pageInfo, err := pb.OpenQuestionsPage(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	expected := []*data.Question{
+		{Id: 5, Title: "Five", Creator: "bob", Created: created, MeanProbability: 0.4, ForecastCount: 3},
+		{Id: 6, Outcome: data.ResolvedYes},
+		{Id: 7, Outcome: data.ResolvedNo},
+	}
+	if !reflect.DeepEqual(questions, expected) {
+		t.Errorf("Expected questions %v, got %v", expected, questions)
+	}
+	if pageInfo == nil || pageInfo.Index != 2 || pageInfo.LastPage != 4 {
+		t.Errorf("Expected page 2 of 4, got %v", pageInfo)
+	}
+}
+
+func TestPredictionBook_OpenQuestionsPage_Err(t *testing.T) {
+	t.Parallel()
+
+	ps := testhelpers.NewPredictionSource(t)
+	ps.RetrievePredictionListPageFunc = func(ctx context.Context, index int64) ([]*predictions.PredictionSummary, *predictions.PredictionListPageInfo, error) {
+		return nil, nil, errors.New("bluh")
+	}
+
+	pb := &PredictionBook{Source: ps}
+	if _, _, err := pb.OpenQuestionsPage(context.Background(), 1); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestPredictionBook_Forecasts(t *testing.T) {
+	t.Parallel()
+
+	ps := testhelpers.NewPredictionSource(t)
+	ps.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+		if len(summaries) != 1 || summaries[0].Id != 5 || summaries[0].Outcome != predictions.Right {
+			t.Errorf("Expected summary for question 5 with outcome right, got %v", summaries)
+		}
+		return []*predictions.PredictionSummary{{Id: 5, Outcome: predictions.Wrong}},
+			[]*predictions.PredictionResponse{
+				{Prediction: 5, User: "bob", Confidence: 0.3},
+				{Prediction: 5, User: "alice", Confidence: math.NaN(), Comment: "Hmm."},
+			}, nil
+	}
+
+	pb := &PredictionBook{Source: ps}
+	questions, forecasts, err := pb.Forecasts(context.Background(), []*data.Question{{Id: 5, Outcome: data.ResolvedYes}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if len(questions) != 1 || questions[0].Outcome != data.ResolvedNo {
+		t.Errorf("Expected question 5 resolved no, got %v", questions)
+	}
+	if len(forecasts) != 2 {
+		t.Fatalf("Expected 2 forecasts, got %d", len(forecasts))
+	}
+	if forecasts[0].Question != 5 || forecasts[0].User != "bob" || forecasts[0].Probability != 0.3 {
+		t.Errorf("Unexpected first forecast %v", forecasts[0])
+	}
+	if !math.IsNaN(forecasts[1].Probability) || forecasts[1].Comment != "Hmm." {
+		t.Errorf("Unexpected second forecast %v", forecasts[1])
+	}
+}
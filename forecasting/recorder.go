@@ -0,0 +1,72 @@
+package forecasting
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// Recorder captures questions and forecasts from a live source into a dump a FileSource can replay.
+type Recorder struct {
+	Source    Source
+	FileStore FileStore
+	Prefix    string
+	Format    DumpFormat
+}
+
+// Record captures all questions created after since, plus the first openPages pages of open questions,
+// along with the forecasts made on each.
+func (r *Recorder) Record(ctx context.Context, since time.Time, openPages int64) error {
+	l := ctxlogrus.Get(ctx)
+
+	seen := make(map[int64]bool)
+	var questions []*data.Question
+	addQuestions := func(qs []*data.Question) {
+		for _, q := range qs {
+			if !seen[q.Id] {
+				seen[q.Id] = true
+				questions = append(questions, q)
+			}
+		}
+	}
+
+	l.WithField("since", since).Info("Recording questions...")
+	sinceQuestions, err := r.Source.QuestionsSince(ctx, since)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	addQuestions(sinceQuestions)
+
+	for page := int64(1); page <= openPages; page++ {
+		l.WithField("page", page).Info("Recording open questions...")
+		pageQuestions, pageInfo, err := r.Source.OpenQuestionsPage(ctx, page)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+		addQuestions(pageQuestions)
+
+		if pageInfo == nil || pageInfo.Index >= pageInfo.LastPage {
+			break
+		}
+	}
+
+	l.WithField("questions", len(questions)).Info("Recording forecasts...")
+	questions, forecasts, err := r.Source.Forecasts(ctx, questions)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	err = saveDump(ctx, r.FileStore, r.Prefix, r.Format, questions, forecasts)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	l.WithFields(logrus.Fields{
+		"questions": len(questions),
+		"forecasts": len(forecasts),
+	}).Info("Recorded questions and forecasts.")
+	return nil
+}
@@ -0,0 +1,33 @@
+package forecasting
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"time"
+)
+
+// Source is a forecasting platform we can read questions and forecasts from.
+type Source interface {
+	OpenQuestionsPage(ctx context.Context, index int64) ([]*data2.Question, *data2.QuestionPageInfo, error)
+	QuestionsSince(ctx context.Context, t time.Time) ([]*data2.Question, error)
+	Forecasts(ctx context.Context, questions []*data2.Question) ([]*data2.Question, []*data2.Forecast, error)
+}
+
+type PredictionBookSource interface {
+	RetrievePredictionListPage(context.Context, int64) ([]*predictions.PredictionSummary, *predictions.PredictionListPageInfo, error)
+	AllPredictionsSince(ctx context.Context, t time.Time) ([]*predictions.PredictionSummary, error)
+	AllPredictionResponses(context.Context, []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error)
+}
+
+type FileStore interface {
+	Load(ctx context.Context, path string) ([]byte, error)
+	Save(ctx context.Context, path string, content []byte) error
+}
+
+type PersistentStore interface {
+	Get(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error)
+	Set(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error
+	Transact(ctx context.Context, f func(ctx context.Context) error) error
+}
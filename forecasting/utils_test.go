@@ -0,0 +1,77 @@
+package forecasting
+
+import (
+	"context"
+	"errors"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"testing"
+	"time"
+)
+
+func newTestSource(t *testing.T) *testSource {
+	return &testSource{
+		OpenQuestionsPageFunc: func(ctx context.Context, index int64) ([]*data.Question, *data.QuestionPageInfo, error) {
+			t.Error("OpenQuestionsPageFunc should not be called")
+			return nil, nil, nil
+		},
+		QuestionsSinceFunc: func(ctx context.Context, t2 time.Time) ([]*data.Question, error) {
+			t.Error("QuestionsSinceFunc should not be called")
+			return nil, nil
+		},
+		ForecastsFunc: func(ctx context.Context, questions []*data.Question) ([]*data.Question, []*data.Forecast, error) {
+			t.Error("ForecastsFunc should not be called")
+			return nil, nil, nil
+		},
+	}
+}
+
+type testSource struct {
+	OpenQuestionsPageFunc func(ctx context.Context, index int64) ([]*data.Question, *data.QuestionPageInfo, error)
+	QuestionsSinceFunc    func(ctx context.Context, t time.Time) ([]*data.Question, error)
+	ForecastsFunc         func(ctx context.Context, questions []*data.Question) ([]*data.Question, []*data.Forecast, error)
+}
+
+func (s *testSource) OpenQuestionsPage(ctx context.Context, index int64) ([]*data.Question, *data.QuestionPageInfo, error) {
+	return s.OpenQuestionsPageFunc(ctx, index)
+}
+
+func (s *testSource) QuestionsSince(ctx context.Context, t time.Time) ([]*data.Question, error) {
+	return s.QuestionsSinceFunc(ctx, t)
+}
+
+func (s *testSource) Forecasts(ctx context.Context, questions []*data.Question) ([]*data.Question, []*data.Forecast, error) {
+	return s.ForecastsFunc(ctx, questions)
+}
+
+// newMemoryFileStore returns a FileStore fake which keeps saved files in a map.
+func newMemoryFileStore() *testFileStore {
+	files := make(map[string][]byte)
+	return &testFileStore{
+		Files: files,
+		LoadFunc: func(ctx context.Context, path string) ([]byte, error) {
+			content, ok := files[path]
+			if !ok {
+				return nil, errors.New("no such file")
+			}
+			return content, nil
+		},
+		SaveFunc: func(ctx context.Context, path string, content []byte) error {
+			files[path] = content
+			return nil
+		},
+	}
+}
+
+type testFileStore struct {
+	Files    map[string][]byte
+	LoadFunc func(ctx context.Context, path string) ([]byte, error)
+	SaveFunc func(ctx context.Context, path string, content []byte) error
+}
+
+func (fs *testFileStore) Load(ctx context.Context, path string) ([]byte, error) {
+	return fs.LoadFunc(ctx, path)
+}
+
+func (fs *testFileStore) Save(ctx context.Context, path string, content []byte) error {
+	return fs.SaveFunc(ctx, path, content)
+}
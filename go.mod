@@ -14,4 +14,5 @@ require (
 	golang.org/x/time v0.0.0-20191024005414-555d28b269f0
 	google.golang.org/api v0.42.0
 	google.golang.org/appengine v1.6.7
+	gopkg.in/yaml.v2 v2.4.0
 )
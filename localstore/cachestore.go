@@ -0,0 +1,127 @@
+package localstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+var ErrCacheMiss = errors.New("cache miss")
+
+const defaultMemoryCacheEntries = 10000
+
+// CacheBackend holds encoded cache entries. Flush removes every entry, whatever its prefix,
+// as flushing memcache does on App Engine.
+type CacheBackend interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte) error
+	Delete(ctx context.Context, key string) error
+	Flush(ctx context.Context) error
+}
+
+// CacheStore caches gob-encoded values in a backend, under keys with a prefix.
+type CacheStore struct {
+	Backend CacheBackend
+	Prefix  string
+}
+
+func (cs *CacheStore) Get(ctx context.Context, key string, v interface{}) error {
+	l := ctxlogrus.Get(ctx)
+	l.WithFields(logrus.Fields{"prefix": cs.Prefix, "key": key}).Debug("cache get")
+
+	content, err := cs.Backend.Get(ctx, cs.Prefix+key)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	return errors.Wrap(gob.NewDecoder(bytes.NewReader(content)).Decode(v), "")
+}
+
+func (cs *CacheStore) Set(ctx context.Context, key string, v interface{}) error {
+	l := ctxlogrus.Get(ctx)
+	l.WithFields(logrus.Fields{"prefix": cs.Prefix, "key": key}).Debug("cache set")
+
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	return errors.Wrap(cs.Backend.Set(ctx, cs.Prefix+key, buf.Bytes()), "")
+}
+
+func (cs *CacheStore) Delete(ctx context.Context, key string) error {
+	l := ctxlogrus.Get(ctx)
+	l.WithFields(logrus.Fields{"prefix": cs.Prefix, "key": key}).Debug("cache delete")
+
+	return errors.Wrap(cs.Backend.Delete(ctx, cs.Prefix+key), "")
+}
+
+func (cs *CacheStore) Flush(ctx context.Context) error {
+	l := ctxlogrus.Get(ctx)
+	l.Debug("cache clear - full purge")
+
+	return errors.Wrap(cs.Backend.Flush(ctx), "")
+}
+
+// MemoryCache keeps cache entries in process memory.
+// Once full, an arbitrary entry is evicted to make room for each new one.
+type MemoryCache struct {
+	// MaxEntries defaults to 10000.
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func (mc *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	value, ok := mc.entries[key]
+	if !ok {
+		return nil, errors.WithStack(ErrCacheMiss)
+	}
+	return value, nil
+}
+
+func (mc *MemoryCache) Set(ctx context.Context, key string, value []byte) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.entries == nil {
+		mc.entries = make(map[string][]byte)
+	}
+
+	maxEntries := mc.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultMemoryCacheEntries
+	}
+	if _, ok := mc.entries[key]; !ok && len(mc.entries) >= maxEntries {
+		for evicted := range mc.entries {
+			delete(mc.entries, evicted)
+			break
+		}
+	}
+
+	mc.entries[key] = value
+	return nil
+}
+
+func (mc *MemoryCache) Delete(ctx context.Context, key string) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	delete(mc.entries, key)
+	return nil
+}
+
+func (mc *MemoryCache) Flush(ctx context.Context) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.entries = nil
+	return nil
+}
@@ -0,0 +1,69 @@
+package localstore
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"testing"
+)
+
+func TestCacheStore_Memory(t *testing.T) {
+	t.Parallel()
+
+	backend := &MemoryCache{}
+	first := &CacheStore{Backend: backend, Prefix: "first-"}
+	second := &CacheStore{Backend: backend, Prefix: "second-"}
+	c := context.Background()
+
+	type value struct {
+		Name  string
+		Items []float64
+	}
+	if err := first.Set(c, "key", &value{Name: "bluh", Items: []float64{0.5}}); err != nil {
+		t.Fatalf("Expected no error setting, got %s", err)
+	}
+
+	got := new(value)
+	if err := first.Get(c, "key", got); err != nil || got.Name != "bluh" || len(got.Items) != 1 {
+		t.Errorf("Expected stored value, got %v, %v", got, err)
+	}
+	if err := second.Get(c, "key", new(value)); errors.Cause(err) != ErrCacheMiss {
+		t.Errorf("Expected cache miss under a different prefix, got %v", err)
+	}
+
+	if err := first.Delete(c, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Get(c, "key", new(value)); errors.Cause(err) != ErrCacheMiss {
+		t.Errorf("Expected cache miss after delete, got %v", err)
+	}
+
+	// Flushing through either store clears both, as on App Engine.
+	if err := first.Set(c, "key", 1.0); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Flush(c); err != nil {
+		t.Fatal(err)
+	}
+	var f float64
+	if err := first.Get(c, "key", &f); errors.Cause(err) != ErrCacheMiss {
+		t.Errorf("Expected cache miss after flush, got %v", err)
+	}
+}
+
+func TestMemoryCache_MaxEntries(t *testing.T) {
+	t.Parallel()
+
+	mc := &MemoryCache{MaxEntries: 2}
+	c := context.Background()
+	for _, key := range []string{"a", "b", "c", "c"} {
+		if err := mc.Set(c, key, []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(mc.entries) != 2 {
+		t.Errorf("Expected 2 entries kept, got %d", len(mc.entries))
+	}
+	if v, err := mc.Get(c, "c"); err != nil || string(v) != "c" {
+		t.Errorf("Expected most recent entry kept, got %q, %v", v, err)
+	}
+}
@@ -0,0 +1,58 @@
+package localstore
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type userKey struct{}
+
+// ContextMaker makes request contexts when running outside App Engine.
+// Signed in users are identified by a header set by an authenticating reverse proxy in front of us;
+// without UserHeader, no one is signed in.
+type ContextMaker struct {
+	Logger     *logrus.Logger
+	UserHeader string
+}
+
+func (cm *ContextMaker) MakeContext(r *http.Request) (context.Context, error) {
+	ctx := r.Context()
+
+	logger := cm.Logger
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	ctx = ctxlogrus.WithLogger(ctx, logrus.NewEntry(logger))
+
+	if cm.UserHeader != "" {
+		if user := r.Header.Get(cm.UserHeader); user != "" {
+			ctx = context.WithValue(ctx, userKey{}, user)
+		}
+	}
+	return ctx, nil
+}
+
+// UserService reports the user identified by ContextMaker, if any, and whether they're one of AdminUsers.
+type UserService struct {
+	AdminUsers []string
+}
+
+func (us *UserService) ContextUser(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+func (us *UserService) ContextUserIsAdmin(ctx context.Context) bool {
+	user := us.ContextUser(ctx)
+	if user == "" {
+		return false
+	}
+	for _, admin := range us.AdminUsers {
+		if admin == user {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package localstore
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextMaker_User(t *testing.T) {
+	t.Parallel()
+
+	cm := &ContextMaker{UserHeader: "X-Forwarded-User"}
+	us := &UserService{}
+
+	r := httptest.NewRequest("GET", "/questions", nil)
+	ctx, err := cm.MakeContext(r)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if user := us.ContextUser(ctx); user != "" {
+		t.Errorf("Expected no user without the header, got '%s'", user)
+	}
+
+	r.Header.Set("X-Forwarded-User", "bob")
+	ctx, err = cm.MakeContext(r)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if user := us.ContextUser(ctx); user != "bob" {
+		t.Errorf("Expected user 'bob', got '%s'", user)
+	}
+
+	// Without a configured header, no request may claim a user.
+	ctx, err = (&ContextMaker{}).MakeContext(r)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if user := us.ContextUser(ctx); user != "" {
+		t.Errorf("Expected no user without a configured header, got '%s'", user)
+	}
+}
+
+func TestUserService_ContextUserIsAdmin(t *testing.T) {
+	t.Parallel()
+
+	us := &UserService{AdminUsers: []string{"alice"}}
+	cm := &ContextMaker{UserHeader: "X-Forwarded-User"}
+
+	for user, admin := range map[string]bool{"alice": true, "bob": false, "": false} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Forwarded-User", user)
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		if us.ContextUserIsAdmin(ctx) != admin {
+			t.Errorf("Expected admin to be %v for user '%s'", admin, user)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package localstore
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidPath = errors.New("invalid file path")
+
+const tempFilePrefix = ".tmp-"
+
+// FileStore stores files in a directory on the local filesystem.
+// Paths are slash-separated and relative to the directory; paths which could refer outside it are refused.
+type FileStore struct {
+	Dir string
+}
+
+func (fs *FileStore) Load(ctx context.Context, p string) ([]byte, error) {
+	l := ctxlogrus.Get(ctx)
+	l.WithFields(logrus.Fields{"dir": fs.Dir, "path": p}).Debug("file load")
+
+	fullPath, err := fs.fullPath(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	content, err := ioutil.ReadFile(fullPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return content, nil
+}
+
+// Save replaces the file by renaming a complete new file over it, so it is never seen partially written.
+func (fs *FileStore) Save(ctx context.Context, p string, content []byte) error {
+	l := ctxlogrus.Get(ctx)
+	l.WithFields(logrus.Fields{"dir": fs.Dir, "path": p}).Debug("file save")
+
+	fullPath, err := fs.fullPath(p)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	return errors.Wrap(writeFileAtomic(fullPath, content), "")
+}
+
+// Delete removes the file. Deleting a file which does not exist is not an error.
+func (fs *FileStore) Delete(ctx context.Context, p string) error {
+	l := ctxlogrus.Get(ctx)
+	l.WithFields(logrus.Fields{"dir": fs.Dir, "path": p}).Debug("file delete")
+
+	fullPath, err := fs.fullPath(p)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	err = os.Remove(fullPath)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "")
+	}
+	return nil
+}
+
+// List returns the paths of all files beginning with prefix, sorted.
+// As with Cloud Storage, the prefix need not end at a directory boundary.
+func (fs *FileStore) List(ctx context.Context, prefix string) ([]string, error) {
+	l := ctxlogrus.Get(ctx)
+	l.WithFields(logrus.Fields{"dir": fs.Dir, "prefix": prefix}).Debug("file list")
+
+	if strings.Contains(prefix, "\\") {
+		return nil, errors.WithStack(ErrInvalidPath)
+	}
+	for _, segment := range strings.Split(prefix, "/") {
+		if segment == ".." {
+			return nil, errors.WithStack(ErrInvalidPath)
+		}
+	}
+
+	var paths []string
+	err := filepath.Walk(fs.Dir, func(fullPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			if fullPath == fs.Dir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), tempFilePrefix) {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(fs.Dir, fullPath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if strings.HasPrefix(relPath, prefix) {
+			paths = append(paths, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (fs *FileStore) fullPath(p string) (string, error) {
+	if p == "" || path.IsAbs(p) || strings.Contains(p, "\\") {
+		return "", errors.WithStack(ErrInvalidPath)
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." || strings.HasPrefix(segment, tempFilePrefix) {
+			return "", errors.WithStack(ErrInvalidPath)
+		}
+	}
+
+	cleaned := path.Clean(p)
+	if cleaned == "." {
+		return "", errors.WithStack(ErrInvalidPath)
+	}
+	return filepath.Join(fs.Dir, filepath.FromSlash(cleaned)), nil
+}
+
+// writeFileAtomic writes the file by renaming a complete temporary file over it.
+func writeFileAtomic(fullPath string, content []byte) error {
+	err := os.MkdirAll(filepath.Dir(fullPath), 0755)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(fullPath), tempFilePrefix)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	_, err = f.Write(content)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), fullPath)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return errors.Wrap(err, "")
+	}
+	return nil
+}
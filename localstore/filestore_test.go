@@ -0,0 +1,164 @@
+package localstore
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileStore_SaveLoad(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+
+	fs := &FileStore{Dir: dir}
+	err := fs.Save(context.Background(), "a/b/c.csv", []byte("bluh"))
+	if err != nil {
+		t.Fatalf("Expected no error saving, got %s", err)
+	}
+	err = fs.Save(context.Background(), "a/b/c.csv", []byte("bluh2"))
+	if err != nil {
+		t.Fatalf("Expected no error overwriting, got %s", err)
+	}
+
+	content, err := fs.Load(context.Background(), "a/b/c.csv")
+	if err != nil {
+		t.Fatalf("Expected no error loading, got %s", err)
+	}
+	if string(content) != "bluh2" {
+		t.Errorf("Expected content 'bluh2', got '%s'", content)
+	}
+
+	// No temporary files should be left behind.
+	entries, err := ioutil.ReadDir(filepath.Join(dir, "a", "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the saved file in its directory, found %d entries", len(entries))
+	}
+}
+
+func TestFileStore_Load_Missing(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+
+	fs := &FileStore{Dir: dir}
+	_, err := fs.Load(context.Background(), "missing.csv")
+	if err == nil {
+		t.Error("Expected error loading missing file, got nil")
+	}
+}
+
+func TestFileStore_InvalidPaths(t *testing.T) {
+	t.Parallel()
+
+	parent, cleanup := newTestDir(t)
+	defer cleanup()
+	dir := filepath.Join(parent, "store")
+
+	err := ioutil.WriteFile(filepath.Join(parent, "secret"), []byte("secret"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := &FileStore{Dir: dir}
+	c := context.Background()
+	for _, p := range []string{"", ".", "../secret", "a/../../secret", "/etc/passwd", "a\\..\\..\\secret", ".tmp-123"} {
+		if _, err := fs.Load(c, p); errors.Cause(err) != ErrInvalidPath {
+			t.Errorf("Expected ErrInvalidPath loading '%s', got %v", p, err)
+		}
+		if err := fs.Save(c, p, []byte("bluh")); errors.Cause(err) != ErrInvalidPath {
+			t.Errorf("Expected ErrInvalidPath saving '%s', got %v", p, err)
+		}
+		if err := fs.Delete(c, p); errors.Cause(err) != ErrInvalidPath {
+			t.Errorf("Expected ErrInvalidPath deleting '%s', got %v", p, err)
+		}
+	}
+	if _, err := fs.List(c, "../"); errors.Cause(err) != ErrInvalidPath {
+		t.Errorf("Expected ErrInvalidPath listing '../', got %v", err)
+	}
+
+	if content, err := ioutil.ReadFile(filepath.Join(parent, "secret")); err != nil || string(content) != "secret" {
+		t.Errorf("Expected file outside the store to be untouched, got '%s', %v", content, err)
+	}
+}
+
+func TestFileStore_ListDelete(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+
+	fs := &FileStore{Dir: dir}
+	c := context.Background()
+
+	paths, err := fs.List(c, "")
+	if err != nil || len(paths) != 0 {
+		t.Errorf("Expected empty listing of empty store, got %v, %v", paths, err)
+	}
+
+	for _, p := range []string{"100/responsedata.csv", "100/summarydata.csv", "1000/responsedata.csv", "200/responsedata.csv"} {
+		if err := fs.Save(c, p, []byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err = fs.List(c, "100")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	expected := []string{"100/responsedata.csv", "100/summarydata.csv", "1000/responsedata.csv"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+
+	if err := fs.Delete(c, "100/summarydata.csv"); err != nil {
+		t.Fatalf("Expected no error deleting, got %s", err)
+	}
+	if err := fs.Delete(c, "100/summarydata.csv"); err != nil {
+		t.Errorf("Expected no error deleting a missing file, got %s", err)
+	}
+
+	paths, err = fs.List(c, "100/")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if !reflect.DeepEqual(paths, []string{"100/responsedata.csv"}) {
+		t.Errorf("Expected only the remaining file, got %v", paths)
+	}
+
+	// A store whose directory is yet to be created lists nothing.
+	paths, err = (&FileStore{Dir: filepath.Join(dir, "missing")}).List(c, "")
+	if err != nil || len(paths) != 0 {
+		t.Errorf("Expected empty listing of missing directory, got %v, %v", paths, err)
+	}
+}
+
+func TestFileStore_List_SkipsTempFiles(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+
+	err := os.MkdirAll(filepath.Join(dir, "a"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "a", tempFilePrefix+"123"), []byte("partial"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := (&FileStore{Dir: dir}).List(context.Background(), "")
+	if err != nil || len(paths) != 0 {
+		t.Errorf("Expected temporary files to be skipped, got %v, %v", paths, err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package localstore
+
+// lockFile does not lock between processes on Windows; only one process may use a store's directory at once.
+func lockFile(path string) (unlock func(), err error) {
+	return func() {}, nil
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package localstore
+
+import (
+	"github.com/pkg/errors"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on the file, creating it if need be, waiting for any other holder.
+func lockFile(path string) (unlock func(), err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "")
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,389 @@
+package localstore
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var errConcurrentTransaction = errors.New("concurrent transaction")
+
+// Transactions which conflict with another are attempted optimistically this many times,
+// before a final attempt made holding the directory's lock.
+const optimisticAttempts = 3
+
+// PersistentStore keeps entities as JSON files in a directory, one file per kind and key.
+//
+// Transactions are optimistic: writes are buffered until the transaction function succeeds,
+// then committed under a lock on the directory, held by one process at a time, only if no entity
+// the transaction read has changed since. Otherwise the transaction is retried, seeing the other
+// transaction's writes; if it keeps conflicting, it is run a last time holding the lock throughout.
+// Each entity is replaced atomically, but a crash part way through committing a transaction
+// may leave only some of its writes made.
+type PersistentStore struct {
+	Dir    string
+	Prefix string
+}
+
+type storedEntity struct {
+	// Version is incremented each time the entity is written, so transactions can detect conflicts.
+	Version    int64
+	Properties []storedProperty
+	Content    json.RawMessage
+}
+
+// storedProperty records the type of a property's value, which JSON alone would lose.
+type storedProperty struct {
+	Name  string
+	Type  string
+	Value json.RawMessage
+}
+
+type transaction struct {
+	// reads holds the version of each entity when the transaction first read it,
+	// with -1 for entities which did not exist.
+	reads map[string]int64
+
+	// writes holds each entity written by the transaction, or nil if it was deleted.
+	writes map[string]*storedEntity
+}
+
+type transactionKey struct {
+	dir string
+}
+
+var dirLocksMu sync.Mutex
+var dirLocks = make(map[string]*sync.Mutex)
+
+func (ps *PersistentStore) Get(ctx context.Context, kind, key string, content interface{}) ([]data.Property, error) {
+	l := ctxlogrus.Get(ctx)
+	l.WithFields(logrus.Fields{"prefix": ps.Prefix, "kind": kind, "key": key}).Debug("local store get")
+
+	path := ps.entityPath(kind, key)
+	tx := ps.transaction(ctx)
+	var entity *storedEntity
+	written := false
+	if tx != nil {
+		entity, written = tx.writes[path]
+	}
+	if !written {
+		var err error
+		entity, err = readEntity(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+
+		if tx != nil {
+			if _, ok := tx.reads[path]; !ok {
+				tx.reads[path] = entityVersion(entity)
+			}
+		}
+	}
+	if entity == nil {
+		return nil, data.ErrNoSuchEntity
+	}
+
+	if content != nil {
+		if entity.Content == nil {
+			return nil, errors.New("entity did not contain content to deserialize, but content param was set")
+		}
+		err := json.Unmarshal(entity.Content, content)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to deserialize entity content")
+		}
+	}
+
+	return propertiesFromStored(entity.Properties)
+}
+
+func (ps *PersistentStore) Set(ctx context.Context, kind, key string, properties []data.Property, content interface{}) error {
+	l := ctxlogrus.Get(ctx)
+	l.WithFields(logrus.Fields{"prefix": ps.Prefix, "kind": kind, "key": key}).Debug("local store set")
+
+	entity := new(storedEntity)
+	var err error
+	entity.Properties, err = propertiesToStored(properties)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	if content != nil {
+		entity.Content, err = json.Marshal(content)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+	}
+
+	path := ps.entityPath(kind, key)
+	if tx := ps.transaction(ctx); tx != nil {
+		tx.writes[path] = entity
+		return nil
+	}
+
+	return errors.Wrap(ps.commit(&transaction{
+		writes: map[string]*storedEntity{path: entity},
+	}), "")
+}
+
+// Delete removes the entity, if it exists.
+func (ps *PersistentStore) Delete(ctx context.Context, kind, key string) error {
+	l := ctxlogrus.Get(ctx)
+	l.WithFields(logrus.Fields{"prefix": ps.Prefix, "kind": kind, "key": key}).Debug("local store delete")
+
+	path := ps.entityPath(kind, key)
+	if tx := ps.transaction(ctx); tx != nil {
+		tx.writes[path] = nil
+		return nil
+	}
+
+	return errors.Wrap(ps.commit(&transaction{
+		writes: map[string]*storedEntity{path: nil},
+	}), "")
+}
+
+// Transact runs f in a transaction. Transactions nested within one on the same directory join it.
+// As f may be run more than once, it should have no effects outside the transaction.
+func (ps *PersistentStore) Transact(ctx context.Context, f func(ctx context.Context) error) error {
+	if ps.transaction(ctx) != nil {
+		return f(ctx)
+	}
+
+	l := ctxlogrus.Get(ctx)
+	l.Debug("local store transaction start")
+	defer l.Debug("local store transaction end")
+
+	for attempt := 1; attempt <= optimisticAttempts; attempt++ {
+		tx := newTransaction()
+		err := f(context.WithValue(ctx, transactionKey{ps.Dir}, tx))
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+
+		err = ps.commit(tx)
+		if errors.Cause(err) != errConcurrentTransaction {
+			return errors.Wrap(err, "")
+		}
+		l.WithField("attempt", attempt).Debug("local store transaction conflicted")
+	}
+
+	unlock, err := ps.lock()
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	defer unlock()
+
+	tx := newTransaction()
+	err = f(context.WithValue(ctx, transactionKey{ps.Dir}, tx))
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	return errors.Wrap(ps.commitLocked(tx), "")
+}
+
+func newTransaction() *transaction {
+	return &transaction{
+		reads:  make(map[string]int64),
+		writes: make(map[string]*storedEntity),
+	}
+}
+
+// commit makes the transaction's writes, if nothing it read has since changed.
+func (ps *PersistentStore) commit(tx *transaction) error {
+	if len(tx.writes) == 0 {
+		return nil
+	}
+
+	unlock, err := ps.lock()
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	defer unlock()
+
+	return errors.Wrap(ps.commitLocked(tx), "")
+}
+
+// commitLocked is commit, for callers already holding the directory's lock.
+func (ps *PersistentStore) commitLocked(tx *transaction) error {
+
+	for path, version := range tx.reads {
+		current, err := readEntity(path)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+		if entityVersion(current) != version {
+			return errors.WithStack(errConcurrentTransaction)
+		}
+	}
+
+	for path, entity := range tx.writes {
+		if entity == nil {
+			err := os.Remove(path)
+			if err != nil && !os.IsNotExist(err) {
+				return errors.Wrap(err, "")
+			}
+			continue
+		}
+
+		current, err := readEntity(path)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+		entity.Version = entityVersion(current) + 1
+		if entity.Version < 1 {
+			entity.Version = 1
+		}
+
+		err = writeEntity(path, entity)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+	}
+	return nil
+}
+
+func (ps *PersistentStore) transaction(ctx context.Context) *transaction {
+	tx, _ := ctx.Value(transactionKey{ps.Dir}).(*transaction)
+	return tx
+}
+
+// lock takes the lock on the store's directory, both within this process and between processes.
+func (ps *PersistentStore) lock() (unlock func(), err error) {
+	dirLocksMu.Lock()
+	processLock, ok := dirLocks[ps.Dir]
+	if !ok {
+		processLock = new(sync.Mutex)
+		dirLocks[ps.Dir] = processLock
+	}
+	dirLocksMu.Unlock()
+
+	processLock.Lock()
+	err = os.MkdirAll(ps.Dir, 0755)
+	if err != nil {
+		processLock.Unlock()
+		return nil, errors.Wrap(err, "")
+	}
+	unlockFile, err := lockFile(filepath.Join(ps.Dir, ".lock"))
+	if err != nil {
+		processLock.Unlock()
+		return nil, errors.Wrap(err, "")
+	}
+
+	return func() {
+		unlockFile()
+		processLock.Unlock()
+	}, nil
+}
+
+func (ps *PersistentStore) entityPath(kind, key string) string {
+	return filepath.Join(ps.Dir, url.PathEscape(kind), url.PathEscape(ps.Prefix+key)+".json")
+}
+
+// readEntity returns the stored entity, or nil if there is none.
+func readEntity(path string) (*storedEntity, error) {
+	fileContent, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "")
+	}
+
+	entity := new(storedEntity)
+	err = json.Unmarshal(fileContent, entity)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to deserialize entity")
+	}
+	return entity, nil
+}
+
+func entityVersion(entity *storedEntity) int64 {
+	if entity == nil {
+		return -1
+	}
+	return entity.Version
+}
+
+func writeEntity(path string, entity *storedEntity) error {
+	content, err := json.Marshal(entity)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	return errors.Wrap(writeFileAtomic(path, content), "")
+}
+
+func propertiesToStored(properties []data.Property) ([]storedProperty, error) {
+	var stored []storedProperty
+	for _, p := range properties {
+		var typ string
+		switch p.Value.(type) {
+		case nil:
+			typ = "nil"
+		case int64:
+			typ = "int64"
+		case bool:
+			typ = "bool"
+		case string:
+			typ = "string"
+		case float64:
+			typ = "float64"
+		default:
+			return nil, errors.Errorf("property '%s' had invalid type: %T", p.Name, p.Value)
+		}
+
+		value, err := json.Marshal(p.Value)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		stored = append(stored, storedProperty{
+			Name:  p.Name,
+			Type:  typ,
+			Value: value,
+		})
+	}
+	return stored, nil
+}
+
+func propertiesFromStored(stored []storedProperty) ([]data.Property, error) {
+	var properties []data.Property
+	for _, s := range stored {
+		var value interface{}
+		var err error
+		switch s.Type {
+		case "nil":
+		case "int64":
+			var v int64
+			err = json.Unmarshal(s.Value, &v)
+			value = v
+		case "bool":
+			var v bool
+			err = json.Unmarshal(s.Value, &v)
+			value = v
+		case "string":
+			var v string
+			err = json.Unmarshal(s.Value, &v)
+			value = v
+		case "float64":
+			var v float64
+			err = json.Unmarshal(s.Value, &v)
+			value = v
+		default:
+			err = errors.Errorf("property '%s' had unknown type %s", s.Name, s.Type)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+
+		properties = append(properties, data.Property{
+			Name:  s.Name,
+			Value: value,
+		})
+	}
+	return properties, nil
+}
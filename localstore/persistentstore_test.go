@@ -0,0 +1,265 @@
+package localstore
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func newTestDir(t *testing.T) (dir string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "localstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+type testEntity struct {
+	Count int64
+	Names []string
+}
+
+func TestPersistentStore_GetSet(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+
+	ps := &PersistentStore{Dir: dir, Prefix: "pre-"}
+	c := context.Background()
+
+	_, err := ps.Get(c, "Kind", "key/with/slashes", new(testEntity))
+	if err != data.ErrNoSuchEntity {
+		t.Errorf("Expected ErrNoSuchEntity, got %v", err)
+	}
+
+	properties := []data.Property{
+		{Name: "int", Value: int64(5)},
+		{Name: "bool", Value: true},
+		{Name: "string", Value: "bluh"},
+		{Name: "float", Value: 0.5},
+		{Name: "nil"},
+	}
+	err = ps.Set(c, "Kind", "key/with/slashes", properties, &testEntity{Count: 3, Names: []string{"a"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	got := new(testEntity)
+	gotProperties, err := ps.Get(c, "Kind", "key/with/slashes", got)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if got.Count != 3 || !reflect.DeepEqual(got.Names, []string{"a"}) {
+		t.Errorf("Expected stored content, got %v", got)
+	}
+	if !reflect.DeepEqual(gotProperties, properties) {
+		t.Errorf("Expected properties %v, got %v", properties, gotProperties)
+	}
+
+	// Stores with other prefixes in the same directory keep separate entities.
+	other := &PersistentStore{Dir: dir, Prefix: "other-"}
+	if _, err := other.Get(c, "Kind", "key/with/slashes", new(testEntity)); err != data.ErrNoSuchEntity {
+		t.Errorf("Expected ErrNoSuchEntity under another prefix, got %v", err)
+	}
+
+	err = ps.Set(c, "Kind", "bad", []data.Property{{Name: "int", Value: 5}}, nil)
+	if err == nil {
+		t.Error("Expected error setting a property of invalid type, got nil")
+	}
+}
+
+func TestPersistentStore_Delete(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+
+	ps := &PersistentStore{Dir: dir}
+	c := context.Background()
+
+	if err := ps.Delete(c, "Kind", "missing"); err != nil {
+		t.Errorf("Expected deleting a missing entity to succeed, got %s", err)
+	}
+
+	_ = ps.Set(c, "Kind", "a", nil, &testEntity{Count: 1})
+	_ = ps.Set(c, "Kind", "b", nil, &testEntity{Count: 2})
+	if err := ps.Delete(c, "Kind", "a"); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if _, err := ps.Get(c, "Kind", "a", new(testEntity)); err != data.ErrNoSuchEntity {
+		t.Errorf("Expected ErrNoSuchEntity after deleting, got %v", err)
+	}
+
+	// Within a transaction, the deletion is seen at once, and made on commit.
+	err := ps.Transact(c, func(c context.Context) error {
+		if err := ps.Delete(c, "Kind", "b"); err != nil {
+			return err
+		}
+		if _, err := ps.Get(c, "Kind", "b", new(testEntity)); err != data.ErrNoSuchEntity {
+			t.Errorf("Expected ErrNoSuchEntity within the deleting transaction, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if _, err := ps.Get(c, "Kind", "b", new(testEntity)); err != data.ErrNoSuchEntity {
+		t.Errorf("Expected ErrNoSuchEntity after the transaction, got %v", err)
+	}
+}
+
+func TestPersistentStore_Transact(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+
+	ps := &PersistentStore{Dir: dir}
+	c := context.Background()
+
+	// Writes are visible within the transaction, but discarded if it fails.
+	err := ps.Transact(c, func(ctx context.Context) error {
+		if err := ps.Set(ctx, "Kind", "key", nil, &testEntity{Count: 1}); err != nil {
+			return err
+		}
+		got := new(testEntity)
+		if _, err := ps.Get(ctx, "Kind", "key", got); err != nil || got.Count != 1 {
+			t.Errorf("Expected write visible within transaction, got %v, %v", got, err)
+		}
+		return errors.New("bluh")
+	})
+	if err == nil {
+		t.Error("Expected transaction error to be returned, got nil")
+	}
+	if _, err := ps.Get(c, "Kind", "key", new(testEntity)); err != data.ErrNoSuchEntity {
+		t.Errorf("Expected failed transaction's write to be discarded, got %v", err)
+	}
+
+	// Concurrent read-modify-write transactions must not lose updates.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := ps.Transact(c, func(ctx context.Context) error {
+				e := new(testEntity)
+				if _, err := ps.Get(ctx, "Kind", "counter", e); err != nil && err != data.ErrNoSuchEntity {
+					return err
+				}
+				e.Count++
+				return ps.Transact(ctx, func(ctx context.Context) error {
+					return ps.Set(ctx, "Kind", "counter", nil, e)
+				})
+			})
+			if err != nil {
+				t.Errorf("Expected no error, got %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	counter := new(testEntity)
+	if _, err := ps.Get(c, "Kind", "counter", counter); err != nil || counter.Count != 20 {
+		t.Errorf("Expected counter of 20, got %d, %v", counter.Count, err)
+	}
+}
+
+func TestPersistentStore_TransactConflict(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+
+	// Separate stores on the same directory, as separate processes would have.
+	ps := &PersistentStore{Dir: dir}
+	other := &PersistentStore{Dir: dir}
+	c := context.Background()
+
+	if err := ps.Set(c, "Kind", "status", nil, &testEntity{Count: 1}); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	// A write committed between a transaction's read and its commit must cause it to be rerun,
+	// seeing the write, as updateLatestModel-style checks depend on.
+	var attempts int
+	var seen []int64
+	err := ps.Transact(c, func(ctx context.Context) error {
+		attempts++
+
+		e := new(testEntity)
+		if _, err := ps.Get(ctx, "Kind", "status", e); err != nil {
+			return err
+		}
+		seen = append(seen, e.Count)
+
+		if attempts == 1 {
+			if err := other.Set(c, "Kind", "status", nil, &testEntity{Count: 10}); err != nil {
+				return err
+			}
+		}
+
+		e.Count++
+		return ps.Set(ctx, "Kind", "status", nil, e)
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if !reflect.DeepEqual(seen, []int64{1, 10}) {
+		t.Errorf("Expected transaction to be rerun after conflict, saw %v", seen)
+	}
+
+	got := new(testEntity)
+	if _, err := other.Get(c, "Kind", "status", got); err != nil || got.Count != 11 {
+		t.Errorf("Expected count of 11, got %d, %v", got.Count, err)
+	}
+
+	// A transaction which always conflicts must eventually run holding the lock, and succeed.
+	attempts = 0
+	err = ps.Transact(c, func(ctx context.Context) error {
+		attempts++
+
+		e := new(testEntity)
+		if _, err := ps.Get(ctx, "Kind", "status", e); err != nil {
+			return err
+		}
+		if attempts <= optimisticAttempts {
+			if err := other.Set(c, "Kind", "status", nil, &testEntity{Count: e.Count + 1}); err != nil {
+				return err
+			}
+		}
+
+		e.Count += 100
+		return ps.Set(ctx, "Kind", "status", nil, e)
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if attempts != optimisticAttempts+1 {
+		t.Errorf("Expected %d attempts, got %d", optimisticAttempts+1, attempts)
+	}
+	if _, err := other.Get(c, "Kind", "status", got); err != nil || got.Count != 114 {
+		t.Errorf("Expected count of 114, got %d, %v", got.Count, err)
+	}
+
+	// Writes to entities the transaction did not read do not conflict.
+	attempts = 0
+	err = ps.Transact(c, func(ctx context.Context) error {
+		attempts++
+		if err := other.Set(c, "Kind", "unrelated", nil, &testEntity{Count: 1}); err != nil {
+			return err
+		}
+		return ps.Set(ctx, "Kind", "blind", nil, &testEntity{Count: 1})
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected blind write to be attempted once, got %d attempts", attempts)
+	}
+}
@@ -0,0 +1,221 @@
+package localstore
+
+import (
+	"bufio"
+	"context"
+	"github.com/pkg/errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultRedisTimeout = 5 * time.Second
+const maxIdleRedisConns = 4
+
+// RedisCache keeps cache entries in Redis, or any server speaking its protocol.
+type RedisCache struct {
+	Addr     string
+	Password string
+	DB       int
+
+	// Timeout bounds connecting and each command, where the context has no sooner deadline.
+	// Defaults to five seconds.
+	Timeout time.Duration
+
+	mu   sync.Mutex
+	idle []*redisConn
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// redisError is an error reply from the server, after which the connection remains usable.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+func (rc *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	reply, err := rc.do(ctx, "GET", key)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	if reply == nil {
+		return nil, errors.WithStack(ErrCacheMiss)
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, errors.Errorf("unexpected reply to GET: %v", reply)
+	}
+	return value, nil
+}
+
+func (rc *RedisCache) Set(ctx context.Context, key string, value []byte) error {
+	_, err := rc.do(ctx, "SET", key, string(value))
+	return errors.Wrap(err, "")
+}
+
+func (rc *RedisCache) Delete(ctx context.Context, key string) error {
+	_, err := rc.do(ctx, "DEL", key)
+	return errors.Wrap(err, "")
+}
+
+func (rc *RedisCache) Flush(ctx context.Context) error {
+	_, err := rc.do(ctx, "FLUSHDB")
+	return errors.Wrap(err, "")
+}
+
+func (rc *RedisCache) do(ctx context.Context, args ...string) (interface{}, error) {
+	c, err := rc.getConn()
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	deadline := time.Now().Add(rc.timeout())
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	err = c.conn.SetDeadline(deadline)
+	if err != nil {
+		c.conn.Close()
+		return nil, errors.Wrap(err, "")
+	}
+
+	reply, err := c.command(args...)
+	if err != nil {
+		if _, ok := err.(redisError); ok {
+			rc.putConn(c)
+		} else {
+			c.conn.Close()
+		}
+		return nil, errors.Wrap(err, "")
+	}
+
+	rc.putConn(c)
+	return reply, nil
+}
+
+func (rc *RedisCache) getConn() (*redisConn, error) {
+	rc.mu.Lock()
+	if len(rc.idle) > 0 {
+		c := rc.idle[len(rc.idle)-1]
+		rc.idle = rc.idle[:len(rc.idle)-1]
+		rc.mu.Unlock()
+		return c, nil
+	}
+	rc.mu.Unlock()
+
+	conn, err := net.DialTimeout("tcp", rc.Addr, rc.timeout())
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	c := &redisConn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+
+	err = conn.SetDeadline(time.Now().Add(rc.timeout()))
+	if err == nil && rc.Password != "" {
+		_, err = c.command("AUTH", rc.Password)
+	}
+	if err == nil && rc.DB != 0 {
+		_, err = c.command("SELECT", strconv.Itoa(rc.DB))
+	}
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "")
+	}
+	return c, nil
+}
+
+func (rc *RedisCache) putConn(c *redisConn) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if len(rc.idle) >= maxIdleRedisConns {
+		c.conn.Close()
+		return
+	}
+	rc.idle = append(rc.idle, c)
+}
+
+func (rc *RedisCache) timeout() time.Duration {
+	if rc.Timeout > 0 {
+		return rc.Timeout
+	}
+	return defaultRedisTimeout
+}
+
+func (c *redisConn) command(args ...string) (interface{}, error) {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	_, err := c.conn.Write(buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return readRedisReply(c.reader)
+}
+
+// readRedisReply reads a single reply. Bulk strings are returned as []byte, with nil for a missing value;
+// simple strings as string, integers as int64, and arrays as []interface{}.
+func readRedisReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.Errorf("malformed reply line %q", line)
+	}
+	prefix, line := line[0], line[1:len(line)-2]
+
+	switch prefix {
+	case '+':
+		return line, nil
+	case '-':
+		return nil, redisError(line)
+	case ':':
+		n, err := strconv.ParseInt(line, 10, 64)
+		return n, errors.Wrap(err, "")
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		value := make([]byte, n+2)
+		_, err = io.ReadFull(r, value)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		return value[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			values[i], err = readRedisReply(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	default:
+		return nil, errors.Errorf("unknown reply type %q", prefix)
+	}
+}
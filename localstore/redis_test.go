@@ -0,0 +1,152 @@
+package localstore
+
+import (
+	"bufio"
+	"context"
+	"github.com/pkg/errors"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// newTestRedisServer starts a server speaking enough of the Redis protocol to test against,
+// requiring the given password if set.
+func newTestRedisServer(t *testing.T, password string) (addr string, closeFunc func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	values := make(map[string]string)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				authed := password == ""
+				for {
+					reply, err := readRedisReply(r)
+					if err != nil {
+						return
+					}
+					var args []string
+					for _, arg := range reply.([]interface{}) {
+						args = append(args, string(arg.([]byte)))
+					}
+
+					mu.Lock()
+					var response string
+					switch {
+					case args[0] == "AUTH":
+						authed = args[1] == password
+						response = "+OK\r\n"
+						if !authed {
+							response = "-ERR invalid password\r\n"
+						}
+					case !authed:
+						response = "-NOAUTH Authentication required.\r\n"
+					case args[0] == "GET":
+						if v, ok := values[args[1]]; ok {
+							response = "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+						} else {
+							response = "$-1\r\n"
+						}
+					case args[0] == "SET":
+						values[args[1]] = args[2]
+						response = "+OK\r\n"
+					case args[0] == "DEL":
+						delete(values, args[1])
+						response = ":1\r\n"
+					case args[0] == "FLUSHDB":
+						values = make(map[string]string)
+						response = "+OK\r\n"
+					default:
+						response = "-ERR unknown command\r\n"
+					}
+					mu.Unlock()
+
+					if _, err := conn.Write([]byte(response)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), func() { listener.Close() }
+}
+
+func TestRedisCache(t *testing.T) {
+	t.Parallel()
+
+	addr, closeServer := newTestRedisServer(t, "hunter2")
+	defer closeServer()
+
+	rc := &RedisCache{Addr: addr, Password: "hunter2"}
+	c := context.Background()
+
+	_, err := rc.Get(c, "a")
+	if errors.Cause(err) != ErrCacheMiss {
+		t.Errorf("Expected cache miss, got %v", err)
+	}
+
+	value := "binary\r\n\x00value"
+	if err := rc.Set(c, "a", []byte(value)); err != nil {
+		t.Fatalf("Expected no error setting, got %s", err)
+	}
+	got, err := rc.Get(c, "a")
+	if err != nil || string(got) != value {
+		t.Errorf("Expected %q, got %q, %v", value, got, err)
+	}
+
+	if err := rc.Delete(c, "a"); err != nil {
+		t.Fatalf("Expected no error deleting, got %s", err)
+	}
+	if _, err := rc.Get(c, "a"); errors.Cause(err) != ErrCacheMiss {
+		t.Errorf("Expected cache miss after delete, got %v", err)
+	}
+
+	if err := rc.Set(c, "b", []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Flush(c); err != nil {
+		t.Fatalf("Expected no error flushing, got %s", err)
+	}
+	if _, err := rc.Get(c, "b"); errors.Cause(err) != ErrCacheMiss {
+		t.Errorf("Expected cache miss after flush, got %v", err)
+	}
+}
+
+func TestRedisCache_WrongPassword(t *testing.T) {
+	t.Parallel()
+
+	addr, closeServer := newTestRedisServer(t, "hunter2")
+	defer closeServer()
+
+	rc := &RedisCache{Addr: addr, Password: "bluh"}
+	if _, err := rc.Get(context.Background(), "a"); err == nil {
+		t.Error("Expected error with the wrong password, got nil")
+	}
+}
+
+func TestCacheStore_Redis(t *testing.T) {
+	t.Parallel()
+
+	addr, closeServer := newTestRedisServer(t, "")
+	defer closeServer()
+
+	cs := &CacheStore{Backend: &RedisCache{Addr: addr}, Prefix: "~"}
+	if err := cs.Set(context.Background(), "p", 0.25); err != nil {
+		t.Fatalf("Expected no error setting, got %s", err)
+	}
+	var p float64
+	if err := cs.Get(context.Background(), "p", &p); err != nil || p != 0.25 {
+		t.Errorf("Expected 0.25, got %f, %v", p, err)
+	}
+}
@@ -1,101 +1,33 @@
 package main
 
 import (
-	"github.com/jbeshir/moonbird-auth-frontend/aengine"
-	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
-	"github.com/jbeshir/moonbird-predictor-frontend/mlclient"
-	"github.com/jbeshir/moonbird-predictor-frontend/pbook"
-	"github.com/jbeshir/moonbird-predictor-frontend/responders"
-	"github.com/jbeshir/predictionbook-extractor/htmlfetcher"
-	"github.com/jbeshir/predictionbook-extractor/predictions"
-	"golang.org/x/time/rate"
-	"google.golang.org/api/ml/v1"
-	"google.golang.org/api/storage/v1"
-	"google.golang.org/appengine"
-	"google.golang.org/appengine/memcache"
+	"context"
+	"github.com/jbeshir/moonbird-predictor-frontend/wiring"
+	"log"
 	"net/http"
 	"os"
 	"time"
 )
 
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	cfg, err := wiring.LoadConfig(os.Getenv("MOONBIRD_CONFIG"))
+	if err != nil {
+		log.Fatalf("%+v", err)
 	}
 
-	contextMaker := &aengine.ContextMaker{
-		Namespace: "moonbird-predictor-frontend",
-	}
-
-	pbSource := predictions.NewSource(
-		htmlfetcher.NewFetcher(rate.NewLimiter(1, 2), 2),
-		"https://predictionbook.com")
-	exampleLister := &pbook.Lister{
-		PredictionSource: pbSource,
-		CacheStore: &aengine.CacheStore{
-			Prefix: "pbook-",
-			Codec:  memcache.Gob,
-		},
-		PersistentStore: &aengine.PersistentStore{
-			Prefix: "pbook-",
-		},
-	}
-
-	predictionCacheStore := &aengine.CacheStore{
-		Prefix: "~",
-		Codec:  aengine.BinaryMemcacheCodec,
-	}
-	predictionMaker := &mlclient.PredictionMaker{
-		CacheStorage: predictionCacheStore,
-		HttpClientMaker: &aengine.AuthenticatedClientMaker{
-			Scope: []string{
-				ml.CloudPlatformScope,
-			},
-		},
-	}
+	p := wiring.NewPlatform(cfg)
+	c := wiring.NewComponents(p)
+	c.RegisterHandlers(http.DefaultServeMux)
 
-	indexController := &controllers.Index{
-		ExampleLister:   exampleLister,
-		PredictionMaker: predictionMaker,
-	}
-	indexResponder := &responders.WebIndexResponder{}
-	http.Handle("/", indexController.HandleFunc(contextMaker, indexResponder))
-
-	cronResponder := &responders.WebSimpleResponder{
-		ExposeErrors: true,
-	}
+	p.Serve()
 
-	pbUpdateController := &controllers.ExamplesUpdate{
-		ExampleLister: exampleLister,
+	// Serving only returns once stopped, in standalone mode; write what's still buffered before exiting.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.ShadowLog.Flush(ctx); err != nil {
+		log.Printf("Unable to record remaining shadow comparisons: %s", err)
 	}
-	http.Handle("/cron/pb-update", pbUpdateController.HandleFunc(contextMaker, cronResponder))
-
-	modelTrainer := &mlclient.Trainer{
-		PersistentStore: &aengine.PersistentStore{
-			Prefix: "model-",
-		},
-		FileStore: &aengine.GcsFileStore{
-			Bucket: "moonbird-data",
-			Prefix: "predictor/",
-		},
-		PredictionSource: pbSource,
-		ModelPath:        "moonbird-models/predictor",
-		DataPath:         "moonbird-data/predictor",
-		SleepFunc:        time.Sleep,
-		TrainPackage:     "gs://moonbird-models/predictor/trainer.tar.gz",
-		HttpClientMaker: &aengine.AuthenticatedClientMaker{
-			Scope: []string{
-				ml.CloudPlatformScope,
-				storage.CloudPlatformScope,
-			},
-		},
-	}
-	mlRetrainController := &controllers.ModelRetrain{
-		Trainer:         modelTrainer,
-		PredictionCache: predictionCacheStore,
+	if err := c.Tracer.Shutdown(ctx); err != nil {
+		log.Printf("Unable to export remaining spans: %s", err)
 	}
-	http.Handle("/cron/ml-retrain", mlRetrainController.HandleFunc(contextMaker, cronResponder))
-
-	appengine.Main()
 }
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	requests := r.NewCounter("test_requests_total", "Requests handled.", "path", "code")
+	examples := r.NewGauge("test_examples", "Examples last seen.")
+	latency := r.NewHistogram("test_latency_seconds", "Request latency.\nIn seconds.", []float64{0.1, 1}, "path")
+
+	requests.Inc("/b", "200")
+	requests.Inc("/a", "500")
+	requests.Add(2, "/a", "500")
+	requests.Inc("/a\"\n", "200")
+	examples.Set(20)
+	examples.Set(12)
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.5, "/a")
+	latency.Observe(3, "/a")
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+
+	want := `# HELP test_requests_total Requests handled.
+# TYPE test_requests_total counter
+test_requests_total{path="/a",code="500"} 3
+test_requests_total{path="/a\"\n",code="200"} 1
+test_requests_total{path="/b",code="200"} 1
+# HELP test_examples Examples last seen.
+# TYPE test_examples gauge
+test_examples 12
+# HELP test_latency_seconds Request latency.\nIn seconds.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{path="/a",le="0.1"} 1
+test_latency_seconds_bucket{path="/a",le="1"} 2
+test_latency_seconds_bucket{path="/a",le="+Inf"} 3
+test_latency_seconds_sum{path="/a"} 3.55
+test_latency_seconds_count{path="/a"} 3
+`
+	if buf.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+func TestRegistry_WriteText_Empty(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.NewCounter("test_total", "Things.", "kind")
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+
+	want := "# HELP test_total Things.\n# TYPE test_total counter\n"
+	if buf.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+func TestCounter_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	c := r.NewCounter("test_total", "Things.")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Inc()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var buf bytes.Buffer
+	_ = r.WriteText(&buf)
+	want := "# HELP test_total Things.\n# TYPE test_total counter\ntest_total 2000\n"
+	if buf.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+func TestRegistry_RegisterTwice(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a name twice to panic, did not panic")
+		}
+	}()
+
+	r := NewRegistry()
+	r.NewCounter("test_total", "Things.")
+	r.NewGauge("test_total", "Things.")
+}
+
+func TestCounter_WrongLabelCount(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected the wrong number of label values to panic, did not panic")
+		}
+	}()
+
+	r := NewRegistry()
+	c := r.NewCounter("test_total", "Things.", "kind")
+	c.Inc()
+}
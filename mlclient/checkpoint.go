@@ -0,0 +1,213 @@
+package mlclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"github.com/pkg/errors"
+)
+
+const retrainCheckpointKind = "TrainerCheckpoint"
+const retrainCheckpointKey = "checkpoint"
+const retrainCheckpointChunkKind = "TrainerCheckpointChunk"
+
+// checkpointChunkSize bounds the retrieved data kept in each chunk entity, keeping it well within
+// the datastore's entity size limit once encoded.
+const checkpointChunkSize = 512 << 10
+
+// Stages of a retrain, in the order they run. A checkpoint records how many have completed.
+const (
+	stageRetrievePredictions = iota
+	stageRetrieveResponses
+	stageWriteData
+	stageTrain
+	stageCreateVersion
+	stagePromote
+)
+
+var ErrRetrainInterrupted = errors.New("retrain interrupted by shutdown")
+var ErrRetrainOptionsConflict = errors.New("retrain options conflict with the checkpointed retrain")
+
+// retrainCheckpoint holds the progress of a retrain, so one interrupted by shutdown can be resumed.
+// A Model of zero means there is no retrain to resume.
+type retrainCheckpoint struct {
+	Model       int64
+	BaseModel   int64
+	LatestModel int64
+	Options     data2.RetrainOptions
+	Completed   int
+	Report      data2.TrainingRunReport
+
+	// Results of the retrieval stages, needed until the training data is written.
+	// These can exceed what one entity may hold, so are saved split across DataChunks chunk entities.
+	PotentiallyResolved []*predictions.PredictionSummary  `json:"-"`
+	Resolved            []*predictions.PredictionSummary  `json:"-"`
+	Unresolved          []*predictions.PredictionSummary  `json:"-"`
+	UnresolvedRecords   [][]string                        `json:"-"`
+	Responses           []*predictions.PredictionResponse `json:"-"`
+	DataChunks          int
+
+	// Whether the train and create-version stages had made their requests, and only need to wait.
+	JobCreated     bool
+	VersionCreated bool
+}
+
+// checkpointing reports whether retrains may be interrupted, and so need checkpoints.
+func (tr *Trainer) checkpointing() bool {
+	return tr.Stopping != nil
+}
+
+func (tr *Trainer) stopping() bool {
+	select {
+	case <-tr.Stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// completeStage records that the checkpoint's current stage is done,
+// and returns ErrRetrainInterrupted if we should stop before the next.
+func (tr *Trainer) completeStage(cp *retrainCheckpoint) error {
+	cp.Completed++
+	if tr.stopping() {
+		return errors.WithStack(ErrRetrainInterrupted)
+	}
+	return nil
+}
+
+// checkpointData is the retrieved data of a checkpoint, saved in chunks apart from it.
+type checkpointData struct {
+	PotentiallyResolved []*predictions.PredictionSummary
+	Resolved            []*predictions.PredictionSummary
+	Unresolved          []*predictions.PredictionSummary
+	UnresolvedRecords   [][]string
+	Responses           []*predictions.PredictionResponse
+}
+
+type checkpointChunk struct {
+	Content []byte
+}
+
+// resumeConflicts lists the ways opts asks for something other than what the checkpointed retrain does.
+// Options left at their defaults never conflict, so a scheduled retrain can resume any checkpoint
+// made with the same setting for training on internal questions.
+func (cp *retrainCheckpoint) resumeConflicts(opts data2.RetrainOptions) (conflicts []string) {
+	if opts.Cutoff.Unix() < cp.Model {
+		conflicts = append(conflicts, fmt.Sprintf("cutoff %d is before the checkpointed cutoff %d", opts.Cutoff.Unix(), cp.Model))
+	}
+	if opts.BaseModel != 0 && opts.BaseModel != cp.BaseModel {
+		conflicts = append(conflicts, fmt.Sprintf("base model %d is not the checkpointed base model %d", opts.BaseModel, cp.BaseModel))
+	}
+	if opts.FullRebuild && cp.BaseModel != 0 {
+		conflicts = append(conflicts, fmt.Sprintf("full rebuild requested, but the checkpointed retrain trains from model %d", cp.BaseModel))
+	}
+	if opts.SkipPromotion && !cp.Options.SkipPromotion {
+		conflicts = append(conflicts, "promotion skip requested, but the checkpointed retrain promotes its model")
+	}
+	if opts.TrainOnInternalQuestions != cp.Options.TrainOnInternalQuestions {
+		conflicts = append(conflicts, fmt.Sprintf("training on internal questions is %t, but was %t for the checkpointed retrain",
+			opts.TrainOnInternalQuestions, cp.Options.TrainOnInternalQuestions))
+	}
+	return conflicts
+}
+
+func (tr *Trainer) getCheckpoint(ctx context.Context) (*retrainCheckpoint, error) {
+	cp := new(retrainCheckpoint)
+	_, err := tr.PersistentStore.Get(ctx, retrainCheckpointKind, retrainCheckpointKey, cp)
+	if err != nil && errors.Cause(err) != data.ErrNoSuchEntity {
+		return nil, errors.Wrap(err, "")
+	}
+	if cp.DataChunks == 0 {
+		return cp, nil
+	}
+
+	var content []byte
+	for i := 0; i < cp.DataChunks; i++ {
+		chunk := new(checkpointChunk)
+		_, err = tr.PersistentStore.Get(ctx, retrainCheckpointChunkKind, checkpointChunkKey(i), chunk)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		content = append(content, chunk.Content...)
+	}
+
+	cpData := new(checkpointData)
+	if err = json.Unmarshal(content, cpData); err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	cp.PotentiallyResolved, cp.Resolved, cp.Unresolved = cpData.PotentiallyResolved, cpData.Resolved, cpData.Unresolved
+	cp.UnresolvedRecords, cp.Responses = cpData.UnresolvedRecords, cpData.Responses
+	return cp, nil
+}
+
+// saveCheckpoint records the checkpoint, or clears it if nil, and deletes data chunks it doesn't use.
+// Chunks are reused between checkpoints, so before overwriting the stored checkpoint's, we clear it;
+// a failure partway then loses only the ability to resume, never mixes two checkpoints' data.
+func (tr *Trainer) saveCheckpoint(ctx context.Context, cp *retrainCheckpoint) error {
+	if cp == nil {
+		cp = new(retrainCheckpoint)
+	}
+
+	stored := new(retrainCheckpoint)
+	_, err := tr.PersistentStore.Get(ctx, retrainCheckpointKind, retrainCheckpointKey, stored)
+	if err != nil && errors.Cause(err) != data.ErrNoSuchEntity {
+		return errors.Wrap(err, "")
+	}
+
+	var chunks [][]byte
+	if cp.PotentiallyResolved != nil || cp.Resolved != nil || cp.Unresolved != nil || cp.UnresolvedRecords != nil || cp.Responses != nil {
+		content, err := json.Marshal(&checkpointData{
+			PotentiallyResolved: cp.PotentiallyResolved,
+			Resolved:            cp.Resolved,
+			Unresolved:          cp.Unresolved,
+			UnresolvedRecords:   cp.UnresolvedRecords,
+			Responses:           cp.Responses,
+		})
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+		for len(content) > 0 {
+			size := checkpointChunkSize
+			if size > len(content) {
+				size = len(content)
+			}
+			chunks = append(chunks, content[:size])
+			content = content[size:]
+		}
+	}
+
+	if len(chunks) > 0 && stored.DataChunks > 0 {
+		err = tr.PersistentStore.Set(ctx, retrainCheckpointKind, retrainCheckpointKey, nil, new(retrainCheckpoint))
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+	}
+	for i, chunk := range chunks {
+		err = tr.PersistentStore.Set(ctx, retrainCheckpointChunkKind, checkpointChunkKey(i), nil, &checkpointChunk{Content: chunk})
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+	}
+
+	cp.DataChunks = len(chunks)
+	err = tr.PersistentStore.Set(ctx, retrainCheckpointKind, retrainCheckpointKey, nil, cp)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	for i := len(chunks); i < stored.DataChunks; i++ {
+		err = tr.PersistentStore.Delete(ctx, retrainCheckpointChunkKind, checkpointChunkKey(i))
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+	}
+	return nil
+}
+
+func checkpointChunkKey(i int) string {
+	return fmt.Sprintf("%s-%d", retrainCheckpointKey, i)
+}
@@ -0,0 +1,467 @@
+package mlclient
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	testhelpers2 "github.com/jbeshir/moonbird-predictor-frontend/testhelpers"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testJsonStore keeps entities in memory as JSON, as a real store would serialize them.
+type testJsonStore struct {
+	*testTrainerStore
+
+	mutex    sync.Mutex
+	entities map[string][]byte
+}
+
+func newTestJsonStore(t *testing.T) *testJsonStore {
+	ps := &testJsonStore{
+		testTrainerStore: newTestTrainerStore(t),
+		entities:         make(map[string][]byte),
+	}
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		ps.mutex.Lock()
+		defer ps.mutex.Unlock()
+
+		content, ok := ps.entities[kind+"/"+key]
+		if !ok {
+			return nil, data.ErrNoSuchEntity
+		}
+		return nil, json.Unmarshal(content, v)
+	}
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		ps.mutex.Lock()
+		defer ps.mutex.Unlock()
+
+		content, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		ps.entities[kind+"/"+key] = content
+		return nil
+	}
+	ps.DeleteFunc = func(ctx context.Context, kind, key string) error {
+		ps.mutex.Lock()
+		defer ps.mutex.Unlock()
+
+		delete(ps.entities, kind+"/"+key)
+		return nil
+	}
+	ps.TransactFunc = func(ctx context.Context, f func(ctx context.Context) error) error {
+		return f(ctx)
+	}
+	return ps
+}
+
+// count returns how many entities of the kind are stored.
+func (ps *testJsonStore) count(kind string) int {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	n := 0
+	for k := range ps.entities {
+		if strings.HasPrefix(k, kind+"/") {
+			n++
+		}
+	}
+	return n
+}
+
+func TestTrainer_RetrainWithOptions_Checkpoint(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestJsonStore(t)
+	_ = ps.Set(context.Background(), "TrainerStatus", "status", nil, &trainerStatus{LatestModel: 123})
+
+	var savedPaths []string
+	fs := newTestFileStore(t)
+	fs.LoadFunc = func(ctx context.Context, path string) ([]byte, error) {
+		return nil, nil
+	}
+	fs.SaveFunc = func(ctx context.Context, path string, content []byte) error {
+		savedPaths = append(savedPaths, path)
+		return nil
+	}
+
+	var stop func()
+	var jobState string
+	var requestPaths []string
+	client := new(http.Client)
+	client.Transport = &testRoundTripper{
+		RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+			requestPaths = append(requestPaths, req.Method+" "+req.URL.Path)
+
+			body := `{}`
+			if req.Method == "GET" && strings.Contains(req.URL.Path, "/jobs/") {
+				body = `{"State":"` + jobState + `"}`
+				if jobState == "RUNNING" {
+					stop()
+				}
+			} else if req.Method == "GET" {
+				body = `{"State":"READY"}`
+			}
+
+			resp := new(http.Response)
+			resp.StatusCode = 200
+			resp.ContentLength = -1
+			resp.Body = ioutil.NopCloser(strings.NewReader(body))
+			return resp, nil
+		},
+	}
+	cm := newTestHttpClientMaker(t)
+	cm.MakeClientFunc = func(ctx context.Context) (*http.Client, error) {
+		return client, nil
+	}
+
+	newTrainer := func(s *testhelpers2.PredictionSource) *Trainer {
+		stopping := make(chan struct{})
+		stop = func() { close(stopping) }
+		return &Trainer{
+			PersistentStore:  ps,
+			FileStore:        fs,
+			PredictionSource: s,
+			HttpClientMaker:  cm,
+			SleepFunc:        func(time.Duration) {},
+			Stopping:         stopping,
+		}
+	}
+
+	// Shutting down while retrieving responses stops the retrain after that stage.
+	s := testhelpers2.NewPredictionSource(t)
+	s.AllPredictionsSinceFunc = func(ctx context.Context, since time.Time) ([]*predictions.PredictionSummary, error) {
+		return []*predictions.PredictionSummary{{Id: 1, Outcome: predictions.Right}}, nil
+	}
+	s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+		stop()
+		return summaries, []*predictions.PredictionResponse{{Prediction: 1, Confidence: 0.5, User: "alice"}}, nil
+	}
+	err := newTrainer(s).RetrainWithOptions(context.Background(), data2.RetrainOptions{
+		Cutoff: time.Unix(500, 0),
+	})
+	if errors.Cause(err) != ErrRetrainInterrupted {
+		t.Fatalf("Expected ErrRetrainInterrupted, got %v", err)
+	}
+	if len(savedPaths) != 0 || len(requestPaths) != 0 {
+		t.Errorf("Expected no training data or ML requests before interruption, got %v and %v", savedPaths, requestPaths)
+	}
+
+	cp, _ := (&Trainer{PersistentStore: ps}).getCheckpoint(context.Background())
+	if cp.Model != 500 || cp.Completed != stageWriteData || len(cp.Resolved) != 1 || len(cp.Responses) != 1 {
+		t.Errorf("Expected checkpoint of model 500 before writing data, with retrieved results, got %+v", cp)
+	}
+	if chunks := ps.count(retrainCheckpointChunkKind); chunks != 1 {
+		t.Errorf("Expected retrieved results to be stored in one chunk, got %d", chunks)
+	}
+
+	// Resuming, even with a later cutoff, continues the checkpointed retrain without retrieving again;
+	// shutting down while waiting for the training job stops it again.
+	jobState = "RUNNING"
+	err = newTrainer(testhelpers2.NewPredictionSource(t)).RetrainWithOptions(context.Background(), data2.RetrainOptions{
+		Cutoff: time.Unix(900, 0),
+	})
+	if errors.Cause(err) != ErrRetrainInterrupted {
+		t.Fatalf("Expected ErrRetrainInterrupted, got %v", err)
+	}
+	if len(savedPaths) != 5 || !strings.HasPrefix(savedPaths[0], "500/") {
+		t.Errorf("Expected training data for model 500 to be written, got %v", savedPaths)
+	}
+
+	cp, _ = (&Trainer{PersistentStore: ps}).getCheckpoint(context.Background())
+	if cp.Completed != stageTrain || !cp.JobCreated || cp.Resolved != nil {
+		t.Errorf("Expected checkpoint waiting for created training job, got %+v", cp)
+	}
+
+	// The final resume waits for the existing job rather than launching another, and promotes the model.
+	jobState = "SUCCEEDED"
+	requestPaths = nil
+	tr := newTrainer(testhelpers2.NewPredictionSource(t))
+	err = tr.RetrainWithOptions(context.Background(), data2.RetrainOptions{
+		Cutoff: time.Unix(900, 0),
+	})
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	for _, p := range requestPaths {
+		if p == "POST /v1/projects/moonbird-beshir/jobs" {
+			t.Error("Expected resumed retrain not to launch a second training job")
+		}
+	}
+	if version, _ := tr.CurrentModelVersion(context.Background()); version != "v500" {
+		t.Errorf("Expected model 500 to be promoted, current version was %s", version)
+	}
+
+	cp, _ = (&Trainer{PersistentStore: ps}).getCheckpoint(context.Background())
+	if cp.Model != 0 {
+		t.Errorf("Expected checkpoint to be cleared after finishing, got %+v", cp)
+	}
+	if chunks := ps.count(retrainCheckpointChunkKind); chunks != 0 {
+		t.Errorf("Expected no checkpoint data chunks to be left after finishing, got %d", chunks)
+	}
+
+	runs, _ := tr.RecentRuns(context.Background())
+	if len(runs) != 1 || runs[0].Model != 500 || runs[0].Outcome != data2.TrainingRunSucceeded {
+		t.Errorf("Expected a single succeeded run report for model 500, got %+v", runs)
+	}
+	var stages []string
+	for _, stage := range runs[0].Stages {
+		stages = append(stages, stage.Name)
+	}
+	wantStages := []string{"retrieve-predictions", "retrieve-responses", "write-data", "train", "train", "create-version", "promote"}
+	if !reflect.DeepEqual(stages, wantStages) {
+		t.Errorf("Expected stages %v, got %v", wantStages, stages)
+	}
+}
+
+func TestTrainer_SaveCheckpoint_Chunks(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestJsonStore(t)
+	storeSet := ps.SetFunc
+	var chunkSizes []int
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		if kind == retrainCheckpointChunkKind {
+			content, _ := json.Marshal(v)
+			chunkSizes = append(chunkSizes, len(content))
+		}
+		return storeSet(ctx, kind, key, properties, v)
+	}
+	tr := &Trainer{PersistentStore: ps}
+
+	// Quotes double in size when encoded, the worst case for each chunk's entity.
+	record := []string{strings.Repeat(`"`, 3*checkpointChunkSize)}
+	saved := &retrainCheckpoint{
+		Model:             500,
+		Completed:         stageWriteData,
+		Resolved:          []*predictions.PredictionSummary{{Id: 1, Outcome: predictions.Right}},
+		UnresolvedRecords: [][]string{record},
+		Responses:         []*predictions.PredictionResponse{{Prediction: 1, Confidence: 0.5, User: "alice"}},
+	}
+	if err := tr.saveCheckpoint(context.Background(), saved); err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+
+	if len(chunkSizes) < 2 {
+		t.Errorf("Expected data to be split across chunks, got %d", len(chunkSizes))
+	}
+	for _, size := range chunkSizes {
+		if size > 900<<10 {
+			t.Errorf("Expected each chunk to be well within the entity size limit, got one of %d bytes", size)
+		}
+	}
+
+	cp, err := tr.getCheckpoint(context.Background())
+	if err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+	if cp.Model != 500 || len(cp.Resolved) != 1 || len(cp.Responses) != 1 || !reflect.DeepEqual(cp.UnresolvedRecords, [][]string{record}) {
+		t.Errorf("Expected checkpoint to be restored with its data, got model %d with %d resolved and %d responses",
+			cp.Model, len(cp.Resolved), len(cp.Responses))
+	}
+
+	// Saving less data reuses the first chunks, and deletes the rest.
+	saved.Completed++
+	saved.UnresolvedRecords = nil
+	if err := tr.saveCheckpoint(context.Background(), saved); err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+	if chunks := ps.count(retrainCheckpointChunkKind); chunks != 1 {
+		t.Errorf("Expected a single chunk to be kept, got %d", chunks)
+	}
+	cp, err = tr.getCheckpoint(context.Background())
+	if err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+	if len(cp.Resolved) != 1 || cp.UnresolvedRecords != nil {
+		t.Errorf("Expected checkpoint with only the remaining data, got %+v", cp)
+	}
+
+	// Clearing leaves no data to restore, and no chunks stored.
+	if err := tr.saveCheckpoint(context.Background(), nil); err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+	cp, err = tr.getCheckpoint(context.Background())
+	if err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+	if cp.Model != 0 || cp.UnresolvedRecords != nil {
+		t.Errorf("Expected cleared checkpoint, got %+v", cp)
+	}
+	if chunks := ps.count(retrainCheckpointChunkKind); chunks != 0 {
+		t.Errorf("Expected no chunks to be left once cleared, got %d", chunks)
+	}
+}
+
+func TestTrainer_SaveCheckpoint_Error(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestTrainerStore(t)
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		return nil, data.ErrNoSuchEntity
+	}
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		return errors.New("datastore unavailable")
+	}
+	tr := &Trainer{PersistentStore: ps}
+
+	err := tr.saveCheckpoint(context.Background(), &retrainCheckpoint{
+		Model:    500,
+		Resolved: []*predictions.PredictionSummary{{Id: 1}},
+	})
+	if err == nil {
+		t.Error("Expected checkpoint save failure to be returned")
+	}
+}
+
+func TestTrainer_SaveCheckpoint_FailurePartway(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestJsonStore(t)
+	tr := &Trainer{PersistentStore: ps}
+	_ = tr.saveCheckpoint(context.Background(), &retrainCheckpoint{
+		Model:     500,
+		Completed: stageRetrieveResponses,
+		Resolved:  []*predictions.PredictionSummary{{Id: 1}},
+	})
+
+	// Failing to write the new data leaves no checkpoint, rather than the old one with the new data.
+	storeSet := ps.SetFunc
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		if kind == retrainCheckpointChunkKind {
+			return errors.New("datastore unavailable")
+		}
+		return storeSet(ctx, kind, key, properties, v)
+	}
+	err := tr.saveCheckpoint(context.Background(), &retrainCheckpoint{
+		Model:     500,
+		Completed: stageWriteData,
+		Resolved:  []*predictions.PredictionSummary{{Id: 2}},
+	})
+	if err == nil {
+		t.Error("Expected checkpoint save failure to be returned")
+	}
+
+	cp, err := tr.getCheckpoint(context.Background())
+	if err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+	if cp.Model != 0 {
+		t.Errorf("Expected no checkpoint to resume, got %+v", cp)
+	}
+}
+
+func TestRetrainCheckpoint_ResumeConflicts(t *testing.T) {
+	t.Parallel()
+
+	cp := &retrainCheckpoint{Model: 500, BaseModel: 123}
+	tests := []struct {
+		name     string
+		opts     data2.RetrainOptions
+		conflict bool
+	}{
+		{"defaults", data2.RetrainOptions{Cutoff: time.Unix(900, 0), AttachToRunning: true}, false},
+		{"same options", data2.RetrainOptions{Cutoff: time.Unix(500, 0), BaseModel: 123}, false},
+		{"earlier cutoff", data2.RetrainOptions{Cutoff: time.Unix(400, 0)}, true},
+		{"other base model", data2.RetrainOptions{Cutoff: time.Unix(900, 0), BaseModel: 100}, true},
+		{"full rebuild", data2.RetrainOptions{Cutoff: time.Unix(900, 0), FullRebuild: true}, true},
+		{"skip promotion", data2.RetrainOptions{Cutoff: time.Unix(900, 0), SkipPromotion: true}, true},
+		{"internal questions", data2.RetrainOptions{Cutoff: time.Unix(900, 0), TrainOnInternalQuestions: true}, true},
+	}
+
+	for _, test := range tests {
+		conflicts := cp.resumeConflicts(test.opts)
+		if test.conflict && len(conflicts) == 0 {
+			t.Errorf("%s: expected a conflict", test.name)
+		}
+		if !test.conflict && len(conflicts) != 0 {
+			t.Errorf("%s: expected no conflicts, got %v", test.name, conflicts)
+		}
+	}
+}
+
+func TestTrainer_RetrainWithOptions_ResumeConflict(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestJsonStore(t)
+	cm := newTestHttpClientMaker(t)
+	cm.MakeClientFunc = func(ctx context.Context) (*http.Client, error) {
+		return new(http.Client), nil
+	}
+	tr := &Trainer{
+		PersistentStore:  ps,
+		FileStore:        newTestFileStore(t),
+		PredictionSource: testhelpers2.NewPredictionSource(t),
+		HttpClientMaker:  cm,
+		Stopping:         make(chan struct{}),
+	}
+	saved := &retrainCheckpoint{
+		Model:     500,
+		BaseModel: 123,
+		Completed: stageWriteData,
+		Resolved:  []*predictions.PredictionSummary{{Id: 1, Outcome: predictions.Right}},
+	}
+	_ = tr.saveCheckpoint(context.Background(), saved)
+
+	err := tr.RetrainWithOptions(context.Background(), data2.RetrainOptions{
+		Cutoff:        time.Unix(900, 0),
+		SkipPromotion: true,
+	})
+	if errors.Cause(err) != ErrRetrainOptionsConflict {
+		t.Fatalf("Expected ErrRetrainOptionsConflict, got %v", err)
+	}
+
+	cp, _ := tr.getCheckpoint(context.Background())
+	if cp.Model != 500 || cp.Completed != stageWriteData || len(cp.Resolved) != 1 {
+		t.Errorf("Expected checkpoint to be kept for a later resume, got %+v", cp)
+	}
+	if runs, _ := tr.RecentRuns(context.Background()); len(runs) != 0 {
+		t.Errorf("Expected no run to be recorded, got %+v", runs)
+	}
+}
+
+func TestTrainer_RetrainWithOptions_ResumeInternalQuestionsConflict(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestJsonStore(t)
+	cm := newTestHttpClientMaker(t)
+	cm.MakeClientFunc = func(ctx context.Context) (*http.Client, error) {
+		return new(http.Client), nil
+	}
+	tr := &Trainer{
+		PersistentStore:  ps,
+		FileStore:        newTestFileStore(t),
+		PredictionSource: testhelpers2.NewPredictionSource(t),
+		HttpClientMaker:  cm,
+		Stopping:         make(chan struct{}),
+	}
+	_ = tr.saveCheckpoint(context.Background(), &retrainCheckpoint{
+		Model:     500,
+		BaseModel: 123,
+		Completed: stageWriteData,
+		Resolved:  []*predictions.PredictionSummary{{Id: 1, Outcome: predictions.Right}},
+	})
+
+	// The checkpointed retrain didn't train on internal questions, so can't be resumed by a trainer which does.
+	tr.TrainOnInternalQuestions = true
+	err := tr.RetrainWithOptions(context.Background(), data2.RetrainOptions{
+		Cutoff: time.Unix(900, 0),
+	})
+	if errors.Cause(err) != ErrRetrainOptionsConflict {
+		t.Fatalf("Expected ErrRetrainOptionsConflict, got %v", err)
+	}
+
+	cp, _ := tr.getCheckpoint(context.Background())
+	if cp.Model != 500 || cp.Options.TrainOnInternalQuestions {
+		t.Errorf("Expected checkpoint to be kept unchanged, got %+v", cp)
+	}
+}
@@ -0,0 +1,24 @@
+package mlclient
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"google.golang.org/api/ml/v1"
+)
+
+// CheckHealth checks that ML Engine is reachable and the model visible to us,
+// without the cost of making a prediction.
+func (pm *PredictionMaker) CheckHealth(ctx context.Context) error {
+	client, err := pm.HttpClientMaker.MakeClient(ctx)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	s, err := ml.New(client)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	_, err = s.Projects.Models.Get(pm.MLEngine.modelName()).Fields("name").Context(ctx).Do()
+	return errors.Wrap(err, "")
+}
@@ -0,0 +1,52 @@
+package mlclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPredictionMaker_CheckHealth(t *testing.T) {
+	t.Parallel()
+
+	for _, statusCode := range []int{200, 403} {
+		calledRoundTrip := false
+		client := &http.Client{
+			Transport: &testRoundTripper{
+				RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+					calledRoundTrip = true
+					wantPath := "/v1/projects/moonbird-beshir/models/Predictor"
+					if req.Method != "GET" || req.URL.Path != wantPath {
+						t.Errorf("Wrong request; expected GET %s, got %s %s", wantPath, req.Method, req.URL.Path)
+					}
+
+					resp := new(http.Response)
+					resp.StatusCode = statusCode
+					resp.ContentLength = -1
+					resp.Body = ioutil.NopCloser(strings.NewReader(`{"name":"projects/moonbird-beshir/models/Predictor"}`))
+					return resp, nil
+				},
+			},
+		}
+		cm := newTestHttpClientMaker(t)
+		cm.MakeClientFunc = func(ctx context.Context) (*http.Client, error) {
+			return client, nil
+		}
+
+		pm := &PredictionMaker{
+			HttpClientMaker: cm,
+		}
+		err := pm.CheckHealth(context.Background())
+		if statusCode == 200 && err != nil {
+			t.Errorf("Expected nil err, got %s", err)
+		}
+		if statusCode != 200 && err == nil {
+			t.Errorf("Expected an error for status %d, got nil", statusCode)
+		}
+		if !calledRoundTrip {
+			t.Error("Expected the model to be requested, was not requested")
+		}
+	}
+}
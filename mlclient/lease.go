@@ -0,0 +1,221 @@
+package mlclient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const trainerLeaseKind = "TrainerLease"
+const trainerLeaseKey = "lease"
+
+const defaultLeaseDuration = 10 * time.Minute
+
+// How often a run waiting to attach to another in-progress run checks whether it has finished.
+const leasePollInterval = 30 * time.Second
+
+var ErrRetrainInProgress = errors.New("retrain already in progress")
+
+var errLeaseLost = errors.New("retrain lease lost to another run")
+
+type trainerLease struct {
+	Owner     string
+	Model     int64
+	Acquired  time.Time
+	Heartbeat time.Time
+	Expires   time.Time
+	Released  bool
+}
+
+func (l *trainerLease) heldAt(t time.Time) bool {
+	return l.Owner != "" && !l.Released && t.Before(l.Expires)
+}
+
+// heldLease is a lease owned by this process, kept alive by a heartbeat until released.
+type heldLease struct {
+	tr    *Trainer
+	owner string
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// acquireLease takes the retrain lease for the given model, failing with ErrRetrainInProgress
+// if another run holds it. The returned context is cancelled if the lease is lost.
+func (tr *Trainer) acquireLease(ctx context.Context, model int64) (*heldLease, context.Context, error) {
+	ownerSuffix := make([]byte, 8)
+	if _, err := rand.Read(ownerSuffix); err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+	owner := strconv.FormatInt(model, 10) + "-" + hex.EncodeToString(ownerSuffix)
+
+	err := tr.PersistentStore.Transact(ctx, func(ctx context.Context) error {
+		existing, err := tr.getLease(ctx)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+
+		now := tr.now()
+		if existing.heldAt(now) {
+			return errors.Wrapf(ErrRetrainInProgress, "model %d, owner %s, expires %s",
+				existing.Model, existing.Owner, existing.Expires.UTC().Format(time.RFC3339))
+		}
+
+		lease := &trainerLease{
+			Owner:     owner,
+			Model:     model,
+			Acquired:  now,
+			Heartbeat: now,
+			Expires:   now.Add(tr.leaseDuration()),
+		}
+		return errors.Wrap(tr.PersistentStore.Set(ctx, trainerLeaseKind, trainerLeaseKey, nil, lease), "")
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	held := &heldLease{
+		tr:    tr,
+		owner: owner,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	go held.keepAlive(ctx, cancel)
+
+	return held, ctx, nil
+}
+
+// attachToRun waits for the run holding the lease to finish, and returns the error it finished with.
+func (tr *Trainer) attachToRun(ctx context.Context) error {
+	l := ctxlogrus.Get(ctx)
+
+	var model int64
+	for {
+		lease, err := tr.getLease(ctx)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+
+		model = lease.Model
+		if !lease.heldAt(tr.now()) {
+			break
+		}
+
+		l.Infof("Waiting for in-progress retrain of model %d by %s", lease.Model, lease.Owner)
+		tr.SleepFunc(leasePollInterval)
+
+		if ctx.Err() != nil {
+			return errors.Wrap(ctx.Err(), "")
+		}
+	}
+
+	runs, err := tr.RecentRuns(ctx)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	for _, run := range runs {
+		if run.Model != model {
+			continue
+		}
+
+		switch run.Outcome {
+		case data2.TrainingRunSucceeded:
+			return nil
+		case data2.TrainingRunFailed:
+			return errors.Errorf("attached retrain of model %d failed: %s", model, run.Err)
+		default:
+			return errors.Errorf("attached retrain of model %d stopped without finishing", model)
+		}
+	}
+	return errors.Errorf("no report found for attached retrain of model %d", model)
+}
+
+func (tr *Trainer) getLease(ctx context.Context) (*trainerLease, error) {
+	lease := new(trainerLease)
+	_, err := tr.PersistentStore.Get(ctx, trainerLeaseKind, trainerLeaseKey, lease)
+	if err != nil && errors.Cause(err) != data.ErrNoSuchEntity {
+		return nil, errors.Wrap(err, "")
+	}
+	return lease, nil
+}
+
+func (tr *Trainer) leaseDuration() time.Duration {
+	if tr.LeaseDuration > 0 {
+		return tr.LeaseDuration
+	}
+	return defaultLeaseDuration
+}
+
+func (hl *heldLease) keepAlive(ctx context.Context, cancel context.CancelFunc) {
+	defer close(hl.done)
+	defer cancel()
+
+	ticker := time.NewTicker(hl.tr.leaseDuration() / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hl.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := hl.heartbeat(ctx); err != nil {
+				ctxlogrus.Get(ctx).Errorf("Unable to renew retrain lease: %s", err)
+				if errors.Cause(err) == errLeaseLost {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (hl *heldLease) heartbeat(ctx context.Context) error {
+	return hl.update(ctx, func(lease *trainerLease, now time.Time) {
+		lease.Heartbeat = now
+		lease.Expires = now.Add(hl.tr.leaseDuration())
+	})
+}
+
+// release stops the heartbeat and marks the lease as released, if we still hold it.
+func (hl *heldLease) release(ctx context.Context) {
+	hl.stopOnce.Do(func() {
+		close(hl.stop)
+	})
+	<-hl.done
+
+	err := hl.update(ctx, func(lease *trainerLease, now time.Time) {
+		lease.Released = true
+		lease.Expires = now
+	})
+	if err != nil {
+		ctxlogrus.Get(ctx).Warnf("Unable to release retrain lease: %s", err)
+	}
+}
+
+func (hl *heldLease) update(ctx context.Context, f func(lease *trainerLease, now time.Time)) error {
+	return hl.tr.PersistentStore.Transact(ctx, func(ctx context.Context) error {
+		lease, err := hl.tr.getLease(ctx)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+
+		now := hl.tr.now()
+		if lease.Owner != hl.owner || !lease.heldAt(now) {
+			return errors.WithStack(errLeaseLost)
+		}
+
+		f(lease, now)
+		return errors.Wrap(hl.tr.PersistentStore.Set(ctx, trainerLeaseKind, trainerLeaseKey, nil, lease), "")
+	})
+}
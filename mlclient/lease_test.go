@@ -0,0 +1,206 @@
+package mlclient
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newTestLeaseStore(t *testing.T) *testTrainerStore {
+	ps := newTestTrainerStore(t)
+	ps.TransactFunc = func(ctx context.Context, f func(ctx context.Context) error) error {
+		return f(ctx)
+	}
+	handleTestLease(ps)
+	return ps
+}
+
+func TestTrainer_AcquireLease(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	tr := &Trainer{
+		PersistentStore: newTestLeaseStore(t),
+		NowFunc: func() time.Time {
+			return now
+		},
+	}
+	ctx := context.Background()
+
+	lease, leaseCtx, err := tr.acquireLease(ctx, 500)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	if leaseCtx == nil {
+		t.Error("Expected a lease context, got nil")
+	}
+
+	_, _, err = tr.acquireLease(ctx, 600)
+	if errors.Cause(err) != ErrRetrainInProgress {
+		t.Errorf("Expected second acquisition to fail with ErrRetrainInProgress, got %v", err)
+	}
+
+	lease.release(ctx)
+	if leaseCtx.Err() == nil {
+		t.Error("Expected lease context to be cancelled after release")
+	}
+
+	second, _, err := tr.acquireLease(ctx, 600)
+	if err != nil {
+		t.Errorf("Expected acquisition after release to succeed, got %s", err)
+	} else {
+		second.release(ctx)
+	}
+}
+
+func TestTrainer_AcquireLease_Expired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	tr := &Trainer{
+		PersistentStore: newTestLeaseStore(t),
+		LeaseDuration:   time.Minute,
+		NowFunc: func() time.Time {
+			return now
+		},
+	}
+	ctx := context.Background()
+
+	first, _, err := tr.acquireLease(ctx, 500)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+
+	// Let the first lease lapse without a heartbeat.
+	now = now.Add(2 * time.Minute)
+
+	second, _, err := tr.acquireLease(ctx, 600)
+	if err != nil {
+		t.Fatalf("Expected acquisition of expired lease to succeed, got %s", err)
+	}
+
+	err = first.heartbeat(ctx)
+	if errors.Cause(err) != errLeaseLost {
+		t.Errorf("Expected heartbeat of superseded lease to fail with errLeaseLost, got %v", err)
+	}
+
+	if err := second.heartbeat(ctx); err != nil {
+		t.Errorf("Expected heartbeat of current lease to succeed, got %s", err)
+	}
+
+	first.release(ctx)
+	second.release(ctx)
+}
+
+func TestTrainer_AttachToRun(t *testing.T) {
+	t.Parallel()
+
+	for _, outcome := range []data2.TrainingRunOutcome{data2.TrainingRunSucceeded, data2.TrainingRunFailed} {
+		ps := newTestTrainerStore(t)
+		ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+			if kind != trainingRunsKind {
+				t.Errorf("Unexpected retrieval of kind %s", kind)
+			}
+			v.(*trainingRunLog).Runs = []data2.TrainingRunReport{{Model: 500, Outcome: outcome, Err: "bluh"}}
+			return nil, nil
+		}
+		ps.TransactFunc = func(ctx context.Context, f func(ctx context.Context) error) error {
+			return f(ctx)
+		}
+		handleTestLease(ps)
+
+		now := time.Unix(1000, 0)
+		owner := &Trainer{
+			PersistentStore: ps,
+			NowFunc: func() time.Time {
+				return now
+			},
+		}
+		ctx := context.Background()
+		lease, _, err := owner.acquireLease(ctx, 500)
+		if err != nil {
+			t.Fatalf("Expected err to be nil, was %s", err)
+		}
+
+		sleeps := 0
+		waiter := &Trainer{
+			PersistentStore: ps,
+			NowFunc: func() time.Time {
+				return now
+			},
+			SleepFunc: func(d time.Duration) {
+				sleeps++
+				lease.release(ctx)
+			},
+		}
+		err = waiter.attachToRun(ctx)
+
+		if sleeps != 1 {
+			t.Errorf("Expected to wait once for the run to finish, waited %d times", sleeps)
+		}
+		if outcome == data2.TrainingRunSucceeded && err != nil {
+			t.Errorf("Expected attaching to a successful run to succeed, got %s", err)
+		}
+		if outcome == data2.TrainingRunFailed && err == nil {
+			t.Error("Expected attaching to a failed run to fail, got nil")
+		}
+	}
+}
+
+func TestTrainer_RetrainWithOptions_InProgress(t *testing.T) {
+	t.Parallel()
+
+	tr := &Trainer{
+		PersistentStore: newTestLeaseStore(t),
+	}
+	ctx := context.Background()
+	lease, _, err := tr.acquireLease(ctx, 400)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	defer lease.release(ctx)
+
+	cm := newTestHttpClientMaker(t)
+	cm.MakeClientFunc = func(ctx context.Context) (*http.Client, error) {
+		return new(http.Client), nil
+	}
+	tr.HttpClientMaker = cm
+
+	err = tr.RetrainWithOptions(ctx, data2.RetrainOptions{Cutoff: time.Unix(500, 0)})
+	if errors.Cause(err) != ErrRetrainInProgress {
+		t.Errorf("Expected retrain to fail with ErrRetrainInProgress, got %v", err)
+	}
+}
+
+func TestTrainer_Retrain_InProgress(t *testing.T) {
+	t.Parallel()
+
+	cm := newTestHttpClientMaker(t)
+	cm.MakeClientFunc = func(ctx context.Context) (*http.Client, error) {
+		return new(http.Client), nil
+	}
+
+	tr := &Trainer{
+		PersistentStore: newTestLeaseStore(t),
+		HttpClientMaker: cm,
+		SleepFunc: func(time.Duration) {
+			t.Error("Expected not to wait for the retrain in progress")
+		},
+	}
+	ctx := context.Background()
+
+	lease, _, err := tr.acquireLease(ctx, 500)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	defer lease.release(ctx)
+
+	err = tr.Retrain(ctx, time.Unix(600, 0))
+	if errors.Cause(err) != ErrRetrainInProgress {
+		t.Errorf("Expected ErrRetrainInProgress, got %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package mlclient
+
+const defaultMLProject = "moonbird-beshir"
+const defaultMLRegion = "us-east1"
+const defaultMLRuntimeVersion = "2.4"
+
+// MLEngine locates the model in ML Engine, and selects the runtime it is trained and served with.
+// Empty fields mean the defaults, for Moonbird's own deployment.
+type MLEngine struct {
+	Project        string
+	Region         string
+	RuntimeVersion string
+}
+
+func (e *MLEngine) projectName() string {
+	if e.Project == "" {
+		return "projects/" + defaultMLProject
+	}
+	return "projects/" + e.Project
+}
+
+func (e *MLEngine) modelName() string {
+	return e.projectName() + "/models/Predictor"
+}
+
+func (e *MLEngine) region() string {
+	if e.Region == "" {
+		return defaultMLRegion
+	}
+	return e.Region
+}
+
+func (e *MLEngine) runtimeVersion() string {
+	if e.RuntimeVersion == "" {
+		return defaultMLRuntimeVersion
+	}
+	return e.RuntimeVersion
+}
@@ -6,34 +6,112 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/tracing"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"google.golang.org/api/ml/v1"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/sha3"
 )
 
+// PredictionOutcome describes how a prediction was made, or why it wasn't, for metrics.
+type PredictionOutcome string
+
+const (
+	PredictionCacheHit        PredictionOutcome = "cache_hit"
+	PredictionBackendSuccess  PredictionOutcome = "backend_success"
+	PredictionBackendError    PredictionOutcome = "backend_error"
+	PredictionValidationError PredictionOutcome = "validation_error"
+)
+
 type PredictionMaker struct {
 	CacheStorage    CacheStorage
 	HttpClientMaker HttpClientMaker
+	MLEngine        MLEngine
+
+	// CanaryVersion, if set, receives CanaryFraction of predictions instead of the default version.
+	// The split is by input, so the same assignments are always predicted by the same version.
+	CanaryVersion  string
+	CanaryFraction float64
+
+	// Shadow, if set, is also asked for every prediction we get from ML Engine, through Background once we have
+	// ours, and its result recorded alongside ours by ShadowRecorder. It never affects the result returned,
+	// or how long it takes. Cached predictions are not shadowed, as nothing new would be compared.
+	Shadow         Predictor
+	ShadowName     string
+	ShadowRecorder ShadowRecorder
+	Background     BackgroundRunner
+
+	// Metrics, if set, is told the outcome of each prediction, and the latency of each call to ML Engine.
+	Metrics PredictionMetrics
 }
 
 func (pm *PredictionMaker) Predict(ctx context.Context, predictions []float64) (p float64, err error) {
-	l := ctxlogrus.Get(ctx)
+	p, _, err = pm.PredictWithVersion(ctx, predictions)
+	return
+}
+
+// PredictWithVersion makes a prediction as Predict does, and also returns the model version which made it,
+// or an empty string if it was the default version.
+func (pm *PredictionMaker) PredictWithVersion(ctx context.Context, predictions []float64) (p float64, version string, err error) {
+	if pm.CanaryVersion != "" && inputFraction(predictions) < pm.CanaryFraction {
+		version = pm.CanaryVersion
+	}
+
+	p, err = pm.predictVersion(ctx, version, predictions, pm.Shadow != nil)
+	return
+}
+
+// PredictVersion makes a prediction using the named model version, or the default version if empty.
+func (pm *PredictionMaker) PredictVersion(ctx context.Context, version string, predictions []float64) (p float64, err error) {
+	return pm.predictVersion(ctx, version, predictions, false)
+}
+
+func (pm *PredictionMaker) predictVersion(ctx context.Context, version string, predictions []float64, shadow bool) (p float64, err error) {
+	versionLabel := version
+	if versionLabel == "" {
+		versionLabel = "default"
+	}
+	l := ctxlogrus.Get(ctx).WithField("model-version", versionLabel)
 	l.Debugf("Predicting from inputs: %v", predictions)
 
+	ctx, span := tracing.Start(ctx, "PredictionMaker.PredictVersion",
+		tracing.String("model.version", versionLabel),
+		tracing.Int("prediction.assignments", int64(len(predictions))))
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
 	cacheKey := generatePredictionCacheKey(predictions)
+	if version != "" {
+		cacheKey = version + ":" + cacheKey
+	}
 	req, err := newMLRequest(predictions)
 	if err != nil {
+		pm.recordPrediction(PredictionValidationError, predictions)
 		return 0, errors.Wrap(err, "makePrediction couldn't create request")
 	}
 
 	err = pm.CacheStorage.Get(ctx, cacheKey, &p)
 	if err == nil {
+		pm.recordPrediction(PredictionCacheHit, predictions)
+		logPrediction(l, predictions, p, true)
 		return
 	}
 	l.Info("Can't read prediction from cache: " + err.Error())
 
+	// Every failure from here on is the backend's.
+	defer func() {
+		if err != nil {
+			pm.recordPrediction(PredictionBackendError, predictions)
+		} else {
+			pm.recordPrediction(PredictionBackendSuccess, predictions)
+		}
+	}()
+
 	client, err := pm.HttpClientMaker.MakeClient(ctx)
 	if err != nil {
 		return 0, errors.Wrap(err, "makePrediction couldn't create client")
@@ -44,9 +122,18 @@ func (pm *PredictionMaker) Predict(ctx context.Context, predictions []float64) (
 		return 0, errors.Wrap(err, "makePrediction couldn't create service")
 	}
 
+	name := pm.MLEngine.modelName()
+	if version != "" {
+		name += "/versions/" + version
+	}
+
 	l.Info("Making predict call...")
-	mlPredictCall := s.Projects.Predict("projects/moonbird-beshir/models/Predictor", req)
+	mlPredictCall := s.Projects.Predict(name, req)
+	callStart := time.Now()
 	r, err := mlPredictCall.Context(ctx).Do()
+	if pm.Metrics != nil {
+		pm.Metrics.BackendCalled(time.Since(callStart))
+	}
 	if err != nil {
 		return 0, errors.Wrap(err, "makePrediction couldn't run request")
 	}
@@ -58,6 +145,7 @@ func (pm *PredictionMaker) Predict(ctx context.Context, predictions []float64) (
 		return 0, errors.New("makePrediction got malformed predict response: Did not get one and only one probability")
 	}
 	p = result.Predictions[0].Income[0]
+	logPrediction(l, predictions, p, false)
 
 	// We ignore failures in writing to cache.
 	cacheWriteErr := pm.CacheStorage.Set(ctx, cacheKey, &p)
@@ -65,9 +153,28 @@ func (pm *PredictionMaker) Predict(ctx context.Context, predictions []float64) (
 		l.Warn("Can't write prediction to cache: " + cacheWriteErr.Error())
 	}
 
+	if shadow {
+		pm.runShadow(ctx, version, predictions, p)
+	}
 	return
 }
 
+func (pm *PredictionMaker) recordPrediction(outcome PredictionOutcome, predictions []float64) {
+	if pm.Metrics != nil {
+		pm.Metrics.PredictionMade(outcome, len(predictions))
+	}
+}
+
+// logPrediction records each prediction with the version that made it,
+// so versions can be compared on live traffic.
+func logPrediction(l *logrus.Entry, predictions []float64, p float64, cached bool) {
+	l.WithFields(logrus.Fields{
+		"inputs":     predictions,
+		"prediction": p,
+		"cached":     cached,
+	}).Info("Prediction made")
+}
+
 type request struct {
 	Instances []requestInput `json:"instances"`
 }
@@ -117,9 +224,18 @@ func newMLRequest(predictions []float64) (*ml.GoogleCloudMlV1__PredictRequest, e
 }
 
 func generatePredictionCacheKey(predictions []float64) string {
+	return base64.StdEncoding.EncodeToString(hashPredictions(predictions))
+}
+
+// inputFraction maps a set of inputs to a stable value in [0, 1).
+func inputFraction(predictions []float64) float64 {
+	return float64(binary.BigEndian.Uint64(hashPredictions(predictions))>>11) / (1 << 53)
+}
+
+func hashPredictions(predictions []float64) []byte {
 	hash := sha3.New512()
 	for _, p := range predictions {
 		binary.Write(hash, binary.BigEndian, p)
 	}
-	return base64.StdEncoding.EncodeToString(hash.Sum(nil))
+	return hash.Sum(nil)
 }
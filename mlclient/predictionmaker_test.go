@@ -284,3 +284,139 @@ func predictionMaker_Predict_FromMLEngineSetup(t *testing.T, roundTripFunc func(
 
 	return cs, pm
 }
+
+func TestPredictionMaker_Predict_Canary(t *testing.T) {
+	t.Parallel()
+
+	cs, pm := predictionMaker_Predict_FromMLEngineSetup(t, func(r *http.Request) (*http.Response, error) {
+		expectedUrl := "https://ml.googleapis.com/v1/projects/moonbird-beshir/models/Predictor/versions/v600:predict?alt=json&prettyPrint=false"
+		if r.URL.String() != expectedUrl {
+			t.Errorf("Incorrect request URL, expected %s, was %s", expectedUrl, r.URL.String())
+		}
+
+		resp := new(http.Response)
+		resp.StatusCode = 200
+		resp.ContentLength = -1
+		resp.Body = ioutil.NopCloser(strings.NewReader(`{"predictions":[{"income":[0.3]}]}`))
+		return resp, nil
+	})
+	pm.CanaryVersion = "v600"
+	pm.CanaryFraction = 1
+
+	expectedCacheKey := "v600:" + generatePredictionCacheKey([]float64{0.4, 0.1})
+	cs.SetFunc = func(ctx context.Context, key string, v interface{}) error {
+		if key != expectedCacheKey {
+			t.Errorf("Writing to wrong cache key; expected %s, was %s", expectedCacheKey, key)
+		}
+		return nil
+	}
+
+	c := context.Background()
+	result, err := pm.Predict(c, []float64{0.4, 0.1})
+	if err != nil {
+		t.Errorf("Unexpected error from Predict: %s", err)
+	}
+	if result != 0.3 {
+		t.Errorf("Incorrect prediction result; expected %g, was %g", 0.3, result)
+	}
+}
+
+func TestInputFraction(t *testing.T) {
+	t.Parallel()
+
+	f := inputFraction([]float64{0.4, 0.1})
+	if f < 0 || f >= 1 {
+		t.Errorf("Expected fraction in [0, 1), was %g", f)
+	}
+	if f != inputFraction([]float64{0.4, 0.1}) {
+		t.Error("Expected fraction to be stable for the same inputs")
+	}
+
+	canaryCount := 0
+	for i := 0; i < 1000; i++ {
+		if inputFraction([]float64{float64(i) / 1000}) < 0.1 {
+			canaryCount++
+		}
+	}
+	if canaryCount < 50 || canaryCount > 150 {
+		t.Errorf("Expected roughly 100 of 1000 inputs below 0.1, got %d", canaryCount)
+	}
+}
+
+func TestPredictionMaker_PredictWithVersion(t *testing.T) {
+	t.Parallel()
+
+	cs := testhelpers.NewCacheStore(t)
+	cs.GetFunc = func(ctx context.Context, key string, v interface{}) error {
+		*v.(*float64) = 0.3
+		return nil
+	}
+	pm := &PredictionMaker{
+		CacheStorage:    cs,
+		HttpClientMaker: newTestHttpClientMaker(t),
+		CanaryVersion:   "v600",
+	}
+
+	c := context.Background()
+	_, version, err := pm.PredictWithVersion(c, []float64{0.4, 0.1})
+	if err != nil || version != "" {
+		t.Errorf("Expected default version without canary traffic, got %q, %v", version, err)
+	}
+
+	pm.CanaryFraction = 1
+	_, version, err = pm.PredictWithVersion(c, []float64{0.4, 0.1})
+	if err != nil || version != "v600" {
+		t.Errorf("Expected canary version with all canary traffic, got %q, %v", version, err)
+	}
+}
+
+func TestPredictionMaker_Predict_Metrics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		predictions []float64
+		cached      bool
+		statusCode  int
+		want        PredictionOutcome
+		wantCalls   int
+	}{
+		{"out of range", []float64{0.4, -0.1}, false, 0, PredictionValidationError, 0},
+		{"cached", []float64{0.4, 0.1}, true, 0, PredictionCacheHit, 0},
+		{"from ML Engine", []float64{0.4, 0.1, 0.2}, false, 200, PredictionBackendSuccess, 1},
+		{"ML Engine error", []float64{0.4, 0.1}, false, 500, PredictionBackendError, 1},
+	}
+
+	for _, test := range tests {
+		cs, pm := predictionMaker_Predict_FromMLEngineSetup(t, func(r *http.Request) (*http.Response, error) {
+			resp := new(http.Response)
+			resp.StatusCode = test.statusCode
+			resp.ContentLength = -1
+			resp.Body = ioutil.NopCloser(strings.NewReader(`{"predictions":[{"income":[0.3]}]}`))
+			return resp, nil
+		})
+		if test.cached {
+			cs.GetFunc = func(ctx context.Context, key string, v interface{}) error {
+				*v.(*float64) = 0.3
+				return nil
+			}
+		}
+		cs.SetFunc = func(ctx context.Context, key string, v interface{}) error {
+			return nil
+		}
+		metrics := &testPredictionMetrics{}
+		pm.Metrics = metrics
+
+		_, _ = pm.Predict(context.Background(), test.predictions)
+
+		if !reflect.DeepEqual(metrics.outcomes, []PredictionOutcome{test.want}) {
+			t.Errorf("%s: expected outcome %s, got %v", test.name, test.want, metrics.outcomes)
+		}
+		if !reflect.DeepEqual(metrics.assignments, []int{len(test.predictions)}) {
+			t.Errorf("%s: expected %d assignments, got %v", test.name, len(test.predictions), metrics.assignments)
+		}
+		if metrics.calls != test.wantCalls {
+			t.Errorf("%s: expected %d backend calls, got %d", test.name, test.wantCalls, metrics.calls)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package mlclient
 import (
 	"context"
 	"github.com/jbeshir/moonbird-auth-frontend/data"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
 	"github.com/jbeshir/predictionbook-extractor/predictions"
 	"net/http"
 	"time"
@@ -19,6 +20,12 @@ type PersistentStore interface {
 	Transact(ctx context.Context, f func(ctx context.Context) error) error
 }
 
+// TrainerStore is a persistent store which can also delete entities, as the trainer's checkpoints need.
+type TrainerStore interface {
+	PersistentStore
+	Delete(ctx context.Context, kind, key string) error
+}
+
 type FileStore interface {
 	Load(ctx context.Context, path string) ([]byte, error)
 	Save(ctx context.Context, path string, content []byte) error
@@ -32,3 +39,28 @@ type PredictionSource interface {
 type HttpClientMaker interface {
 	MakeClient(ctx context.Context) (*http.Client, error)
 }
+
+type Predictor interface {
+	Predict(ctx context.Context, predictions []float64) (p float64, err error)
+}
+
+// BackgroundRunner runs work which may outlive the request starting it.
+type BackgroundRunner interface {
+	RunInBackground(ctx context.Context, f func(ctx context.Context))
+}
+
+type ShadowRecorder interface {
+	Record(ctx context.Context, c data2.ShadowComparison)
+}
+
+// PredictionMetrics is told how predictions are made, for monitoring.
+type PredictionMetrics interface {
+	PredictionMade(outcome PredictionOutcome, assignments int)
+	BackendCalled(latency time.Duration)
+}
+
+// RetrainMetrics is told how each retrain, and each of its stages, ends, for monitoring.
+type RetrainMetrics interface {
+	RetrainStageFinished(stage string, duration time.Duration, err error)
+	RetrainFinished(outcome data2.TrainingRunOutcome, duration time.Duration)
+}
@@ -0,0 +1,158 @@
+package mlclient
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+const shadowComparisonsKind = "ShadowComparisons"
+const shadowComparisonsKey = "recent"
+
+const maxShadowComparisons = 500
+
+// Every write contends on the same entity, so a buffered log writes comparisons in batches,
+// once there are shadowBatchSize of them or the oldest has waited shadowFlushInterval.
+const shadowBatchSize = 20
+const shadowFlushInterval = time.Minute
+
+// Shadow predictions run in the background, but still get a deadline, so a hung version can't pile up work.
+const shadowTimeout = 5 * time.Second
+
+// VersionPredictor makes predictions using a specific model version, for use as a shadow.
+type VersionPredictor struct {
+	PredictionMaker *PredictionMaker
+	Version         string
+}
+
+func (vp *VersionPredictor) Predict(ctx context.Context, predictions []float64) (float64, error) {
+	return vp.PredictionMaker.PredictVersion(ctx, vp.Version, predictions)
+}
+
+type ShadowLog struct {
+	PersistentStore PersistentStore
+	NowFunc         func() time.Time
+
+	// Buffered has comparisons written in batches, rather than each as it is recorded.
+	// Comparisons still buffered are lost unless Flush is called before stopping,
+	// so it should only be set where we see the process stop.
+	Buffered bool
+
+	mu      sync.Mutex
+	pending []data2.ShadowComparison
+}
+
+type shadowComparisonLog struct {
+	Comparisons []data2.ShadowComparison
+}
+
+// runShadow asks the shadow for its prediction in the background, once we've made ours,
+// and records the two alongside each other. Nothing waits on it, so it can't delay our response.
+func (pm *PredictionMaker) runShadow(ctx context.Context, version string, predictions []float64, primary float64) {
+	c := data2.ShadowComparison{
+		InputHash:      generatePredictionCacheKey(predictions),
+		Inputs:         predictions,
+		PrimaryVersion: version,
+		ShadowVersion:  pm.ShadowName,
+		Primary:        primary,
+	}
+	pm.Background.RunInBackground(ctx, func(ctx context.Context) {
+		shadowCtx, cancel := context.WithTimeout(ctx, shadowTimeout)
+		defer cancel()
+
+		var err error
+		c.Shadow, err = pm.Shadow.Predict(shadowCtx, predictions)
+		if err != nil {
+			c.Err = err.Error()
+		}
+		if pm.ShadowRecorder != nil {
+			pm.ShadowRecorder.Record(ctx, c)
+		}
+	})
+}
+
+func (sl *ShadowLog) RecentComparisons(ctx context.Context) ([]data2.ShadowComparison, error) {
+	comparisonLog := new(shadowComparisonLog)
+	_, err := sl.PersistentStore.Get(ctx, shadowComparisonsKind, shadowComparisonsKey, comparisonLog)
+	if err != nil {
+		if errors.Cause(err) == data.ErrNoSuchEntity {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "")
+	}
+	return comparisonLog.Comparisons, nil
+}
+
+// Record adds the comparison to the log, newest first, once its batch is written, or immediately if unbuffered.
+// Errors are only logged, as there is nobody waiting on a shadow prediction to report them to.
+func (sl *ShadowLog) Record(ctx context.Context, c data2.ShadowComparison) {
+	c.Time = sl.now()
+
+	if !sl.Buffered {
+		if err := sl.write(ctx, []data2.ShadowComparison{c}); err != nil {
+			ctxlogrus.Get(ctx).Warnf("Unable to record shadow comparison: %s", err)
+		}
+		return
+	}
+
+	sl.mu.Lock()
+	sl.pending = append(sl.pending, c)
+	var batch []data2.ShadowComparison
+	if len(sl.pending) >= shadowBatchSize || c.Time.Sub(sl.pending[0].Time) >= shadowFlushInterval {
+		batch = sl.pending
+		sl.pending = nil
+	}
+	sl.mu.Unlock()
+
+	if batch != nil {
+		if err := sl.write(ctx, batch); err != nil {
+			ctxlogrus.Get(ctx).Warnf("Unable to record %d shadow comparisons: %s", len(batch), err)
+		}
+	}
+}
+
+// Flush writes any buffered comparisons, such as before shutting down.
+func (sl *ShadowLog) Flush(ctx context.Context) error {
+	sl.mu.Lock()
+	batch := sl.pending
+	sl.pending = nil
+	sl.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	return sl.write(ctx, batch)
+}
+
+func (sl *ShadowLog) write(ctx context.Context, batch []data2.ShadowComparison) error {
+	return sl.PersistentStore.Transact(ctx, func(ctx context.Context) error {
+		comparisonLog := new(shadowComparisonLog)
+		_, err := sl.PersistentStore.Get(ctx, shadowComparisonsKind, shadowComparisonsKey, comparisonLog)
+		if err != nil && errors.Cause(err) != data.ErrNoSuchEntity {
+			return errors.Wrap(err, "")
+		}
+
+		comparisons := make([]data2.ShadowComparison, 0, len(batch)+len(comparisonLog.Comparisons))
+		for i := len(batch) - 1; i >= 0; i-- {
+			comparisons = append(comparisons, batch[i])
+		}
+		comparisons = append(comparisons, comparisonLog.Comparisons...)
+		if len(comparisons) > maxShadowComparisons {
+			comparisons = comparisons[:maxShadowComparisons]
+		}
+		comparisonLog.Comparisons = comparisons
+
+		return errors.Wrap(sl.PersistentStore.Set(ctx, shadowComparisonsKind, shadowComparisonsKey, nil, comparisonLog), "")
+	})
+}
+
+func (sl *ShadowLog) now() time.Time {
+	if sl.NowFunc != nil {
+		return sl.NowFunc()
+	}
+	return time.Now()
+}
@@ -0,0 +1,351 @@
+package mlclient
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPredictionMaker_Predict_Shadow(t *testing.T) {
+	t.Parallel()
+
+	_, pm := predictionMaker_Predict_FromMLEngineSetup(t, func(r *http.Request) (*http.Response, error) {
+		resp := new(http.Response)
+		resp.StatusCode = 200
+		resp.ContentLength = -1
+		resp.Body = ioutil.NopCloser(strings.NewReader(`{"predictions":[{"income":[0.3]}]}`))
+		return resp, nil
+	})
+	pm.CacheStorage.(*testhelpers.CacheStore).SetFunc = func(ctx context.Context, key string, v interface{}) error {
+		return nil
+	}
+
+	shadow := newTestPredictor(t)
+	shadow.PredictFunc = func(ctx context.Context, predictions []float64) (float64, error) {
+		return 0.5, nil
+	}
+
+	var recorded []data2.ShadowComparison
+	sr := newTestShadowRecorder(t)
+	sr.RecordFunc = func(ctx context.Context, c data2.ShadowComparison) {
+		recorded = append(recorded, c)
+	}
+
+	br := &testBackgroundRunner{}
+	pm.Shadow = shadow
+	pm.ShadowName = "v600"
+	pm.ShadowRecorder = sr
+	pm.Background = br
+
+	result, err := pm.Predict(context.Background(), []float64{0.4, 0.1})
+	if err != nil {
+		t.Errorf("Unexpected error from Predict: %s", err)
+	}
+	if result != 0.3 {
+		t.Errorf("Incorrect prediction result; expected %g, was %g", 0.3, result)
+	}
+
+	br.Wait()
+	if len(recorded) != 1 {
+		t.Fatalf("Expected one shadow comparison to be recorded, got %d", len(recorded))
+	}
+	c := recorded[0]
+	if c.InputHash != generatePredictionCacheKey([]float64{0.4, 0.1}) {
+		t.Errorf("Expected comparison to be tagged with the input hash, was %s", c.InputHash)
+	}
+	if c.Primary != 0.3 || c.Shadow != 0.5 || c.PrimaryVersion != "" || c.ShadowVersion != "v600" || c.Err != "" {
+		t.Errorf("Unexpected comparison recorded: %+v", c)
+	}
+}
+
+func TestPredictionMaker_Predict_ShadowCanary(t *testing.T) {
+	t.Parallel()
+
+	_, pm := predictionMaker_Predict_FromMLEngineSetup(t, func(r *http.Request) (*http.Response, error) {
+		resp := new(http.Response)
+		resp.StatusCode = 200
+		resp.ContentLength = -1
+		resp.Body = ioutil.NopCloser(strings.NewReader(`{"predictions":[{"income":[0.3]}]}`))
+		return resp, nil
+	})
+	pm.CacheStorage.(*testhelpers.CacheStore).SetFunc = func(ctx context.Context, key string, v interface{}) error {
+		return nil
+	}
+
+	shadow := newTestPredictor(t)
+	shadow.PredictFunc = func(ctx context.Context, predictions []float64) (float64, error) {
+		return 0.5, nil
+	}
+
+	var recorded []data2.ShadowComparison
+	sr := newTestShadowRecorder(t)
+	sr.RecordFunc = func(ctx context.Context, c data2.ShadowComparison) {
+		recorded = append(recorded, c)
+	}
+
+	br := &testBackgroundRunner{}
+	pm.CanaryVersion = "v700"
+	pm.CanaryFraction = 1
+	pm.Shadow = shadow
+	pm.ShadowName = "v600"
+	pm.ShadowRecorder = sr
+	pm.Background = br
+
+	_, err := pm.Predict(context.Background(), []float64{0.4, 0.1})
+	if err != nil {
+		t.Errorf("Unexpected error from Predict: %s", err)
+	}
+
+	br.Wait()
+	if len(recorded) != 1 || recorded[0].PrimaryVersion != "v700" {
+		t.Errorf("Expected comparison to record the canary as the live version, got %+v", recorded)
+	}
+}
+
+func TestPredictionMaker_Predict_SlowShadow(t *testing.T) {
+	t.Parallel()
+
+	_, pm := predictionMaker_Predict_FromMLEngineSetup(t, func(r *http.Request) (*http.Response, error) {
+		resp := new(http.Response)
+		resp.StatusCode = 200
+		resp.ContentLength = -1
+		resp.Body = ioutil.NopCloser(strings.NewReader(`{"predictions":[{"income":[0.3]}]}`))
+		return resp, nil
+	})
+	pm.CacheStorage.(*testhelpers.CacheStore).SetFunc = func(ctx context.Context, key string, v interface{}) error {
+		return nil
+	}
+
+	release := make(chan struct{})
+	shadow := newTestPredictor(t)
+	shadow.PredictFunc = func(ctx context.Context, predictions []float64) (float64, error) {
+		<-release
+		return 0.5, nil
+	}
+
+	recorded := make(chan data2.ShadowComparison, 1)
+	sr := newTestShadowRecorder(t)
+	sr.RecordFunc = func(ctx context.Context, c data2.ShadowComparison) {
+		recorded <- c
+	}
+
+	br := &testBackgroundRunner{}
+	pm.Shadow = shadow
+	pm.ShadowName = "v600"
+	pm.ShadowRecorder = sr
+	pm.Background = br
+
+	// Predict returns while the shadow is still blocked.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		result, err := pm.Predict(context.Background(), []float64{0.4, 0.1})
+		if err != nil || result != 0.3 {
+			t.Errorf("Expected prediction of 0.3, got %g, %v", result, err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Predict to return without waiting for the shadow")
+	}
+
+	close(release)
+	br.Wait()
+	select {
+	case c := <-recorded:
+		if c.Primary != 0.3 || c.Shadow != 0.5 {
+			t.Errorf("Unexpected comparison recorded: %+v", c)
+		}
+	default:
+		t.Error("Expected the shadow comparison to be recorded once the shadow finished")
+	}
+}
+
+func TestPredictionMaker_Predict_ShadowCacheHit(t *testing.T) {
+	t.Parallel()
+
+	cs := testhelpers.NewCacheStore(t)
+	cs.GetFunc = func(ctx context.Context, key string, v interface{}) error {
+		*v.(*float64) = 0.3
+		return nil
+	}
+
+	// The shadow and recorder fail the test if called.
+	pm := &PredictionMaker{
+		CacheStorage:    cs,
+		HttpClientMaker: newTestHttpClientMaker(t),
+		Shadow:          newTestPredictor(t),
+		ShadowName:      "v600",
+		ShadowRecorder:  newTestShadowRecorder(t),
+	}
+
+	result, err := pm.Predict(context.Background(), []float64{0.4, 0.1})
+	if err != nil {
+		t.Errorf("Unexpected error from Predict: %s", err)
+	}
+	if result != 0.3 {
+		t.Errorf("Incorrect prediction result; expected %g, was %g", 0.3, result)
+	}
+}
+
+func TestShadowLog_Record(t *testing.T) {
+	t.Parallel()
+
+	var saved *shadowComparisonLog
+	var transactions int
+	ps := testhelpers.NewPersistentStore(t)
+	ps.TransactFunc = func(ctx context.Context, f func(ctx context.Context) error) error {
+		transactions++
+		return f(ctx)
+	}
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		if kind != shadowComparisonsKind || key != shadowComparisonsKey {
+			t.Errorf("Unexpected retrieval of %s/%s", kind, key)
+		}
+		if saved == nil {
+			return nil, data.ErrNoSuchEntity
+		}
+		*v.(*shadowComparisonLog) = *saved
+		return nil, nil
+	}
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		saved = v.(*shadowComparisonLog)
+		return nil
+	}
+
+	sl := &ShadowLog{
+		PersistentStore: ps,
+		Buffered:        true,
+		NowFunc: func() time.Time {
+			return time.Unix(1000, 0)
+		},
+	}
+	ctx := context.Background()
+
+	comparisons, err := sl.RecentComparisons(ctx)
+	if err != nil || comparisons != nil {
+		t.Errorf("Expected no comparisons and no error before recording, got %v, %v", comparisons, err)
+	}
+
+	for i := 0; i < shadowBatchSize-1; i++ {
+		sl.Record(ctx, data2.ShadowComparison{Primary: float64(i)})
+	}
+	if saved != nil || transactions != 0 {
+		t.Fatalf("Expected comparisons to be buffered until a batch is full, got %d transactions", transactions)
+	}
+
+	for i := shadowBatchSize - 1; i < maxShadowComparisons+1; i++ {
+		sl.Record(ctx, data2.ShadowComparison{Primary: float64(i)})
+	}
+	if transactions != (maxShadowComparisons+1)/shadowBatchSize {
+		t.Errorf("Expected one transaction per batch, got %d", transactions)
+	}
+	if err := sl.Flush(ctx); err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+
+	comparisons, err = sl.RecentComparisons(ctx)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	if len(comparisons) != maxShadowComparisons {
+		t.Fatalf("Expected %d comparisons to be kept, got %d", maxShadowComparisons, len(comparisons))
+	}
+	if comparisons[0].Primary != maxShadowComparisons || comparisons[1].Primary != maxShadowComparisons-1 {
+		t.Errorf("Expected newest comparisons first, got %+v, %+v", comparisons[0], comparisons[1])
+	}
+	if !comparisons[0].Time.Equal(time.Unix(1000, 0)) {
+		t.Errorf("Expected comparison to be timestamped, got %s", comparisons[0].Time)
+	}
+}
+
+func TestShadowLog_RecentComparisons_Error(t *testing.T) {
+	t.Parallel()
+
+	ps := testhelpers.NewPersistentStore(t)
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		return nil, errors.New("bluh")
+	}
+
+	sl := &ShadowLog{PersistentStore: ps}
+	_, err := sl.RecentComparisons(context.Background())
+	if err == nil {
+		t.Error("Expected error, got nil error")
+	}
+}
+
+func TestShadowLog_Record_FlushInterval(t *testing.T) {
+	t.Parallel()
+
+	var saved *shadowComparisonLog
+	ps := testhelpers.NewPersistentStore(t)
+	ps.TransactFunc = func(ctx context.Context, f func(ctx context.Context) error) error {
+		return f(ctx)
+	}
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		return nil, data.ErrNoSuchEntity
+	}
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		saved = v.(*shadowComparisonLog)
+		return nil
+	}
+
+	now := time.Unix(1000, 0)
+	sl := &ShadowLog{
+		PersistentStore: ps,
+		Buffered:        true,
+		NowFunc: func() time.Time {
+			return now
+		},
+	}
+
+	sl.Record(context.Background(), data2.ShadowComparison{Primary: 0.1})
+	if saved != nil {
+		t.Fatal("Expected a lone comparison to be buffered")
+	}
+
+	now = now.Add(shadowFlushInterval)
+	sl.Record(context.Background(), data2.ShadowComparison{Primary: 0.2})
+	if saved == nil || len(saved.Comparisons) != 2 {
+		t.Fatalf("Expected both comparisons to be written once the first had waited, got %+v", saved)
+	}
+}
+
+func TestShadowLog_Record_Unbuffered(t *testing.T) {
+	t.Parallel()
+
+	var saved *shadowComparisonLog
+	ps := testhelpers.NewPersistentStore(t)
+	ps.TransactFunc = func(ctx context.Context, f func(ctx context.Context) error) error {
+		return f(ctx)
+	}
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		if saved == nil {
+			return nil, data.ErrNoSuchEntity
+		}
+		*v.(*shadowComparisonLog) = *saved
+		return nil, nil
+	}
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		saved = v.(*shadowComparisonLog)
+		return nil
+	}
+
+	sl := &ShadowLog{PersistentStore: ps}
+	sl.Record(context.Background(), data2.ShadowComparison{Primary: 0.1})
+	if saved == nil || len(saved.Comparisons) != 1 {
+		t.Fatalf("Expected the comparison to be written immediately, got %+v", saved)
+	}
+	sl.Record(context.Background(), data2.ShadowComparison{Primary: 0.2})
+	if len(saved.Comparisons) != 2 || saved.Comparisons[0].Primary != 0.2 {
+		t.Errorf("Expected both comparisons, newest first, got %+v", saved.Comparisons)
+	}
+}
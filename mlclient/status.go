@@ -0,0 +1,22 @@
+package mlclient
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	"github.com/pkg/errors"
+)
+
+type trainerStatus struct {
+	LatestModel int64
+}
+
+// getStatus returns the trainer's status. Until a model is first promoted there is none,
+// and we report a latest model of zero, meaning there is no previous model.
+func (tr *Trainer) getStatus(ctx context.Context) (*trainerStatus, error) {
+	status := new(trainerStatus)
+	_, err := tr.PersistentStore.Get(ctx, "TrainerStatus", "status", status)
+	if err != nil && errors.Cause(err) != data.ErrNoSuchEntity {
+		return nil, errors.Wrap(err, "")
+	}
+	return status, nil
+}
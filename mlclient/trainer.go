@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/csv"
 	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/moonbird-predictor-frontend/tracing"
 	"github.com/jbeshir/predictionbook-extractor/predictions"
 	"github.com/pkg/errors"
 	"google.golang.org/api/ml/v1"
@@ -12,70 +14,314 @@ import (
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
-type trainerStatus struct {
-	LatestModel int64
-}
-
 type Trainer struct {
-	PersistentStore  PersistentStore
+	PersistentStore  TrainerStore
 	FileStore        FileStore
 	PredictionSource PredictionSource
 	ModelPath        string
 	DataPath         string
 	TrainPackage     string
 	SleepFunc        func(time.Duration)
+	NowFunc          func() time.Time
+	LeaseDuration    time.Duration
 	HttpClientMaker  HttpClientMaker
+	MLEngine         MLEngine
+
+	// TrainOnInternalQuestions is whether PredictionSource includes questions asked on Moonbird itself.
+	TrainOnInternalQuestions bool
+
+	// Stopping is closed when the process is shutting down. A retrain in progress then finishes
+	// its current stage, checkpoints, and fails with ErrRetrainInterrupted; the next retrain resumes it.
+	// If nil, retrains are never interrupted, and no checkpoints are kept.
+	Stopping <-chan struct{}
+
+	DuplicateResponses data.DuplicateResponsePolicy
+
+	// Metrics, if set, is told how each retrain and each of its stages ends.
+	Metrics RetrainMetrics
 }
 
+// Retrain trains and promotes a new model from the latest, as the scheduled retrain does.
+// It fails immediately with ErrRetrainInProgress if another retrain is running, rather than holding its request open.
 func (tr *Trainer) Retrain(ctx context.Context, now time.Time) error {
+	return tr.RetrainWithOptions(ctx, data.RetrainOptions{
+		Cutoff: now,
+	})
+}
+
+func (tr *Trainer) RetrainWithOptions(ctx context.Context, opts data.RetrainOptions) (err error) {
 	l := ctxlogrus.Get(ctx)
 
-	newModel := now.Unix()
-	newModelStr := strconv.FormatInt(newModel, 10)
+	ctx, span := tracing.Start(ctx, "Trainer.RetrainWithOptions")
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	opts.TrainOnInternalQuestions = tr.TrainOnInternalQuestions
 
 	client, err := tr.HttpClientMaker.MakeClient(ctx)
 	if err != nil {
 		return errors.Wrap(err, "")
 	}
 
-	// Get the current version of the model; this provides us with the path to the data it was based on,
-	// and tells us what time we need to incorporate predictions from after.
-	status := new(trainerStatus)
-	if _, err := tr.PersistentStore.Get(ctx, "TrainerStatus", "status", status); err != nil {
-		return errors.Wrap(err, "")
+	// Only one retrain may run at a time; a second invocation either fails immediately,
+	// or waits for the run in progress and reports its outcome.
+	lease, leaseCtx, err := tr.acquireLease(ctx, opts.Cutoff.Unix())
+	if err != nil {
+		if errors.Cause(err) == ErrRetrainInProgress && opts.AttachToRunning {
+			l.Infof("Attaching to in-progress retrain: %s", err)
+			return tr.attachToRun(ctx)
+		}
+		return err
+	}
+	defer lease.release(ctx)
+	ctx = leaseCtx
+
+	// A retrain interrupted by shutdown is resumed from its checkpoint, in place of starting a new one.
+	cp := new(retrainCheckpoint)
+	if tr.checkpointing() {
+		cp, err = tr.getCheckpoint(ctx)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
 	}
 
-	potentiallyResolved, unresolved, unresolvedRecords, err := tr.retrieveNewAndOutstandingPredictions(ctx, status.LatestModel, now)
-	l.Infof("Have %d potentially resolved, %d unresolved, and %d existing not due predictions",
-		len(potentiallyResolved), len(unresolved), len(unresolvedRecords))
+	var run *trainingRun
+	if cp.Model != 0 {
+		if conflicts := cp.resumeConflicts(opts); len(conflicts) > 0 {
+			return errors.Wrapf(ErrRetrainOptionsConflict, "retrain of model %d must finish first: %s",
+				cp.Model, strings.Join(conflicts, "; "))
+		}
+
+		l.Infof("Resuming retrain of model %d after %d completed stages", cp.Model, cp.Completed)
+		err = lease.update(ctx, func(lease *trainerLease, now time.Time) {
+			lease.Model = cp.Model
+		})
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+
+		run = tr.resumeRun(ctx, &cp.Report)
+	} else {
+		// The run is recorded before we look at the trainer's status, so failing to is recorded too.
+		run = tr.startRun(ctx, 0, opts.Cutoff.Unix())
+		run.report.SkipPromotion = opts.SkipPromotion
+		run.report.FullRebuild = opts.FullRebuild
+
+		cp, err = tr.newCheckpoint(ctx, opts)
+		if err != nil {
+			tr.finishRun(ctx, run, err)
+			return err
+		}
+		run.report.BaseModel = cp.BaseModel
+		tr.saveRunReport(ctx, run.report)
+	}
+	defer func() {
+		if tr.checkpointing() {
+			if errors.Cause(err) == ErrRetrainInterrupted {
+				l.Infof("Checkpointing retrain of model %d after %d completed stages", cp.Model, cp.Completed)
+				cp.Report = *run.report
+				if saveErr := tr.saveCheckpoint(ctx, cp); saveErr != nil {
+					err = errors.Wrap(saveErr, "unable to checkpoint interrupted retrain")
+				}
+			} else if saveErr := tr.saveCheckpoint(ctx, nil); saveErr != nil {
+				// A checkpoint left behind would have the next retrain resume this one.
+				if err == nil {
+					err = errors.Wrap(saveErr, "unable to clear retrain checkpoint")
+				} else {
+					err = errors.Wrapf(err, "unable to clear retrain checkpoint (%s)", saveErr)
+				}
+			}
+		}
+		tr.finishRun(ctx, run, err)
+	}()
+	report := run.report
+
+	newModel := cp.Model
+	newModelStr := strconv.FormatInt(newModel, 10)
+
+	if cp.Completed <= stageRetrievePredictions {
+		stageCtx := run.beginStage(ctx, "retrieve-predictions")
+		cp.PotentiallyResolved, cp.Unresolved, cp.UnresolvedRecords, err = tr.retrieveNewAndOutstandingPredictions(stageCtx, cp.BaseModel, cp.Options.Cutoff)
+		if err = run.endStage(err); err != nil {
+			return errors.Wrap(err, "")
+		}
+		l.Infof("Have %d potentially resolved, %d unresolved, and %d existing not due predictions",
+			len(cp.PotentiallyResolved), len(cp.Unresolved), len(cp.UnresolvedRecords))
+		report.PotentiallyResolvedCount = len(cp.PotentiallyResolved)
+
+		if err = tr.completeStage(cp); err != nil {
+			return err
+		}
+	}
 
-	// Retrieve and save out the responses to the newly resolved predictions.
-	l.Infof("Retrieving prediction responses and status for %d potentially resolved predictions",
-		len(potentiallyResolved))
-	newSummaries, responses, err := tr.PredictionSource.AllPredictionResponses(ctx, potentiallyResolved)
+	if cp.Completed <= stageRetrieveResponses {
+		// Retrieve and save out the responses to the newly resolved predictions.
+		l.Infof("Retrieving prediction responses and status for %d potentially resolved predictions",
+			len(cp.PotentiallyResolved))
+		stageCtx := run.beginStage(ctx, "retrieve-responses")
+		var newSummaries []*predictions.PredictionSummary
+		newSummaries, cp.Responses, err = tr.PredictionSource.AllPredictionResponses(stageCtx, cp.PotentiallyResolved)
+		if err = run.endStage(err); err != nil {
+			return errors.Wrap(err, "")
+		}
+
+		l.Info("Sorting potentially resolved into newly resolved and still unresolved predictions...")
+		for _, newSummary := range newSummaries {
+			if resolvedByCutoff(newSummary, cp.Options.Cutoff) {
+				cp.Resolved = append(cp.Resolved, newSummary)
+			} else {
+				cp.Unresolved = append(cp.Unresolved, unresolvedSummary(newSummary))
+			}
+		}
+		cp.Responses = responsesByCutoff(cp.Responses, cp.Options.Cutoff)
+		l.Infof("Now have %d newly resolved and %d still unresolved predictions", len(cp.Resolved),
+			len(cp.Unresolved))
+		report.ResolvedCount = len(cp.Resolved)
+		report.UnresolvedCount = len(cp.Unresolved)
+
+		if err = tr.completeStage(cp); err != nil {
+			return err
+		}
+	}
+
+	if cp.Completed <= stageWriteData {
+		stageCtx := run.beginStage(ctx, "write-data")
+		err = tr.writeTrainingData(stageCtx, run, newModelStr, cp.Resolved, cp.Unresolved, cp.UnresolvedRecords, cp.Responses)
+		if err = run.endStage(err); err != nil {
+			return errors.Wrap(err, "")
+		}
+
+		// The retrieved predictions are in the training data now, and needn't be kept in the checkpoint.
+		cp.PotentiallyResolved, cp.Resolved, cp.Unresolved, cp.UnresolvedRecords, cp.Responses = nil, nil, nil, nil, nil
+
+		if err = tr.completeStage(cp); err != nil {
+			return err
+		}
+	}
+
+	mlService, err := ml.New(client)
 	if err != nil {
 		return errors.Wrap(err, "")
 	}
 
-	l.Info("Sorting potentially resolved into newly resolved and still unresolved predictions...")
-	var resolvedSummaries []*predictions.PredictionSummary
-	for _, newSummary := range newSummaries {
-		if newSummary.Outcome != predictions.Unknown {
-			resolvedSummaries = append(resolvedSummaries, newSummary)
-		} else {
-			unresolved = append(unresolved, newSummary)
+	if cp.Completed <= stageTrain {
+		run.beginStage(ctx, "train")
+		if !cp.JobCreated {
+			l.Info("Launching training job...")
+			createCall := mlService.Projects.Jobs.Create(tr.MLEngine.projectName(), tr.newTrainJobSpec(cp.BaseModel, newModel))
+			_, err = createCall.Do()
+			cp.JobCreated = err == nil
+		}
+		if err == nil {
+			l.Info("Waiting for training job...")
+			err = tr.waitForTrainJob("predictor_"+strconv.FormatInt(newModel, 10), client)
+		}
+		if err = run.endStage(err); err != nil {
+			return errors.Wrap(err, "")
 		}
+
+		if err = tr.completeStage(cp); err != nil {
+			return err
+		}
+	}
+
+	if cp.Completed <= stageCreateVersion {
+		run.beginStage(ctx, "create-version")
+		if !cp.VersionCreated {
+			l.Info("Creating new version...")
+			versionCall := mlService.Projects.Models.Versions.Create(tr.MLEngine.modelName(), tr.newTrainVersionSpec(newModel))
+			_, err = versionCall.Do()
+			cp.VersionCreated = err == nil
+		}
+		if err == nil {
+			l.Info("Waiting for new version to be ready...")
+			err = tr.waitForVersionReady("v"+strconv.FormatInt(newModel, 10), client)
+		}
+		if err = run.endStage(err); err != nil {
+			return errors.Wrap(err, "")
+		}
+
+		if err = tr.completeStage(cp); err != nil {
+			return err
+		}
+	}
+
+	if cp.Options.SkipPromotion {
+		l.Info("Skipping promotion of new version")
+		return nil
+	}
+
+	l.Info("Setting new version as default...")
+	stageCtx := run.beginStage(ctx, "promote")
+
+	// Confirm we still hold the lease before making the new version live.
+	err = lease.heartbeat(stageCtx)
+	if err == nil {
+		versionDefaultCall := mlService.Projects.Models.Versions.SetDefault(tr.MLEngine.modelName()+"/versions/v"+strconv.FormatInt(newModel, 10),
+			&ml.GoogleCloudMlV1__SetDefaultVersionRequest{})
+		_, err = versionDefaultCall.Do()
+	}
+	if err == nil {
+		l.Infof("Updating latest model version to %d", newModel)
+		err = tr.updateLatestModel(stageCtx, cp.LatestModel, newModel)
+	}
+	if err = run.endStage(err); err != nil {
+		return errors.Wrap(err, "")
 	}
-	l.Infof("Now have %d newly resolved and %d still unresolved predictions", len(resolvedSummaries),
-		len(unresolved))
+	report.PromotedVersion = "v" + strconv.FormatInt(newModel, 10)
+
+	return nil
+}
+
+// newCheckpoint begins a retrain with the given options, from its first stage.
+func (tr *Trainer) newCheckpoint(ctx context.Context, opts data.RetrainOptions) (*retrainCheckpoint, error) {
+	newModel := opts.Cutoff.Unix()
+
+	// Get the current version of the model; this provides us with the path to the data it was based on,
+	// and tells us what time we need to incorporate predictions from after.
+	status, err := tr.getStatus(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	// A base model of zero means there is no previous model; we train from scratch on all predictions.
+	baseModel := status.LatestModel
+	if opts.BaseModel != 0 {
+		baseModel = opts.BaseModel
+	}
+	if opts.FullRebuild {
+		baseModel = 0
+	}
+	if baseModel >= newModel {
+		return nil, errors.Errorf("base model %d is not before cutoff %d", baseModel, newModel)
+	}
+
+	// Promoting a model older than the latest would move the latest model backwards.
+	if !opts.SkipPromotion && status.LatestModel >= newModel {
+		return nil, errors.Errorf("latest model %d is not before cutoff %d; earlier cutoffs must skip promotion", status.LatestModel, newModel)
+	}
+
+	return &retrainCheckpoint{
+		Model:       newModel,
+		BaseModel:   baseModel,
+		LatestModel: status.LatestModel,
+		Options:     opts,
+	}, nil
+}
+
+func (tr *Trainer) writeTrainingData(ctx context.Context, run *trainingRun, newModelStr string, resolvedSummaries, unresolved []*predictions.PredictionSummary, unresolvedRecords [][]string, responses []*predictions.PredictionResponse) error {
+	l := ctxlogrus.Get(ctx)
 
 	l.Info("Writing resolved prediction responses to CSV...")
 	var buf bytes.Buffer
 	csvWriter := csv.NewWriter(&buf)
-	for _, r := range responses {
+	for _, r := range tr.DuplicateResponses.Apply(responses) {
 		var summary *predictions.PredictionSummary
 		for _, candidate := range resolvedSummaries {
 			if candidate.Id == r.Prediction {
@@ -94,14 +340,15 @@ func (tr *Trainer) Retrain(ctx context.Context, now time.Time) error {
 			r.User,
 			r.Comment,
 		})
+		run.report.ResponseCount++
 	}
 	csvWriter.Flush()
-	err = csvWriter.Error()
+	err := csvWriter.Error()
 	if err != nil {
 		return errors.Wrap(err, "")
 	}
 
-	err = tr.FileStore.Save(ctx, strconv.FormatInt(now.Unix(), 10)+"/responsedata.csv", buf.Bytes())
+	err = tr.FileStore.Save(ctx, newModelStr+"/responsedata.csv", buf.Bytes())
 	if err != nil {
 		return errors.Wrap(err, "")
 	}
@@ -118,9 +365,13 @@ func (tr *Trainer) Retrain(ctx context.Context, now time.Time) error {
 	if err != nil {
 		return errors.Wrap(err, "")
 	}
+	run.report.OutstandingCount = len(unresolvedRecords)
 
 	train, cv, test := divideSummaries(rand.New(rand.NewSource(time.Now().Unix())), resolvedSummaries)
 	l.Infof("Split newly resolved predictions into %d train, %d cv, %d test", len(train), len(cv), len(test))
+	run.report.TrainCount = len(train)
+	run.report.CvCount = len(cv)
+	run.report.TestCount = len(test)
 
 	err = tr.writeCsv(ctx, newModelStr+"/summarydata-train.csv", tr.generateSummaryRecords(train))
 	if err != nil {
@@ -135,60 +386,19 @@ func (tr *Trainer) Retrain(ctx context.Context, now time.Time) error {
 		return errors.Wrap(err, "")
 	}
 
-	mlService, err := ml.New(client)
-	if err != nil {
-		return errors.Wrap(err, "")
-	}
-
-	l.Info("Launching training job...")
-	createCall := mlService.Projects.Jobs.Create("projects/moonbird-beshir", tr.newTrainJobSpec(status.LatestModel, newModel))
-	_, err = createCall.Do()
-	if err != nil {
-		return errors.Wrap(err, "")
-	}
-
-	l.Info("Waiting for training job...")
-	err = tr.waitForTrainJob("predictor_"+strconv.FormatInt(newModel, 10), client)
-	if err != nil {
-		return errors.Wrap(err, "")
-	}
-
-	l.Info("Creating new version...")
-	versionCall := mlService.Projects.Models.Versions.Create("projects/moonbird-beshir/models/Predictor", tr.newTrainVersionSpec(newModel))
-	_, err = versionCall.Do()
-	if err != nil {
-		return errors.Wrap(err, "")
-	}
-
-	l.Info("Waiting for new version to be ready...")
-	err = tr.waitForVersionReady("v"+strconv.FormatInt(newModel, 10), client)
-	if err != nil {
-		return errors.Wrap(err, "")
-	}
-
-	l.Info("Setting new version as default...")
-	versionDefaultCall := mlService.Projects.Models.Versions.SetDefault("projects/moonbird-beshir/models/Predictor/versions/v"+strconv.FormatInt(newModel, 10),
-		&ml.GoogleCloudMlV1__SetDefaultVersionRequest{})
-	_, err = versionDefaultCall.Do()
-	if err != nil {
-		return errors.Wrap(err, "")
-	}
-
-	l.Infof("Updating latest model version to %d", newModel)
-	err = tr.updateLatestModel(ctx, status.LatestModel, newModel)
-	if err != nil {
-		return errors.Wrap(err, "")
-	}
-
 	return nil
 }
 
-func (tr *Trainer) retrieveNewAndOutstandingPredictions(ctx context.Context, prevModel int64, now time.Time) (potentiallyResolved []*predictions.PredictionSummary, unresolved []*predictions.PredictionSummary, unresolvedRecords [][]string, err error) {
+func (tr *Trainer) retrieveNewAndOutstandingPredictions(ctx context.Context, prevModel int64, cutoff time.Time) (potentiallyResolved []*predictions.PredictionSummary, unresolved []*predictions.PredictionSummary, unresolvedRecords [][]string, err error) {
 	l := ctxlogrus.Get(ctx)
 
-	oldPredictionFile, err := tr.FileStore.Load(ctx, strconv.FormatInt(prevModel, 10)+"/summarydata-unresolved.csv")
-	if err != nil {
-		return nil, nil, nil, errors.Wrap(err, "")
+	// Without a previous model, there are no outstanding predictions carried over from it.
+	var oldPredictionFile []byte
+	if prevModel != 0 {
+		oldPredictionFile, err = tr.FileStore.Load(ctx, strconv.FormatInt(prevModel, 10)+"/summarydata-unresolved.csv")
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "")
+		}
 	}
 
 	l.Debugf("Retrieving new predictions from source since %d", prevModel)
@@ -207,14 +417,19 @@ func (tr *Trainer) retrieveNewAndOutstandingPredictions(ctx context.Context, pre
 	l.Debug("Sorting potentially resolved and unresolved predictions apart...")
 	potentiallyResolvedIds := make(map[int64]struct{})
 	for _, p := range newPredictions {
-		if p.Outcome != predictions.Unknown {
+		// Predictions made after the cutoff are left for a later model.
+		if p.Created.After(cutoff) {
+			continue
+		}
+
+		if resolvedByCutoff(p, cutoff) {
 			_, exists := potentiallyResolvedIds[p.Id]
 			if !exists {
 				potentiallyResolved = append(potentiallyResolved, p)
 				potentiallyResolvedIds[p.Id] = struct{}{}
 			}
 		} else {
-			unresolved = append(unresolved, p)
+			unresolved = append(unresolved, unresolvedSummary(p))
 		}
 	}
 
@@ -229,7 +444,7 @@ func (tr *Trainer) retrieveNewAndOutstandingPredictions(ctx context.Context, pre
 			return nil, nil, nil, errors.Wrap(err, "")
 		}
 
-		if now.After(time.Unix(deadlineUnix, 0)) {
+		if cutoff.After(time.Unix(deadlineUnix, 0)) {
 			_, exists := potentiallyResolvedIds[id]
 			if !exists {
 				potentiallyResolved = append(potentiallyResolved, &predictions.PredictionSummary{
@@ -245,10 +460,38 @@ func (tr *Trainer) retrieveNewAndOutstandingPredictions(ctx context.Context, pre
 	return potentiallyResolved, unresolved, unresolvedRecords, nil
 }
 
+// resolvedByCutoff returns whether the prediction's outcome is to be trained on by a model with the given cutoff.
+// The source doesn't say when predictions were judged, so outcomes of predictions not yet due by the cutoff
+// are left for a later model, as if they were judged after it.
+func resolvedByCutoff(p *predictions.PredictionSummary, cutoff time.Time) bool {
+	return p.Outcome != predictions.Unknown && !p.Deadline.After(cutoff)
+}
+
+// unresolvedSummary returns the prediction without its outcome, if it has one.
+func unresolvedSummary(p *predictions.PredictionSummary) *predictions.PredictionSummary {
+	if p.Outcome == predictions.Unknown {
+		return p
+	}
+	unresolved := *p
+	unresolved.Outcome = predictions.Unknown
+	return &unresolved
+}
+
+// responsesByCutoff returns the responses made by the cutoff.
+func responsesByCutoff(responses []*predictions.PredictionResponse, cutoff time.Time) []*predictions.PredictionResponse {
+	var filtered []*predictions.PredictionResponse
+	for _, r := range responses {
+		if !r.Time.After(cutoff) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
 func (tr *Trainer) updateLatestModel(ctx context.Context, oldModel, newModel int64) error {
 	return tr.PersistentStore.Transact(ctx, func(ctx context.Context) error {
-		status := new(trainerStatus)
-		if _, err := tr.PersistentStore.Get(ctx, "TrainerStatus", "status", status); err != nil {
+		status, err := tr.getStatus(ctx)
+		if err != nil {
 			return errors.Wrap(err, "")
 		}
 		if status.LatestModel != oldModel {
@@ -264,6 +507,19 @@ func (tr *Trainer) updateLatestModel(ctx context.Context, oldModel, newModel int
 	})
 }
 
+// CurrentModelVersion returns the name of the version most recently promoted to default,
+// or an empty string if none has been.
+func (tr *Trainer) CurrentModelVersion(ctx context.Context) (string, error) {
+	status, err := tr.getStatus(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "")
+	}
+	if status.LatestModel == 0 {
+		return "", nil
+	}
+	return "v" + strconv.FormatInt(status.LatestModel, 10), nil
+}
+
 func (tr *Trainer) generateSummaryRecords(summaries []*predictions.PredictionSummary) (records [][]string) {
 	for _, p := range summaries {
 		records = append(records, []string{
@@ -306,25 +562,30 @@ func (tr *Trainer) writeCsv(ctx context.Context, path string, records [][]string
 }
 
 func (tr *Trainer) newTrainJobSpec(oldModel, newModel int64) *ml.GoogleCloudMlV1__Job {
+	args := []string{
+		"--train-file",
+		"gs://" + tr.DataPath + "/" + strconv.FormatInt(newModel, 10) + "/",
+		"--num-epochs",
+		"1",
+	}
+	if oldModel != 0 {
+		args = append(args,
+			"--prev-model-dir",
+			"gs://"+tr.ModelPath+"/"+strconv.FormatInt(oldModel, 10)+"/model/")
+	}
+
 	return &ml.GoogleCloudMlV1__Job{
 		JobId: "predictor_" + strconv.FormatInt(newModel, 10),
 		TrainingInput: &ml.GoogleCloudMlV1__TrainingInput{
 			JobDir:         "gs://" + tr.ModelPath + "/" + strconv.FormatInt(newModel, 10) + "/",
 			PythonModule:   "trainer.train",
 			PythonVersion:  "3.7",
-			RuntimeVersion: "2.4",
-			Args: []string{
-				"--train-file",
-				"gs://" + tr.DataPath + "/" + strconv.FormatInt(newModel, 10) + "/",
-				"--num-epochs",
-				"1",
-				"--prev-model-dir",
-				"gs://" + tr.ModelPath + "/" + strconv.FormatInt(oldModel, 10) + "/model/",
-			},
+			RuntimeVersion: tr.MLEngine.runtimeVersion(),
+			Args:           args,
 			PackageUris: []string{
 				tr.TrainPackage,
 			},
-			Region: "us-east1",
+			Region: tr.MLEngine.region(),
 		},
 	}
 }
@@ -333,7 +594,7 @@ func (tr *Trainer) newTrainVersionSpec(model int64) *ml.GoogleCloudMlV1__Version
 	return &ml.GoogleCloudMlV1__Version{
 		Name:           "v" + strconv.FormatInt(model, 10),
 		DeploymentUri:  "gs://" + tr.ModelPath + "/" + strconv.FormatInt(model, 10) + "/saved_model/",
-		RuntimeVersion: "2.4",
+		RuntimeVersion: tr.MLEngine.runtimeVersion(),
 	}
 }
 
@@ -345,7 +606,7 @@ func (tr *Trainer) waitForTrainJob(jobID string, client *http.Client) error {
 	}
 
 	for {
-		jobCall := mlService.Projects.Jobs.Get("projects/moonbird-beshir/jobs/" + jobID)
+		jobCall := mlService.Projects.Jobs.Get(tr.MLEngine.projectName() + "/jobs/" + jobID)
 		job, err := jobCall.Do()
 		if err != nil {
 			return errors.Wrap(err, "")
@@ -359,6 +620,9 @@ func (tr *Trainer) waitForTrainJob(jobID string, client *http.Client) error {
 		if job.State == "SUCCEEDED" {
 			return nil
 		}
+		if tr.stopping() {
+			return errors.WithStack(ErrRetrainInterrupted)
+		}
 
 		tr.SleepFunc(500 * time.Millisecond)
 	}
@@ -372,7 +636,7 @@ func (tr *Trainer) waitForVersionReady(version string, client *http.Client) erro
 	}
 
 	for {
-		versionCall := mlService.Projects.Models.Versions.Get("projects/moonbird-beshir/models/Predictor/versions/" + version)
+		versionCall := mlService.Projects.Models.Versions.Get(tr.MLEngine.modelName() + "/versions/" + version)
 		version, err := versionCall.Do()
 		if err != nil {
 			return errors.Wrap(err, "")
@@ -383,6 +647,9 @@ func (tr *Trainer) waitForVersionReady(version string, client *http.Client) erro
 		if version.State == "READY" {
 			return nil
 		}
+		if tr.stopping() {
+			return errors.WithStack(ErrRetrainInterrupted)
+		}
 
 		tr.SleepFunc(500 * time.Millisecond)
 	}
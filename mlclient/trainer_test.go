@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/jbeshir/moonbird-auth-frontend/data"
-	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
 	testhelpers2 "github.com/jbeshir/moonbird-predictor-frontend/testhelpers"
 	"github.com/jbeshir/predictionbook-extractor/predictions"
 	"google.golang.org/api/ml/v1"
@@ -23,8 +23,17 @@ func TestTrainer_Retrain(t *testing.T) {
 	now := time.Unix(500, 0)
 	step := 0
 
-	ps := testhelpers.NewPersistentStore(t)
+	var savedRuns *trainingRunLog
+	ps := newTestTrainerStore(t)
 	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		if kind == trainingRunsKind {
+			if savedRuns == nil {
+				return nil, data.ErrNoSuchEntity
+			}
+			*v.(*trainingRunLog) = *savedRuns
+			return nil, nil
+		}
+
 		wantKind := "TrainerStatus"
 		if kind != wantKind {
 			t.Errorf("Expected retrieval to be of kind %s, was %s", wantKind, kind)
@@ -49,6 +58,11 @@ func TestTrainer_Retrain(t *testing.T) {
 		return nil, nil
 	}
 	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		if kind == trainingRunsKind {
+			savedRuns = v.(*trainingRunLog)
+			return nil
+		}
+
 		wantKind := "TrainerStatus"
 		if kind != wantKind {
 			t.Errorf("Expected retrieval to be of kind %s, was %s", wantKind, kind)
@@ -77,6 +91,11 @@ func TestTrainer_Retrain(t *testing.T) {
 		return nil
 	}
 	ps.TransactFunc = func(ctx context.Context, f func(ctx context.Context) error) error {
+		// Run report updates happen in their own transactions, outside of the latest model update.
+		if step != 14 {
+			return f(ctx)
+		}
+
 		wantStep := 14
 		if step != wantStep {
 			t.Errorf("Expected to be called at step %d, was called at step %d", wantStep, step)
@@ -94,6 +113,8 @@ func TestTrainer_Retrain(t *testing.T) {
 		return nil
 	}
 
+	handleTestLease(ps)
+
 	fs := newTestFileStore(t)
 	fs.LoadFunc = func(ctx context.Context, path string) (bytes []byte, e error) {
 		wantPath := "123/summarydata-unresolved.csv"
@@ -443,6 +464,39 @@ func TestTrainer_Retrain(t *testing.T) {
 	if step != wantStep {
 		t.Errorf("Expected to end on step %d, ended at step %d", wantStep, step)
 	}
+
+	if savedRuns == nil || len(savedRuns.Runs) != 1 {
+		t.Fatalf("Expected one training run report to be saved")
+	}
+	report := savedRuns.Runs[0]
+	if report.Model != 500 || report.BaseModel != 123 {
+		t.Errorf("Expected report for model 500 based on 123, was for model %d based on %d", report.Model, report.BaseModel)
+	}
+	if report.Outcome != data2.TrainingRunSucceeded {
+		t.Errorf("Expected report outcome %s, was %s", data2.TrainingRunSucceeded, report.Outcome)
+	}
+	if report.PromotedVersion != "v500" {
+		t.Errorf("Expected promoted version v500, was %s", report.PromotedVersion)
+	}
+	wantStages := []string{"retrieve-predictions", "retrieve-responses", "write-data", "train", "create-version", "promote"}
+	var stages []string
+	for _, s := range report.Stages {
+		stages = append(stages, s.Name)
+	}
+	if !reflect.DeepEqual(stages, wantStages) {
+		t.Errorf("Expected report stages %v, were %v", wantStages, stages)
+	}
+	if report.PotentiallyResolvedCount != 3 || report.ResolvedCount != 2 || report.UnresolvedCount != 2 {
+		t.Errorf("Unexpected report counts: %d potentially resolved, %d resolved, %d unresolved",
+			report.PotentiallyResolvedCount, report.ResolvedCount, report.UnresolvedCount)
+	}
+	if report.TrainCount != 2 || report.CvCount != 0 || report.TestCount != 0 || report.OutstandingCount != 3 {
+		t.Errorf("Unexpected report split sizes: %d train, %d cv, %d test, %d outstanding",
+			report.TrainCount, report.CvCount, report.TestCount, report.OutstandingCount)
+	}
+	if report.ResponseCount != 3 {
+		t.Errorf("Expected report response count 3, was %d", report.ResponseCount)
+	}
 }
 
 func TestTrainer_RetrieveNewAndOutstanding(t *testing.T) {
@@ -532,6 +586,77 @@ func TestTrainer_RetrieveNewAndOutstanding(t *testing.T) {
 	}
 }
 
+func TestTrainer_RetrieveNewAndOutstanding_Cutoff(t *testing.T) {
+	t.Parallel()
+
+	cutoff := time.Unix(500, 0)
+
+	fs := newTestFileStore(t)
+	fs.LoadFunc = func(ctx context.Context, path string) (bytes []byte, e error) {
+		return []byte("2,2,300,0.49,6,0,Person1,Deadline Due\n10,2,1000,0.96,2,0,Person3,Deadline Not Due"), nil
+	}
+
+	s := testhelpers2.NewPredictionSource(t)
+	s.AllPredictionsSinceFunc = func(context context.Context, since time.Time) (summaries []*predictions.PredictionSummary, e error) {
+		return []*predictions.PredictionSummary{
+			{
+				Id:       7,
+				Outcome:  predictions.Right,
+				Created:  time.Unix(200, 0),
+				Deadline: time.Unix(400, 0),
+			},
+			{
+				Id:       8,
+				Outcome:  predictions.Wrong,
+				Created:  time.Unix(200, 0),
+				Deadline: time.Unix(800, 0),
+			},
+			{
+				Id:       9,
+				Outcome:  predictions.Right,
+				Created:  time.Unix(600, 0),
+				Deadline: time.Unix(700, 0),
+			},
+		}, nil
+	}
+
+	tr := &Trainer{
+		FileStore:        fs,
+		PredictionSource: s,
+	}
+	potentiallyResolved, unresolved, unresolvedRecords, err := tr.retrieveNewAndOutstandingPredictions(context.Background(), 123, cutoff)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+
+	if len(potentiallyResolved) != 2 || potentiallyResolved[0].Id != 7 || potentiallyResolved[1].Id != 2 {
+		t.Errorf("Expected predictions 7 and 2, due by the cutoff, to be potentially resolved, got %v", potentiallyResolved)
+	}
+	if len(unresolved) != 1 || unresolved[0].Id != 8 {
+		t.Fatalf("Expected prediction 8, not due by the cutoff, to be unresolved, got %v", unresolved)
+	}
+	if unresolved[0].Outcome != predictions.Unknown {
+		t.Errorf("Expected prediction not due by the cutoff to have an unknown outcome, got %d", unresolved[0].Outcome)
+	}
+	if len(unresolvedRecords) != 1 || unresolvedRecords[0][0] != "10" {
+		t.Errorf("Expected existing prediction 10 to remain unresolved, got %v", unresolvedRecords)
+	}
+}
+
+func TestTrainer_ResponsesByCutoff(t *testing.T) {
+	t.Parallel()
+
+	responses := responsesByCutoff([]*predictions.PredictionResponse{
+		{Prediction: 1, Time: time.Unix(400, 0)},
+		{Prediction: 1, Time: time.Unix(500, 0)},
+		{Prediction: 1, Time: time.Unix(600, 0)},
+	}, time.Unix(500, 0))
+
+	if len(responses) != 2 || responses[1].Time != time.Unix(500, 0) {
+		t.Errorf("Expected the two responses made by the cutoff, got %v", responses)
+	}
+}
+
 func TestTrainer_RetrieveNewAndOutstanding_Deduplicate(t *testing.T) {
 	t.Parallel()
 
@@ -644,7 +769,7 @@ func TestTrainer_UpdateLatestModel(t *testing.T) {
 	t.Parallel()
 
 	step := 0
-	ps := testhelpers.NewPersistentStore(t)
+	ps := newTestTrainerStore(t)
 	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
 		wantKind := "TrainerStatus"
 		if kind != wantKind {
@@ -734,7 +859,7 @@ func TestTrainer_UpdateLatestModel_Conflict(t *testing.T) {
 	t.Parallel()
 
 	step := 0
-	ps := testhelpers.NewPersistentStore(t)
+	ps := newTestTrainerStore(t)
 	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
 		wantKind := "TrainerStatus"
 		if kind != wantKind {
@@ -1173,3 +1298,358 @@ func TestTrainer_WaitForVersion_Failed(t *testing.T) {
 		t.Errorf("Expected call count before returning %d, got %d", wantCallCount, callCount)
 	}
 }
+
+func TestTrainer_RetrainWithOptions_FullRebuildSkipPromotion(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestTrainerStore(t)
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		if kind == trainingRunsKind {
+			return nil, data.ErrNoSuchEntity
+		}
+		v.(*trainerStatus).LatestModel = 123
+		return nil, nil
+	}
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		if kind != trainingRunsKind {
+			t.Errorf("Expected only training run reports to be written, wrote %s", kind)
+		}
+		return nil
+	}
+	ps.TransactFunc = func(ctx context.Context, f func(ctx context.Context) error) error {
+		return f(ctx)
+	}
+
+	handleTestLease(ps)
+
+	var savedPaths []string
+	fs := newTestFileStore(t)
+	fs.SaveFunc = func(ctx context.Context, path string, content []byte) error {
+		savedPaths = append(savedPaths, path)
+		return nil
+	}
+
+	s := testhelpers2.NewPredictionSource(t)
+	s.AllPredictionsSinceFunc = func(ctx context.Context, since time.Time) ([]*predictions.PredictionSummary, error) {
+		if since != time.Unix(0, 0) {
+			t.Errorf("Expected full rebuild to retrieve all predictions, retrieved since %s", since)
+		}
+		return nil, nil
+	}
+	s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+		return nil, nil, nil
+	}
+
+	var requestPaths []string
+	client := new(http.Client)
+	client.Transport = &testRoundTripper{
+		RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+			requestPaths = append(requestPaths, req.Method+" "+req.URL.Path)
+
+			body := `{}`
+			if req.URL.Path == "/v1/projects/moonbird-beshir/jobs" {
+				job := new(ml.GoogleCloudMlV1__Job)
+				_ = json.NewDecoder(req.Body).Decode(job)
+				for _, arg := range job.TrainingInput.Args {
+					if arg == "--prev-model-dir" {
+						t.Error("Expected full rebuild job to have no previous model")
+					}
+				}
+			} else if req.Method == "GET" && strings.Contains(req.URL.Path, "/jobs/") {
+				body = `{"State":"SUCCEEDED"}`
+			} else if req.Method == "GET" {
+				body = `{"State":"READY"}`
+			}
+
+			resp := new(http.Response)
+			resp.StatusCode = 200
+			resp.ContentLength = -1
+			resp.Body = ioutil.NopCloser(strings.NewReader(body))
+			return resp, nil
+		},
+	}
+	cm := newTestHttpClientMaker(t)
+	cm.MakeClientFunc = func(ctx context.Context) (*http.Client, error) {
+		return client, nil
+	}
+
+	tr := &Trainer{
+		PersistentStore:  ps,
+		FileStore:        fs,
+		PredictionSource: s,
+		HttpClientMaker:  cm,
+	}
+	err := tr.RetrainWithOptions(context.Background(), data2.RetrainOptions{
+		Cutoff:        time.Unix(500, 0),
+		BaseModel:     100,
+		SkipPromotion: true,
+		FullRebuild:   true,
+	})
+	if err != nil {
+		t.Errorf("Expected err to be nil, was %s", err)
+	}
+
+	wantPaths := []string{
+		"500/responsedata.csv",
+		"500/summarydata-unresolved.csv",
+		"500/summarydata-train.csv",
+		"500/summarydata-cv.csv",
+		"500/summarydata-test.csv",
+	}
+	if !reflect.DeepEqual(savedPaths, wantPaths) {
+		t.Errorf("Expected saved files %v, got %v", wantPaths, savedPaths)
+	}
+
+	for _, p := range requestPaths {
+		if strings.HasSuffix(p, ":setDefault") {
+			t.Error("Expected new version not to be set as default")
+		}
+	}
+	if len(requestPaths) != 4 {
+		t.Errorf("Expected 4 ML requests, got %d: %v", len(requestPaths), requestPaths)
+	}
+}
+
+func TestTrainer_RetrainWithOptions_BaseAfterCutoff(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestJsonStore(t)
+	_ = ps.Set(context.Background(), "TrainerStatus", "status", nil, &trainerStatus{LatestModel: 123})
+
+	cm := newTestHttpClientMaker(t)
+	cm.MakeClientFunc = func(ctx context.Context) (*http.Client, error) {
+		return new(http.Client), nil
+	}
+
+	tr := &Trainer{
+		PersistentStore: ps,
+		HttpClientMaker: cm,
+	}
+	err := tr.RetrainWithOptions(context.Background(), data2.RetrainOptions{
+		Cutoff:    time.Unix(500, 0),
+		BaseModel: 600,
+	})
+	if err == nil {
+		t.Error("Expected an error retraining from a base model after the cutoff, got nil")
+	}
+
+	runs, _ := tr.RecentRuns(context.Background())
+	if len(runs) != 1 || runs[0].Outcome != data2.TrainingRunFailed {
+		t.Errorf("Expected a failed run report, got %+v", runs)
+	}
+}
+
+func TestTrainer_RetrainWithOptions_CutoffBeforeLatest(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestJsonStore(t)
+	_ = ps.Set(context.Background(), "TrainerStatus", "status", nil, &trainerStatus{LatestModel: 600})
+
+	cm := newTestHttpClientMaker(t)
+	cm.MakeClientFunc = func(ctx context.Context) (*http.Client, error) {
+		return new(http.Client), nil
+	}
+
+	tr := &Trainer{
+		PersistentStore: ps,
+		HttpClientMaker: cm,
+	}
+	err := tr.RetrainWithOptions(context.Background(), data2.RetrainOptions{
+		Cutoff:    time.Unix(500, 0),
+		BaseModel: 100,
+	})
+	if err == nil {
+		t.Error("Expected an error promoting a model from before the latest model, got nil")
+	}
+
+	runs, _ := tr.RecentRuns(context.Background())
+	if len(runs) != 1 || runs[0].Model != 500 || runs[0].Outcome != data2.TrainingRunFailed || runs[0].Err == "" {
+		t.Errorf("Expected a failed run report for model 500, got %+v", runs)
+	}
+}
+
+func TestTrainer_JobSpec_NoBaseModel(t *testing.T) {
+	t.Parallel()
+
+	tr := &Trainer{
+		ModelPath: "moonbird-models/predictor",
+		DataPath:  "moonbird-data/predictor",
+	}
+	jobSpec := tr.newTrainJobSpec(0, 500)
+
+	wantArgs := []string{
+		"--train-file",
+		"gs://moonbird-data/predictor/500/",
+		"--num-epochs",
+		"1",
+	}
+	if !reflect.DeepEqual(jobSpec.TrainingInput.Args, wantArgs) {
+		t.Errorf("Args attached to job did not match expected args")
+	}
+}
+
+func TestTrainer_JobSpec_MLEngine(t *testing.T) {
+	t.Parallel()
+
+	tr := &Trainer{
+		ModelPath: "moonbird-models/predictor",
+		DataPath:  "moonbird-data/predictor",
+		MLEngine: MLEngine{
+			Project:        "staging",
+			Region:         "europe-west1",
+			RuntimeVersion: "2.8",
+		},
+	}
+
+	jobSpec := tr.newTrainJobSpec(0, 500)
+	if jobSpec.TrainingInput.Region != "europe-west1" || jobSpec.TrainingInput.RuntimeVersion != "2.8" {
+		t.Errorf("Expected job in the configured region and runtime, got %s and %s",
+			jobSpec.TrainingInput.Region, jobSpec.TrainingInput.RuntimeVersion)
+	}
+	versionSpec := tr.newTrainVersionSpec(500)
+	if versionSpec.RuntimeVersion != "2.8" {
+		t.Errorf("Expected version with the configured runtime, got %s", versionSpec.RuntimeVersion)
+	}
+	if name := tr.MLEngine.modelName(); name != "projects/staging/models/Predictor" {
+		t.Errorf("Expected model in the configured project, got %s", name)
+	}
+}
+
+func TestTrainer_WriteTrainingData_DuplicateResponses(t *testing.T) {
+	t.Parallel()
+
+	saved := make(map[string]string)
+	fs := newTestFileStore(t)
+	fs.SaveFunc = func(ctx context.Context, path string, content []byte) error {
+		saved[path] = string(content)
+		return nil
+	}
+
+	tr := &Trainer{
+		FileStore:          fs,
+		DuplicateResponses: data2.AverageResponses,
+	}
+	run := &trainingRun{
+		report:  new(data2.TrainingRunReport),
+		nowFunc: time.Now,
+	}
+
+	resolved := []*predictions.PredictionSummary{{Id: 7, Outcome: predictions.Right}}
+	responses := []*predictions.PredictionResponse{
+		{Prediction: 7, User: "alice", Time: time.Unix(100, 0), Confidence: 0.2},
+		{Prediction: 7, User: "bob", Time: time.Unix(150, 0), Confidence: 0.9},
+		{Prediction: 7, User: "alice", Time: time.Unix(200, 0), Confidence: 0.4},
+	}
+	err := tr.writeTrainingData(context.Background(), run, "500", resolved, nil, nil, responses)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+
+	expected := "7,150,0.9,bob,\n7,200,0.30000000000000004,alice,\n"
+	if saved["500/responsedata.csv"] != expected {
+		t.Errorf("Expected response data %q, got %q", expected, saved["500/responsedata.csv"])
+	}
+	if run.report.ResponseCount != 2 {
+		t.Errorf("Expected response count of 2, got %d", run.report.ResponseCount)
+	}
+}
+
+func TestTrainer_CurrentModelVersion(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestTrainerStore(t)
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		if kind != "TrainerStatus" || key != "status" {
+			t.Errorf("Unexpected retrieval of %s/%s", kind, key)
+		}
+		v.(*trainerStatus).LatestModel = 500
+		return nil, nil
+	}
+
+	tr := &Trainer{PersistentStore: ps}
+	version, err := tr.CurrentModelVersion(context.Background())
+	if err != nil {
+		t.Errorf("Expected err to be nil, was %s", err)
+	}
+	if version != "v500" {
+		t.Errorf("Expected version v500, got %s", version)
+	}
+}
+
+func TestTrainer_CurrentModelVersion_NoStatus(t *testing.T) {
+	t.Parallel()
+
+	tr := &Trainer{PersistentStore: newTestJsonStore(t)}
+	version, err := tr.CurrentModelVersion(context.Background())
+	if err != nil {
+		t.Errorf("Expected err to be nil, was %s", err)
+	}
+	if version != "" {
+		t.Errorf("Expected no version, got %s", version)
+	}
+}
+
+func TestTrainer_RetrainWithOptions_NoStatus(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestJsonStore(t)
+
+	fs := newTestFileStore(t)
+	fs.SaveFunc = func(ctx context.Context, path string, content []byte) error {
+		return nil
+	}
+
+	s := testhelpers2.NewPredictionSource(t)
+	s.AllPredictionsSinceFunc = func(ctx context.Context, since time.Time) ([]*predictions.PredictionSummary, error) {
+		if since != time.Unix(0, 0) {
+			t.Errorf("Expected first retrain to retrieve all predictions, retrieved since %s", since)
+		}
+		return nil, nil
+	}
+	s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+		return nil, nil, nil
+	}
+
+	client := new(http.Client)
+	client.Transport = &testRoundTripper{
+		RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+			body := `{}`
+			if req.Method == "GET" && strings.Contains(req.URL.Path, "/jobs/") {
+				body = `{"State":"SUCCEEDED"}`
+			} else if req.Method == "GET" {
+				body = `{"State":"READY"}`
+			}
+
+			resp := new(http.Response)
+			resp.StatusCode = 200
+			resp.ContentLength = -1
+			resp.Body = ioutil.NopCloser(strings.NewReader(body))
+			return resp, nil
+		},
+	}
+	cm := newTestHttpClientMaker(t)
+	cm.MakeClientFunc = func(ctx context.Context) (*http.Client, error) {
+		return client, nil
+	}
+
+	tr := &Trainer{
+		PersistentStore:  ps,
+		FileStore:        fs,
+		PredictionSource: s,
+		HttpClientMaker:  cm,
+	}
+	err := tr.RetrainWithOptions(context.Background(), data2.RetrainOptions{
+		Cutoff: time.Unix(500, 0),
+	})
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+
+	version, err := tr.CurrentModelVersion(context.Background())
+	if err != nil {
+		t.Errorf("Expected err to be nil, was %s", err)
+	}
+	if version != "v500" {
+		t.Errorf("Expected first model to be promoted as v500, got %s", version)
+	}
+}
@@ -0,0 +1,148 @@
+package mlclient
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/moonbird-predictor-frontend/tracing"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const trainingRunsKind = "TrainingRuns"
+const trainingRunsKey = "recent"
+
+// Only the most recent runs are kept; older reports are dropped when new runs are recorded.
+const maxTrainingRuns = 50
+
+type trainingRunLog struct {
+	Runs []data2.TrainingRunReport
+}
+
+type trainingRun struct {
+	report  *data2.TrainingRunReport
+	nowFunc func() time.Time
+	metrics RetrainMetrics
+	span    *tracing.Span
+}
+
+// beginStage records the start of a stage, and returns a context for its work, traced as a span.
+func (r *trainingRun) beginStage(ctx context.Context, name string) context.Context {
+	r.report.Stages = append(r.report.Stages, data2.TrainingRunStage{
+		Name:    name,
+		Started: r.nowFunc(),
+	})
+
+	ctx, r.span = tracing.Start(ctx, "Trainer.stage", tracing.String("stage", name),
+		tracing.Int("model", r.report.Model))
+	return ctx
+}
+
+// endStage records the end of the current stage, and passes through the error it ended with.
+func (r *trainingRun) endStage(err error) error {
+	stage := &r.report.Stages[len(r.report.Stages)-1]
+	stage.Finished = r.nowFunc()
+	if err != nil {
+		stage.Err = err.Error()
+	}
+	if r.metrics != nil {
+		r.metrics.RetrainStageFinished(stage.Name, stage.Duration(), err)
+	}
+	r.span.SetError(err)
+	r.span.End()
+	return err
+}
+
+func (tr *Trainer) RecentRuns(ctx context.Context) ([]data2.TrainingRunReport, error) {
+	runLog := new(trainingRunLog)
+	_, err := tr.PersistentStore.Get(ctx, trainingRunsKind, trainingRunsKey, runLog)
+	if err != nil {
+		if errors.Cause(err) == data.ErrNoSuchEntity {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "")
+	}
+	return runLog.Runs, nil
+}
+
+func (tr *Trainer) startRun(ctx context.Context, baseModel, newModel int64) *trainingRun {
+	run := &trainingRun{
+		report: &data2.TrainingRunReport{
+			Model:     newModel,
+			BaseModel: baseModel,
+			Outcome:   data2.TrainingRunRunning,
+		},
+		nowFunc: tr.now,
+		metrics: tr.Metrics,
+	}
+	run.report.Started = run.nowFunc()
+
+	tr.saveRunReport(ctx, run.report)
+	return run
+}
+
+// resumeRun continues recording a run from its checkpointed report.
+func (tr *Trainer) resumeRun(ctx context.Context, report *data2.TrainingRunReport) *trainingRun {
+	run := &trainingRun{
+		report:  report,
+		nowFunc: tr.now,
+		metrics: tr.Metrics,
+	}
+	run.report.Outcome = data2.TrainingRunRunning
+	run.report.Finished = time.Time{}
+	run.report.Err = ""
+
+	tr.saveRunReport(ctx, run.report)
+	return run
+}
+
+func (tr *Trainer) finishRun(ctx context.Context, run *trainingRun, err error) {
+	run.report.Finished = run.nowFunc()
+	if err != nil {
+		run.report.Outcome = data2.TrainingRunFailed
+		run.report.Err = err.Error()
+	} else {
+		run.report.Outcome = data2.TrainingRunSucceeded
+	}
+	if run.metrics != nil {
+		run.metrics.RetrainFinished(run.report.Outcome, run.report.Duration())
+	}
+
+	tr.saveRunReport(ctx, run.report)
+}
+
+// saveRunReport records the report in the run log, replacing any earlier copy of the same run.
+// We don't want failure to write a report to fail the run itself, so errors are only logged.
+func (tr *Trainer) saveRunReport(ctx context.Context, report *data2.TrainingRunReport) {
+	err := tr.PersistentStore.Transact(ctx, func(ctx context.Context) error {
+		runLog := new(trainingRunLog)
+		_, err := tr.PersistentStore.Get(ctx, trainingRunsKind, trainingRunsKey, runLog)
+		if err != nil && errors.Cause(err) != data.ErrNoSuchEntity {
+			return errors.Wrap(err, "")
+		}
+
+		runs := []data2.TrainingRunReport{*report}
+		for _, r := range runLog.Runs {
+			if r.Model != report.Model {
+				runs = append(runs, r)
+			}
+		}
+		if len(runs) > maxTrainingRuns {
+			runs = runs[:maxTrainingRuns]
+		}
+		runLog.Runs = runs
+
+		return errors.Wrap(tr.PersistentStore.Set(ctx, trainingRunsKind, trainingRunsKey, nil, runLog), "")
+	})
+	if err != nil {
+		ctxlogrus.Get(ctx).Warnf("Unable to save training run report: %s", err)
+	}
+}
+
+func (tr *Trainer) now() time.Time {
+	if tr.NowFunc != nil {
+		return tr.NowFunc()
+	}
+	return time.Now()
+}
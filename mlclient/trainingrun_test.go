@@ -0,0 +1,174 @@
+package mlclient
+
+import (
+	"context"
+	"errors"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTrainer_RecentRuns(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestTrainerStore(t)
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		if kind != trainingRunsKind || key != trainingRunsKey {
+			t.Errorf("Expected retrieval of %s/%s, was %s/%s", trainingRunsKind, trainingRunsKey, kind, key)
+		}
+		v.(*trainingRunLog).Runs = []data2.TrainingRunReport{{Model: 5}, {Model: 3}}
+		return nil, nil
+	}
+
+	tr := &Trainer{PersistentStore: ps}
+	runs, err := tr.RecentRuns(context.Background())
+	if err != nil {
+		t.Errorf("Expected err to be nil, was %s", err)
+	}
+	if len(runs) != 2 || runs[0].Model != 5 || runs[1].Model != 3 {
+		t.Errorf("Unexpected runs returned: %v", runs)
+	}
+}
+
+func TestTrainer_RecentRuns_None(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestTrainerStore(t)
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		return nil, data.ErrNoSuchEntity
+	}
+
+	tr := &Trainer{PersistentStore: ps}
+	runs, err := tr.RecentRuns(context.Background())
+	if err != nil {
+		t.Errorf("Expected err to be nil, was %s", err)
+	}
+	if len(runs) != 0 {
+		t.Errorf("Expected no runs, got %d", len(runs))
+	}
+}
+
+func TestTrainer_RecentRuns_Error(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestTrainerStore(t)
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		return nil, errors.New("bluh")
+	}
+
+	tr := &Trainer{PersistentStore: ps}
+	_, err := tr.RecentRuns(context.Background())
+	if err == nil {
+		t.Error("Expected an error, got nil")
+	}
+}
+
+func TestTrainer_FinishRun(t *testing.T) {
+	t.Parallel()
+
+	existing := make([]data2.TrainingRunReport, maxTrainingRuns)
+	for i := range existing {
+		existing[i].Model = int64(maxTrainingRuns - i)
+	}
+	existing[0].Model = 500
+	existing[0].Outcome = data2.TrainingRunRunning
+
+	var saved *trainingRunLog
+	ps := newTestTrainerStore(t)
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		v.(*trainingRunLog).Runs = existing
+		return nil, nil
+	}
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		if kind != trainingRunsKind || key != trainingRunsKey {
+			t.Errorf("Expected write of %s/%s, was %s/%s", trainingRunsKind, trainingRunsKey, kind, key)
+		}
+		saved = v.(*trainingRunLog)
+		return nil
+	}
+	ps.TransactFunc = func(ctx context.Context, f func(ctx context.Context) error) error {
+		return f(ctx)
+	}
+
+	tr := &Trainer{
+		PersistentStore: ps,
+		NowFunc: func() time.Time {
+			return time.Unix(600, 0)
+		},
+	}
+	run := &trainingRun{
+		report: &data2.TrainingRunReport{
+			Model:   500,
+			Started: time.Unix(500, 0),
+			Outcome: data2.TrainingRunRunning,
+		},
+		nowFunc: tr.now,
+	}
+	run.beginStage(context.Background(), "train")
+	_ = run.endStage(errors.New("job failed"))
+	tr.finishRun(context.Background(), run, errors.New("job failed"))
+
+	if saved == nil {
+		t.Fatal("Expected run log to be saved")
+	}
+	if len(saved.Runs) != maxTrainingRuns {
+		t.Errorf("Expected run log to be capped at %d runs, was %d", maxTrainingRuns, len(saved.Runs))
+	}
+	if saved.Runs[0].Model != 500 || saved.Runs[1].Model == 500 {
+		t.Error("Expected finished run to replace its earlier report at the front of the log")
+	}
+	if saved.Runs[0].Outcome != data2.TrainingRunFailed {
+		t.Errorf("Expected outcome %s, was %s", data2.TrainingRunFailed, saved.Runs[0].Outcome)
+	}
+	if saved.Runs[0].Err != "job failed" || saved.Runs[0].Stages[0].Err != "job failed" {
+		t.Error("Expected run and stage errors to be recorded")
+	}
+	if saved.Runs[0].Duration() != 100*time.Second {
+		t.Errorf("Expected run duration of 100s, was %s", saved.Runs[0].Duration())
+	}
+}
+
+func TestTrainer_FinishRun_Metrics(t *testing.T) {
+	t.Parallel()
+
+	ps := newTestTrainerStore(t)
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		return nil, data.ErrNoSuchEntity
+	}
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		return nil
+	}
+	ps.TransactFunc = func(ctx context.Context, f func(ctx context.Context) error) error {
+		return f(ctx)
+	}
+
+	now := time.Unix(500, 0)
+	metrics := &testRetrainMetrics{}
+	tr := &Trainer{
+		PersistentStore: ps,
+		NowFunc: func() time.Time {
+			return now
+		},
+		Metrics: metrics,
+	}
+	run := tr.startRun(context.Background(), 400, 500)
+	run.beginStage(context.Background(), "retrieve-predictions")
+	now = now.Add(10 * time.Second)
+	_ = run.endStage(nil)
+	run.beginStage(context.Background(), "train")
+	now = now.Add(50 * time.Second)
+	_ = run.endStage(errors.New("job failed"))
+	tr.finishRun(context.Background(), run, errors.New("job failed"))
+
+	if !reflect.DeepEqual(metrics.stages, []string{"retrieve-predictions", "train"}) {
+		t.Errorf("Expected both stages to be recorded, got %v", metrics.stages)
+	}
+	if len(metrics.stageErrs) != 2 || metrics.stageErrs[0] != nil || metrics.stageErrs[1] == nil {
+		t.Errorf("Expected only the second stage to fail, got %v", metrics.stageErrs)
+	}
+	if metrics.finishRuns != 1 || metrics.outcome != data2.TrainingRunFailed || metrics.duration != time.Minute {
+		t.Errorf("Expected one failed run of 1m, got %d runs, outcome %s, duration %s", metrics.finishRuns, metrics.outcome, metrics.duration)
+	}
+}
@@ -2,8 +2,13 @@ package mlclient
 
 import (
 	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
 	"net/http"
+	"sync"
 	"testing"
+	"time"
 )
 
 type testFileStore struct {
@@ -56,3 +61,146 @@ type testRoundTripper struct {
 func (rt *testRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
 	return rt.RoundTripFunc(r)
 }
+
+type testTrainerStore struct {
+	*testhelpers.PersistentStore
+	DeleteFunc func(ctx context.Context, kind, key string) error
+}
+
+func newTestTrainerStore(t *testing.T) *testTrainerStore {
+	return &testTrainerStore{
+		PersistentStore: testhelpers.NewPersistentStore(t),
+		DeleteFunc: func(ctx context.Context, kind, key string) error {
+			t.Error("Delete should not be called")
+			return nil
+		},
+	}
+}
+
+func (ps *testTrainerStore) Delete(ctx context.Context, kind, key string) error {
+	return ps.DeleteFunc(ctx, kind, key)
+}
+
+// handleTestLease wraps the store's Get and Set functions to keep the trainer lease in memory,
+// leaving other kinds to the wrapped functions. It must be called after they are set.
+func handleTestLease(ps *testTrainerStore) {
+	var lease *trainerLease
+	var mutex sync.Mutex
+
+	getFunc := ps.GetFunc
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		if kind != trainerLeaseKind {
+			return getFunc(ctx, kind, key, v)
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if lease == nil {
+			return nil, data.ErrNoSuchEntity
+		}
+		*v.(*trainerLease) = *lease
+		return nil, nil
+	}
+
+	setFunc := ps.SetFunc
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		if kind != trainerLeaseKind {
+			return setFunc(ctx, kind, key, properties, v)
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		lease = new(trainerLease)
+		*lease = *v.(*trainerLease)
+		return nil
+	}
+}
+
+type testPredictor struct {
+	PredictFunc func(ctx context.Context, predictions []float64) (float64, error)
+}
+
+func newTestPredictor(t *testing.T) *testPredictor {
+	return &testPredictor{
+		PredictFunc: func(ctx context.Context, predictions []float64) (float64, error) {
+			t.Error("Predict should not be called")
+			return 0, nil
+		},
+	}
+}
+
+func (p *testPredictor) Predict(ctx context.Context, predictions []float64) (float64, error) {
+	return p.PredictFunc(ctx, predictions)
+}
+
+type testShadowRecorder struct {
+	RecordFunc func(ctx context.Context, c data2.ShadowComparison)
+}
+
+func newTestShadowRecorder(t *testing.T) *testShadowRecorder {
+	return &testShadowRecorder{
+		RecordFunc: func(ctx context.Context, c data2.ShadowComparison) {
+			t.Error("Record should not be called")
+		},
+	}
+}
+
+func (sr *testShadowRecorder) Record(ctx context.Context, c data2.ShadowComparison) {
+	sr.RecordFunc(ctx, c)
+}
+
+type testPredictionMetrics struct {
+	mutex       sync.Mutex
+	outcomes    []PredictionOutcome
+	assignments []int
+	calls       int
+}
+
+func (m *testPredictionMetrics) PredictionMade(outcome PredictionOutcome, assignments int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.outcomes = append(m.outcomes, outcome)
+	m.assignments = append(m.assignments, assignments)
+}
+
+func (m *testPredictionMetrics) BackendCalled(latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.calls++
+}
+
+type testRetrainMetrics struct {
+	stages     []string
+	stageErrs  []error
+	outcome    data2.TrainingRunOutcome
+	duration   time.Duration
+	finishRuns int
+}
+
+func (m *testRetrainMetrics) RetrainStageFinished(stage string, duration time.Duration, err error) {
+	m.stages = append(m.stages, stage)
+	m.stageErrs = append(m.stageErrs, err)
+}
+
+func (m *testRetrainMetrics) RetrainFinished(outcome data2.TrainingRunOutcome, duration time.Duration) {
+	m.outcome = outcome
+	m.duration = duration
+	m.finishRuns++
+}
+
+// testBackgroundRunner runs work in goroutines, which Wait waits for.
+type testBackgroundRunner struct {
+	wg sync.WaitGroup
+}
+
+func (br *testBackgroundRunner) RunInBackground(ctx context.Context, f func(ctx context.Context)) {
+	br.wg.Add(1)
+	go func() {
+		defer br.wg.Done()
+		f(ctx)
+	}()
+}
+
+func (br *testBackgroundRunner) Wait() {
+	br.wg.Wait()
+}
@@ -0,0 +1,51 @@
+package mlclient
+
+import (
+	"context"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"google.golang.org/api/ml/v1"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ListVersions returns the deployed versions of the model, most recently created first.
+func (tr *Trainer) ListVersions(ctx context.Context) ([]data2.ModelVersion, error) {
+	client, err := tr.HttpClientMaker.MakeClient(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	mlService, err := ml.New(client)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	var versions []data2.ModelVersion
+	err = mlService.Projects.Models.Versions.List(tr.MLEngine.modelName()).Pages(ctx, func(resp *ml.GoogleCloudMlV1__ListVersionsResponse) error {
+		for _, v := range resp.Versions {
+			version := data2.ModelVersion{
+				Name:    v.Name[strings.LastIndex(v.Name, "/")+1:],
+				State:   v.State,
+				Default: v.IsDefault,
+			}
+			if v.CreateTime != "" {
+				version.Created, err = time.Parse(time.RFC3339, v.CreateTime)
+				if err != nil {
+					return errors.Wrap(err, "")
+				}
+			}
+			versions = append(versions, version)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Created.After(versions[j].Created)
+	})
+	return versions, nil
+}
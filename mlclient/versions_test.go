@@ -0,0 +1,65 @@
+package mlclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrainer_ListVersions(t *testing.T) {
+	t.Parallel()
+
+	var pageTokens []string
+	client := &http.Client{
+		Transport: &testRoundTripper{
+			RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+				wantPath := "/v1/projects/moonbird-beshir/models/Predictor/versions"
+				if req.URL.Path != wantPath {
+					t.Errorf("Wrong URL path; expected %s, got %s", wantPath, req.URL.Path)
+				}
+
+				pageToken := req.URL.Query().Get("pageToken")
+				pageTokens = append(pageTokens, pageToken)
+
+				body := `{"versions":[{"name":"projects/moonbird-beshir/models/Predictor/versions/v100","state":"READY","createTime":"2020-01-01T00:00:00Z"}],"nextPageToken":"next"}`
+				if pageToken == "next" {
+					body = `{"versions":[{"name":"projects/moonbird-beshir/models/Predictor/versions/v200","state":"READY","isDefault":true,"createTime":"2020-02-01T00:00:00Z"}]}`
+				}
+
+				resp := new(http.Response)
+				resp.StatusCode = 200
+				resp.ContentLength = -1
+				resp.Body = ioutil.NopCloser(strings.NewReader(body))
+				return resp, nil
+			},
+		},
+	}
+	cm := newTestHttpClientMaker(t)
+	cm.MakeClientFunc = func(ctx context.Context) (*http.Client, error) {
+		return client, nil
+	}
+
+	tr := &Trainer{
+		HttpClientMaker: cm,
+	}
+	versions, err := tr.ListVersions(context.Background())
+	if err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+
+	if len(pageTokens) != 2 {
+		t.Errorf("Expected 2 pages to be requested, got %v", pageTokens)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("Expected 2 versions, got %d", len(versions))
+	}
+	if versions[0].Name != "v200" || !versions[0].Default || !versions[0].Created.Equal(time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected newest default version v200 first, got %+v", versions[0])
+	}
+	if versions[1].Name != "v100" || versions[1].Default || versions[1].State != "READY" {
+		t.Errorf("Expected version v100 second, got %+v", versions[1])
+	}
+}
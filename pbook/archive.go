@@ -0,0 +1,81 @@
+package pbook
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+)
+
+const storeSnapshotKind = "ExampleSnapshot"
+const storeSnapshotIndexKind = "ExampleSnapshotIndex"
+const storeSnapshotIndexKey = "index"
+
+// Archive keeps a dated snapshot of each day's example list.
+// Snapshots are keyed by date, so a second snapshot on the same day replaces the first.
+type Archive struct {
+	PersistentStore TransactionalStore
+}
+
+type snapshotIndex struct {
+	Snapshots []data2.ExampleSnapshotInfo
+}
+
+func (a *Archive) SaveSnapshot(ctx context.Context, snapshot *data2.ExampleSnapshot) error {
+	return a.PersistentStore.Transact(ctx, func(ctx context.Context) error {
+		err := a.PersistentStore.Set(ctx, storeSnapshotKind, snapshot.Date, nil, snapshot)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+
+		index, err := a.getIndex(ctx)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+
+		snapshots := []data2.ExampleSnapshotInfo{{
+			Date:         snapshot.Date,
+			Taken:        snapshot.Taken,
+			ModelVersion: snapshot.ModelVersion,
+			ExampleCount: len(snapshot.Examples),
+		}}
+		for _, s := range index.Snapshots {
+			if s.Date != snapshot.Date {
+				snapshots = append(snapshots, s)
+			}
+		}
+		index.Snapshots = snapshots
+
+		return errors.Wrap(a.PersistentStore.Set(ctx, storeSnapshotIndexKind, storeSnapshotIndexKey, nil, index), "")
+	})
+}
+
+// ListSnapshots returns the archived snapshots, most recently taken first.
+func (a *Archive) ListSnapshots(ctx context.Context) ([]data2.ExampleSnapshotInfo, error) {
+	index, err := a.getIndex(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return index.Snapshots, nil
+}
+
+func (a *Archive) GetSnapshot(ctx context.Context, date string) (*data2.ExampleSnapshot, error) {
+	snapshot := new(data2.ExampleSnapshot)
+	_, err := a.PersistentStore.Get(ctx, storeSnapshotKind, date, snapshot)
+	if err != nil {
+		if errors.Cause(err) == data.ErrNoSuchEntity {
+			return nil, errors.WithStack(data2.ErrNoSuchSnapshot)
+		}
+		return nil, errors.Wrap(err, "")
+	}
+	return snapshot, nil
+}
+
+func (a *Archive) getIndex(ctx context.Context) (*snapshotIndex, error) {
+	index := new(snapshotIndex)
+	_, err := a.PersistentStore.Get(ctx, storeSnapshotIndexKind, storeSnapshotIndexKey, index)
+	if err != nil && errors.Cause(err) != data.ErrNoSuchEntity {
+		return nil, errors.Wrap(err, "")
+	}
+	return index, nil
+}
@@ -0,0 +1,124 @@
+package pbook
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"github.com/pkg/errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestArchiveStore(t *testing.T) *testhelpers.PersistentStore {
+	snapshots := make(map[string]data2.ExampleSnapshot)
+	var index *snapshotIndex
+
+	ps := testhelpers.NewPersistentStore(t)
+	ps.TransactFunc = func(ctx context.Context, f func(ctx context.Context) error) error {
+		return f(ctx)
+	}
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		switch kind {
+		case storeSnapshotKind:
+			snapshot, ok := snapshots[key]
+			if !ok {
+				return nil, data.ErrNoSuchEntity
+			}
+			*v.(*data2.ExampleSnapshot) = snapshot
+		case storeSnapshotIndexKind:
+			if index == nil {
+				return nil, data.ErrNoSuchEntity
+			}
+			*v.(*snapshotIndex) = *index
+		default:
+			t.Errorf("Unexpected retrieval of kind %s", kind)
+		}
+		return nil, nil
+	}
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		switch kind {
+		case storeSnapshotKind:
+			snapshots[key] = *v.(*data2.ExampleSnapshot)
+		case storeSnapshotIndexKind:
+			index = v.(*snapshotIndex)
+		default:
+			t.Errorf("Unexpected write of kind %s", kind)
+		}
+		return nil
+	}
+	return ps
+}
+
+func TestArchive_SaveSnapshot(t *testing.T) {
+	t.Parallel()
+
+	a := &Archive{PersistentStore: newTestArchiveStore(t)}
+	c := context.Background()
+
+	snapshots, err := a.ListSnapshots(c)
+	if err != nil || len(snapshots) != 0 {
+		t.Errorf("Expected no snapshots and no error before saving, got %v, %v", snapshots, err)
+	}
+
+	first := &data2.ExampleSnapshot{
+		Date:         "2019-01-01",
+		Taken:        time.Unix(1000, 0),
+		ModelVersion: "v500",
+		Examples: []data2.ExampleSnapshotEntry{
+			{ExamplePrediction: data2.ExamplePrediction{PredictionSummary: &predictions.PredictionSummary{Id: 7}}, Result: 0.3},
+		},
+	}
+	second := &data2.ExampleSnapshot{Date: "2019-01-02", ModelVersion: "v500"}
+	secondRetaken := &data2.ExampleSnapshot{Date: "2019-01-02", ModelVersion: "v600"}
+	for _, s := range []*data2.ExampleSnapshot{first, second, secondRetaken} {
+		if err := a.SaveSnapshot(c, s); err != nil {
+			t.Fatalf("Expected err to be nil, was %s", err)
+		}
+	}
+
+	snapshots, err = a.ListSnapshots(c)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	expected := []data2.ExampleSnapshotInfo{
+		{Date: "2019-01-02", ModelVersion: "v600"},
+		{Date: "2019-01-01", Taken: time.Unix(1000, 0), ModelVersion: "v500", ExampleCount: 1},
+	}
+	if !reflect.DeepEqual(snapshots, expected) {
+		t.Errorf("Expected snapshot index %v, got %v", expected, snapshots)
+	}
+
+	snapshot, err := a.GetSnapshot(c, "2019-01-01")
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	if len(snapshot.Examples) != 1 || snapshot.Examples[0].Id != 7 || snapshot.Examples[0].Result != 0.3 {
+		t.Errorf("Expected snapshot to contain example 7 with result 0.3, got %+v", snapshot.Examples)
+	}
+
+	_, err = a.GetSnapshot(c, "2019-01-03")
+	if errors.Cause(err) != data2.ErrNoSuchSnapshot {
+		t.Errorf("Expected ErrNoSuchSnapshot for missing snapshot, got %v", err)
+	}
+}
+
+func TestArchive_SaveSnapshot_StoreErr(t *testing.T) {
+	t.Parallel()
+
+	ps := testhelpers.NewPersistentStore(t)
+	ps.TransactFunc = func(ctx context.Context, f func(ctx context.Context) error) error {
+		return f(ctx)
+	}
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		return errors.New("nope")
+	}
+
+	a := &Archive{PersistentStore: ps}
+	err := a.SaveSnapshot(context.Background(), &data2.ExampleSnapshot{Date: "2019-01-01"})
+	if err == nil {
+		t.Error("Expected error return from archive, got nil error")
+	}
+}
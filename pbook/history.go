@@ -0,0 +1,75 @@
+package pbook
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"strconv"
+)
+
+const storeHistoryKind = "ExampleHistory"
+
+// Only the most recent updates are kept for each example.
+const maxHistoryEntries = 100
+
+type exampleHistory struct {
+	Entries []data2.ExampleHistoryEntry
+}
+
+// GetExampleHistory returns the example's assignments at each update, oldest first.
+func (l *Lister) GetExampleHistory(ctx context.Context, id int64) ([]data2.ExampleHistoryEntry, error) {
+	if l.HistoryStore == nil {
+		return nil, nil
+	}
+
+	history := new(exampleHistory)
+	_, err := l.HistoryStore.Get(ctx, storeHistoryKind, strconv.FormatInt(id, 10), history)
+	if err != nil {
+		if errors.Cause(err) == data.ErrNoSuchEntity {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "")
+	}
+	return history.Entries, nil
+}
+
+// recordHistory appends each example's current assignments, and our combined probability for them, to its history.
+// History is secondary to the example list itself, so failures are only logged.
+func (l *Lister) recordHistory(ctx context.Context, examples data2.ExamplePredictions) {
+	if l.HistoryStore == nil {
+		return
+	}
+
+	now := l.now()
+	for _, example := range examples {
+		history := new(exampleHistory)
+		key := strconv.FormatInt(example.Id, 10)
+		_, err := l.HistoryStore.Get(ctx, storeHistoryKind, key, history)
+		if err != nil && errors.Cause(err) != data.ErrNoSuchEntity {
+			ctxlogrus.Get(ctx).Warnf("Unable to read history for example %d: %s", example.Id, err)
+			continue
+		}
+
+		entry := data2.ExampleHistoryEntry{
+			Updated:     now,
+			Assignments: example.Assignments,
+		}
+		if l.PredictionMaker != nil {
+			entry.Result, entry.ModelVersion, err = l.PredictionMaker.PredictWithVersion(ctx, example.Assignments)
+			if err != nil {
+				entry.ResultErr = err.Error()
+			}
+		}
+		history.Entries = append(history.Entries, entry)
+		if len(history.Entries) > maxHistoryEntries {
+			history.Entries = history.Entries[len(history.Entries)-maxHistoryEntries:]
+		}
+
+		err = l.HistoryStore.Set(ctx, storeHistoryKind, key, nil, history)
+		if err != nil {
+			ctxlogrus.Get(ctx).Warnf("Unable to save history for example %d: %s", example.Id, err)
+		}
+	}
+}
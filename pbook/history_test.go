@@ -0,0 +1,111 @@
+package pbook
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"github.com/pkg/errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLister_History(t *testing.T) {
+	t.Parallel()
+
+	stored := make(map[string]exampleHistory)
+	hs := testhelpers.NewPersistentStore(t)
+	hs.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		if kind != storeHistoryKind {
+			t.Errorf("Reading from wrong store kind; expected %s, was %s", storeHistoryKind, kind)
+		}
+		history, ok := stored[key]
+		if !ok {
+			return nil, data.ErrNoSuchEntity
+		}
+		*v.(*exampleHistory) = history
+		return nil, nil
+	}
+	hs.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		stored[key] = *v.(*exampleHistory)
+		return nil
+	}
+
+	pm := newTestPredictionMaker(t)
+	pm.PredictWithVersionFunc = func(ctx context.Context, predictions []float64) (float64, string, error) {
+		if len(predictions) == 1 {
+			return 0, "", errors.New("bluh")
+		}
+		return 0.4, "v100", nil
+	}
+
+	now := time.Unix(1000, 0)
+	lister := &Lister{
+		HistoryStore:    hs,
+		PredictionMaker: pm,
+		NowFunc: func() time.Time {
+			return now
+		},
+	}
+
+	c := context.Background()
+	lister.recordHistory(c, data2.ExamplePredictions{
+		{PredictionSummary: &predictions.PredictionSummary{Id: 7}, Assignments: []float64{0.3}},
+	})
+	now = now.Add(24 * time.Hour)
+	lister.recordHistory(c, data2.ExamplePredictions{
+		{PredictionSummary: &predictions.PredictionSummary{Id: 7}, Assignments: []float64{0.3, 0.5}},
+		{PredictionSummary: &predictions.PredictionSummary{Id: 9}, Assignments: []float64{0.1}},
+	})
+
+	history, err := lister.GetExampleHistory(c, 7)
+	if err != nil {
+		t.Fatalf("Unexpected error from lister: %s", err)
+	}
+	expected := []data2.ExampleHistoryEntry{
+		{Updated: time.Unix(1000, 0), Assignments: []float64{0.3}, ResultErr: "bluh"},
+		{Updated: time.Unix(1000, 0).Add(24 * time.Hour), Assignments: []float64{0.3, 0.5}, Result: 0.4, ModelVersion: "v100"},
+	}
+	if !reflect.DeepEqual(history, expected) {
+		t.Errorf("Expected history %v, got %v", expected, history)
+	}
+
+	history, err = lister.GetExampleHistory(c, 11)
+	if err != nil || history != nil {
+		t.Errorf("Expected no history and no error for unknown example, got %v, %v", history, err)
+	}
+}
+
+func TestLister_GetExampleHistory_Error(t *testing.T) {
+	t.Parallel()
+
+	hs := testhelpers.NewPersistentStore(t)
+	hs.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		return nil, errors.New("nope")
+	}
+
+	lister := &Lister{HistoryStore: hs}
+	_, err := lister.GetExampleHistory(context.Background(), 7)
+	if err == nil {
+		t.Error("Expected error return from lister, got nil error")
+	}
+}
+
+type testPredictionMaker struct {
+	PredictWithVersionFunc func(ctx context.Context, predictions []float64) (float64, string, error)
+}
+
+func newTestPredictionMaker(t *testing.T) *testPredictionMaker {
+	return &testPredictionMaker{
+		PredictWithVersionFunc: func(ctx context.Context, predictions []float64) (float64, string, error) {
+			t.Error("PredictWithVersion should not be called")
+			return 0, "", nil
+		},
+	}
+}
+
+func (pm *testPredictionMaker) PredictWithVersion(ctx context.Context, predictions []float64) (float64, string, error) {
+	return pm.PredictWithVersionFunc(ctx, predictions)
+}
@@ -7,16 +7,37 @@ import (
 	"github.com/jbeshir/predictionbook-extractor/predictions"
 	"github.com/pkg/errors"
 	"math"
+	"time"
 )
 
 const cacheExamplesKey = "examples"
 const storeExamplesKind = "ExamplePredictions"
 const storeExamplesKey = "examples"
 
+const defaultTargetExamples = 20
+const defaultMaxPages = 10
+
 type Lister struct {
 	PredictionSource PredictionSource
 	CacheStore       CacheStore
 	PersistentStore  PersistentStore
+
+	// HistoryStore, if set, records each example's assignments at every update,
+	// along with PredictionMaker's combined probability for them, if it is set.
+	HistoryStore    PersistentStore
+	PredictionMaker PredictionMaker
+
+	// TargetExamples is how many unresolved examples to collect, paging through the prediction list
+	// until we have that many or have read MaxPages pages. Zero means the defaults.
+	TargetExamples int
+	MaxPages       int64
+
+	// PageLimiter, if set, is waited on before retrieving each page.
+	PageLimiter PageLimiter
+
+	Selection          SelectionPolicy
+	DuplicateResponses data.DuplicateResponsePolicy
+	NowFunc            func() time.Time
 }
 
 func (l *Lister) GetExamples(ctx context.Context) (data.ExamplePredictions, error) {
@@ -39,50 +60,146 @@ func (l *Lister) GetExamples(ctx context.Context) (data.ExamplePredictions, erro
 }
 
 func (l *Lister) UpdateExamples(ctx context.Context) (data.ExamplePredictions, error) {
-	logger := ctxlogrus.Get(ctx)
+	examples, err := l.retrieveExamples(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	examples = l.Selection.selectExamples(examples)
+
+	err = l.PersistentStore.Set(ctx, storeExamplesKind, storeExamplesKey, nil, &examples)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	_ = l.CacheStore.Delete(ctx, cacheExamplesKey)
+
+	l.recordHistory(ctx, examples)
+
+	return examples, nil
+}
 
-	logger.Info("Retrieving first page of predictions from prediction source...")
-	summaries, _, err := l.PredictionSource.RetrievePredictionListPage(ctx, 1)
+func (l *Lister) GetExample(ctx context.Context, id int64) (*data.ExamplePrediction, error) {
+	examples, err := l.GetExamples(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "")
 	}
-	logger.Infof("Got %d predictions", len(summaries))
 
-	logger.Info("Splitting unresolved predictions from resolved...")
-	var unresolvedSummaries []*predictions.PredictionSummary
-	for _, s := range summaries {
-		if s.Outcome == predictions.Unknown {
-			unresolvedSummaries = append(unresolvedSummaries, s)
+	for i := range examples {
+		if examples[i].Id == id {
+			return &examples[i], nil
 		}
 	}
-	logger.Infof("Got %d unresolved predictions", len(unresolvedSummaries))
+	return nil, errors.WithStack(data.ErrNoSuchExample)
+}
+
+// retrieveExamples pages through the prediction list until it has the target number of examples
+// admitted by the selection policy, or has read the maximum number of pages.
+func (l *Lister) retrieveExamples(ctx context.Context) (data.ExamplePredictions, error) {
+	logger := ctxlogrus.Get(ctx)
 
-	logger.Info("Retrieving unresolved prediction responses...")
+	target := l.TargetExamples
+	if target <= 0 {
+		target = defaultTargetExamples
+	}
+	maxPages := l.MaxPages
+	if maxPages <= 0 {
+		maxPages = defaultMaxPages
+	}
+
+	now := l.now()
+
+	// Predictions can move between pages as new ones are made while we're reading,
+	// so we skip any we've already seen.
+	seen := make(map[int64]bool)
+	var examples data.ExamplePredictions
+	for page := int64(1); page <= maxPages && len(examples) < target; page++ {
+		if l.PageLimiter != nil {
+			if err := l.PageLimiter.Wait(ctx); err != nil {
+				return nil, errors.Wrap(err, "")
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+
+		logger.Infof("Retrieving page %d of predictions from prediction source...", page)
+		summaries, pageInfo, err := l.PredictionSource.RetrievePredictionListPage(ctx, page)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		logger.Infof("Got %d predictions", len(summaries))
+
+		var candidates []*predictions.PredictionSummary
+		for _, s := range summaries {
+			if s.Outcome == predictions.Unknown && !seen[s.Id] && l.Selection.admitsSummary(s, now) {
+				seen[s.Id] = true
+				candidates = append(candidates, s)
+			}
+		}
+
+		// We only retrieve responses for as many candidates as we still need examples,
+		// retrieving more if some aren't admitted once we know who assigned them probabilities.
+		for len(candidates) > 0 && len(examples) < target {
+			n := target - len(examples)
+			if n > len(candidates) {
+				n = len(candidates)
+			}
+
+			admitted, err := l.buildExamples(ctx, candidates[:n])
+			if err != nil {
+				return nil, errors.Wrap(err, "")
+			}
+			examples = append(examples, admitted...)
+			candidates = candidates[n:]
+		}
+		logger.Infof("Have %d examples", len(examples))
+
+		if pageInfo == nil || pageInfo.Index >= pageInfo.LastPage {
+			break
+		}
+	}
+
+	return examples, nil
+}
+
+// buildExamples retrieves the responses to the unresolved predictions,
+// returning examples of those the selection policy admits.
+func (l *Lister) buildExamples(ctx context.Context, unresolvedSummaries []*predictions.PredictionSummary) (data.ExamplePredictions, error) {
+	ctxlogrus.Get(ctx).Infof("Retrieving responses to %d unresolved predictions...", len(unresolvedSummaries))
 	_, responses, err := l.PredictionSource.AllPredictionResponses(ctx, unresolvedSummaries)
 	if err != nil {
 		return nil, errors.Wrap(err, "")
 	}
 
-	logger.Info("Building and saving new example predictions list.")
+	responses = l.DuplicateResponses.Apply(responses)
+
 	var examples data.ExamplePredictions
 	for i := range unresolvedSummaries {
 		example := data.ExamplePrediction{
 			PredictionSummary: unresolvedSummaries[i],
 		}
+		users := make(map[string]bool)
 		for _, r := range responses {
 			if r.Prediction == example.Id && !math.IsNaN(r.Confidence) {
 				example.Assignments = append(example.Assignments, r.Confidence)
+				example.Responses = append(example.Responses, data.ExampleResponse{
+					User:       r.User,
+					Time:       r.Time,
+					Confidence: r.Confidence,
+				})
+				users[r.User] = true
 			}
 		}
-		examples = append(examples, example)
+		if l.Selection.admitsExample(len(users)) {
+			examples = append(examples, example)
+		}
 	}
+	return examples, nil
+}
 
-	err = l.PersistentStore.Set(ctx, storeExamplesKind, storeExamplesKey, nil, &examples)
-	if err != nil {
-		return nil, errors.Wrap(err, "")
+func (l *Lister) now() time.Time {
+	if l.NowFunc != nil {
+		return l.NowFunc()
 	}
-
-	_ = l.CacheStore.Delete(ctx, cacheExamplesKey)
-
-	return examples, nil
+	return time.Now()
 }
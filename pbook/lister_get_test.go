@@ -160,3 +160,38 @@ func TestLister_GetExamples_FromStoreErr(t *testing.T) {
 		t.Error("Expected error from lister, got nil error")
 	}
 }
+
+func TestLister_GetExample(t *testing.T) {
+	t.Parallel()
+
+	ps := testhelpers.NewPersistentStore(t)
+	cs := testhelpers.NewCacheStore(t)
+	lister := &Lister{
+		CacheStore:      cs,
+		PersistentStore: ps,
+	}
+
+	cs.GetFunc = func(ctx context.Context, key string, v interface{}) error {
+		*v.(*data2.ExamplePredictions) = []data2.ExamplePrediction{
+			{PredictionSummary: &predictions.PredictionSummary{Id: 7}},
+			{PredictionSummary: &predictions.PredictionSummary{Id: 9}},
+		}
+		return nil
+	}
+
+	c := context.Background()
+	example, err := lister.GetExample(c, 9)
+	if err != nil {
+		t.Errorf("Unexpected error from lister: %s", err)
+	} else if example.Id != 9 {
+		t.Errorf("Incorrect example, prediction ID should have been %d, was %d", 9, example.Id)
+	}
+
+	example, err = lister.GetExample(c, 11)
+	if errors.Cause(err) != data2.ErrNoSuchExample {
+		t.Errorf("Expected ErrNoSuchExample for missing example, got %v", err)
+	}
+	if example != nil {
+		t.Error("Expected nil example for missing example, got non-nil example")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"math"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestLister_UpdateExamples(t *testing.T) {
@@ -249,3 +250,330 @@ func TestLister_UpdateExamples_StoreErr(t *testing.T) {
 		t.Error("Expected error return from lister, got nil error")
 	}
 }
+
+func TestLister_UpdateExamples_MultiplePages(t *testing.T) {
+	t.Parallel()
+
+	ps := testhelpers.NewPersistentStore(t)
+	cs := testhelpers.NewCacheStore(t)
+	s := testhelpers2.NewPredictionSource(t)
+	limiter := newTestPageLimiter(t)
+	lister := &Lister{
+		PredictionSource: s,
+		CacheStore:       cs,
+		PersistentStore:  ps,
+		TargetExamples:   3,
+		PageLimiter:      limiter,
+	}
+
+	waitCount := 0
+	limiter.WaitFunc = func(ctx context.Context) error {
+		waitCount++
+		return nil
+	}
+
+	pages := map[int64][]*predictions.PredictionSummary{
+		1: {{Id: 15}, {Id: 13, Outcome: predictions.Right}},
+		2: {{Id: 13, Outcome: predictions.Right}, {Id: 11}},
+		3: {{Id: 11}, {Id: 9}, {Id: 7}},
+	}
+	var retrievedPages []int64
+	s.RetrievePredictionListPageFunc = func(ctx context.Context, i int64) ([]*predictions.PredictionSummary, *predictions.PredictionListPageInfo, error) {
+		retrievedPages = append(retrievedPages, i)
+		return pages[i], &predictions.PredictionListPageInfo{Index: i, LastPage: 5}, nil
+	}
+
+	var ids []int64
+	s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+		for _, s := range summaries {
+			ids = append(ids, s.Id)
+		}
+		return nil, nil, nil
+	}
+
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		return nil
+	}
+	cs.DeleteFunc = func(ctx context.Context, key string) error {
+		return nil
+	}
+
+	result, err := lister.UpdateExamples(context.Background())
+	if err != nil {
+		t.Errorf("Unexpected error returned from lister: %s", err)
+	}
+	if len(result) != 3 {
+		t.Errorf("Expected %d examples, got %d", 3, len(result))
+	}
+	if !reflect.DeepEqual(ids, []int64{15, 11, 9}) {
+		t.Errorf("Expected to retrieve responses for the first 3 distinct unresolved predictions, got %v", ids)
+	}
+	if !reflect.DeepEqual(retrievedPages, []int64{1, 2, 3}) {
+		t.Errorf("Expected to retrieve pages 1 to 3, retrieved %v", retrievedPages)
+	}
+	if waitCount != 3 {
+		t.Errorf("Expected to wait on the limiter %d times, waited %d times", 3, waitCount)
+	}
+}
+
+func TestLister_UpdateExamples_PageLimits(t *testing.T) {
+	t.Parallel()
+
+	for _, lastPage := range []int64{2, 10} {
+		ps := testhelpers.NewPersistentStore(t)
+		cs := testhelpers.NewCacheStore(t)
+		s := testhelpers2.NewPredictionSource(t)
+		lister := &Lister{
+			PredictionSource: s,
+			CacheStore:       cs,
+			PersistentStore:  ps,
+			TargetExamples:   100,
+			MaxPages:         3,
+		}
+
+		var retrievedPages []int64
+		s.RetrievePredictionListPageFunc = func(ctx context.Context, i int64) ([]*predictions.PredictionSummary, *predictions.PredictionListPageInfo, error) {
+			retrievedPages = append(retrievedPages, i)
+			return []*predictions.PredictionSummary{{Id: i}}, &predictions.PredictionListPageInfo{Index: i, LastPage: lastPage}, nil
+		}
+		s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+			return nil, nil, nil
+		}
+		ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+			return nil
+		}
+		cs.DeleteFunc = func(ctx context.Context, key string) error {
+			return nil
+		}
+
+		_, err := lister.UpdateExamples(context.Background())
+		if err != nil {
+			t.Errorf("Unexpected error returned from lister: %s", err)
+		}
+
+		expectedPages := int64(3)
+		if lastPage < expectedPages {
+			expectedPages = lastPage
+		}
+		if int64(len(retrievedPages)) != expectedPages {
+			t.Errorf("With last page %d, expected to retrieve %d pages, retrieved %v", lastPage, expectedPages, retrievedPages)
+		}
+	}
+}
+
+func TestLister_UpdateExamples_LimiterErr(t *testing.T) {
+	t.Parallel()
+
+	ps := testhelpers.NewPersistentStore(t)
+	cs := testhelpers.NewCacheStore(t)
+	s := testhelpers2.NewPredictionSource(t)
+	limiter := newTestPageLimiter(t)
+	lister := &Lister{
+		PredictionSource: s,
+		CacheStore:       cs,
+		PersistentStore:  ps,
+		PageLimiter:      limiter,
+	}
+
+	limiter.WaitFunc = func(ctx context.Context) error {
+		return errors.New("nope")
+	}
+
+	result, err := lister.UpdateExamples(context.Background())
+	if result != nil {
+		t.Error("Expected nil results from lister, got non-nil results")
+	}
+	if err == nil {
+		t.Error("Expected error return from lister, got nil error")
+	}
+}
+
+type testPageLimiter struct {
+	WaitFunc func(ctx context.Context) error
+}
+
+func newTestPageLimiter(t *testing.T) *testPageLimiter {
+	return &testPageLimiter{
+		WaitFunc: func(ctx context.Context) error {
+			t.Error("Wait should not be called")
+			return nil
+		},
+	}
+}
+
+func (l *testPageLimiter) Wait(ctx context.Context) error {
+	return l.WaitFunc(ctx)
+}
+
+func TestLister_UpdateExamples_Selection(t *testing.T) {
+	t.Parallel()
+
+	ps := testhelpers.NewPersistentStore(t)
+	cs := testhelpers.NewCacheStore(t)
+	s := testhelpers2.NewPredictionSource(t)
+	lister := &Lister{
+		PredictionSource: s,
+		CacheStore:       cs,
+		PersistentStore:  ps,
+		Selection: SelectionPolicy{
+			MinDistinctAssignments: 2,
+			ExcludeCreators:        []string{"bob"},
+		},
+	}
+
+	s.RetrievePredictionListPageFunc = func(ctx context.Context, i int64) ([]*predictions.PredictionSummary, *predictions.PredictionListPageInfo, error) {
+		return []*predictions.PredictionSummary{
+			{Id: 7},
+			{Id: 9},
+			{Id: 11, Creator: "bob"},
+		}, nil, nil
+	}
+
+	s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+		if len(summaries) != 2 {
+			t.Errorf("Expected excluded creator's prediction not to be retrieved, retrieving %d summaries", len(summaries))
+		}
+		return nil, []*predictions.PredictionResponse{
+			{Prediction: 7, User: "alice", Confidence: 0.6},
+			{Prediction: 7, User: "alice", Confidence: 0.7},
+			{Prediction: 9, User: "alice", Confidence: 0.3},
+			{Prediction: 9, User: "carol", Confidence: 0.1},
+		}, nil
+	}
+
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		return nil
+	}
+	cs.DeleteFunc = func(ctx context.Context, key string) error {
+		return nil
+	}
+
+	result, err := lister.UpdateExamples(context.Background())
+	if err != nil {
+		t.Errorf("Unexpected error returned from lister: %s", err)
+	}
+	if len(result) != 1 || result[0].Id != 9 {
+		t.Errorf("Expected only the prediction with two distinct users to be selected, got %v", result)
+	}
+}
+
+func TestLister_UpdateExamples_SelectionPaging(t *testing.T) {
+	t.Parallel()
+
+	ps := testhelpers.NewPersistentStore(t)
+	cs := testhelpers.NewCacheStore(t)
+	s := testhelpers2.NewPredictionSource(t)
+	lister := &Lister{
+		PredictionSource: s,
+		CacheStore:       cs,
+		PersistentStore:  ps,
+		TargetExamples:   2,
+		Selection: SelectionPolicy{
+			MinDistinctAssignments: 2,
+		},
+	}
+
+	pages := map[int64][]*predictions.PredictionSummary{
+		1: {{Id: 1}, {Id: 2}},
+		2: {{Id: 3}, {Id: 4}},
+	}
+	var retrievedPages []int64
+	s.RetrievePredictionListPageFunc = func(ctx context.Context, i int64) ([]*predictions.PredictionSummary, *predictions.PredictionListPageInfo, error) {
+		retrievedPages = append(retrievedPages, i)
+		return pages[i], &predictions.PredictionListPageInfo{Index: i, LastPage: 5}, nil
+	}
+
+	var retrievedIds [][]int64
+	s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+		var ids []int64
+		var responses []*predictions.PredictionResponse
+		for _, s := range summaries {
+			ids = append(ids, s.Id)
+			responses = append(responses, &predictions.PredictionResponse{Prediction: s.Id, User: "alice", Confidence: 0.5})
+			if s.Id%2 == 1 {
+				responses = append(responses, &predictions.PredictionResponse{Prediction: s.Id, User: "bob", Confidence: 0.5})
+			}
+		}
+		retrievedIds = append(retrievedIds, ids)
+		return nil, responses, nil
+	}
+
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		return nil
+	}
+	cs.DeleteFunc = func(ctx context.Context, key string) error {
+		return nil
+	}
+
+	result, err := lister.UpdateExamples(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error returned from lister: %s", err)
+	}
+	if len(result) != 2 || result[0].Id != 1 || result[1].Id != 3 {
+		t.Errorf("Expected the two predictions with two distinct users, got %v", result)
+	}
+	if !reflect.DeepEqual(retrievedPages, []int64{1, 2}) {
+		t.Errorf("Expected to retrieve pages until two examples were admitted, retrieved %v", retrievedPages)
+	}
+	if !reflect.DeepEqual(retrievedIds, [][]int64{{1, 2}, {3}}) {
+		t.Errorf("Expected to retrieve responses only for as many predictions as examples were needed, got %v", retrievedIds)
+	}
+}
+
+func TestLister_UpdateExamples_DuplicateResponses(t *testing.T) {
+	t.Parallel()
+
+	responses := []*predictions.PredictionResponse{
+		{Prediction: 7, User: "alice", Time: time.Unix(100, 0), Confidence: 0.2},
+		{Prediction: 7, User: "bob", Time: time.Unix(150, 0), Confidence: 0.9},
+		{Prediction: 7, User: "alice", Time: time.Unix(200, 0), Confidence: 0.4},
+		{Prediction: 7, User: "alice", Time: time.Unix(250, 0), Confidence: math.NaN()},
+	}
+
+	cases := []struct {
+		policy   data2.DuplicateResponsePolicy
+		expected []float64
+	}{
+		{data2.KeepAllResponses, []float64{0.2, 0.9, 0.4}},
+		{data2.KeepLatestResponse, []float64{0.9, 0.4}},
+		{data2.KeepFirstResponse, []float64{0.9, 0.2}},
+		{data2.AverageResponses, []float64{0.9, 0.30000000000000004}},
+	}
+	for _, c := range cases {
+		ps := testhelpers.NewPersistentStore(t)
+		cs := testhelpers.NewCacheStore(t)
+		s := testhelpers2.NewPredictionSource(t)
+		lister := &Lister{
+			PredictionSource:   s,
+			CacheStore:         cs,
+			PersistentStore:    ps,
+			DuplicateResponses: c.policy,
+		}
+
+		s.RetrievePredictionListPageFunc = func(ctx context.Context, i int64) ([]*predictions.PredictionSummary, *predictions.PredictionListPageInfo, error) {
+			return []*predictions.PredictionSummary{{Id: 7}}, nil, nil
+		}
+		s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+			return nil, responses, nil
+		}
+		ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+			return nil
+		}
+		cs.DeleteFunc = func(ctx context.Context, key string) error {
+			return nil
+		}
+
+		result, err := lister.UpdateExamples(context.Background())
+		if err != nil {
+			t.Errorf("Unexpected error returned from lister: %s", err)
+			continue
+		}
+		if len(result) != 1 || !reflect.DeepEqual(result[0].Assignments, c.expected) {
+			t.Errorf("With policy %q, expected assignments %v, got %v", c.policy, c.expected, result)
+		}
+	}
+
+	if responses[0].Confidence != 0.2 {
+		t.Error("Expected source responses not to be modified")
+	}
+}
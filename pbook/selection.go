@@ -0,0 +1,70 @@
+package pbook
+
+import (
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SelectionPolicy decides which unresolved predictions become examples.
+// The zero value selects every unresolved prediction.
+type SelectionPolicy struct {
+	// MinDistinctAssignments is the fewest different users who must have assigned a probability.
+	MinDistinctAssignments int
+
+	// MaxTimeToDeadline excludes predictions due further in the future than this, if non-zero.
+	MaxTimeToDeadline time.Duration
+
+	// ExcludeCreators and ExcludeTitleKeywords are matched case-insensitively,
+	// creators against the whole name and keywords anywhere in the title.
+	ExcludeCreators      []string
+	ExcludeTitleKeywords []string
+
+	RankByWagerCount bool
+
+	// MaxExamples caps the number of examples kept, if non-zero.
+	MaxExamples int
+}
+
+// admitsSummary applies the parts of the policy which don't need the prediction's responses,
+// so excluded predictions don't count towards the examples we page through the list for.
+func (p *SelectionPolicy) admitsSummary(s *predictions.PredictionSummary, now time.Time) bool {
+	if p.MaxTimeToDeadline > 0 && s.Deadline.Sub(now) > p.MaxTimeToDeadline {
+		return false
+	}
+	for _, creator := range p.ExcludeCreators {
+		if strings.EqualFold(s.Creator, creator) {
+			return false
+		}
+	}
+	title := strings.ToLower(s.Title)
+	for _, keyword := range p.ExcludeTitleKeywords {
+		if keyword != "" && strings.Contains(title, strings.ToLower(keyword)) {
+			return false
+		}
+	}
+	return true
+}
+
+// admitsExample applies the parts of the policy which need to know how many distinct users
+// assigned the prediction a probability.
+func (p *SelectionPolicy) admitsExample(distinctUsers int) bool {
+	return distinctUsers >= p.MinDistinctAssignments
+}
+
+// selectExamples ranks the admitted examples, and caps how many are kept.
+func (p *SelectionPolicy) selectExamples(examples data.ExamplePredictions) data.ExamplePredictions {
+	selected := append(data.ExamplePredictions(nil), examples...)
+	if p.RankByWagerCount {
+		sort.SliceStable(selected, func(i, j int) bool {
+			return selected[i].WagerCount > selected[j].WagerCount
+		})
+	}
+
+	if p.MaxExamples > 0 && len(selected) > p.MaxExamples {
+		selected = selected[:p.MaxExamples]
+	}
+	return selected
+}
@@ -0,0 +1,86 @@
+package pbook
+
+import (
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectionPolicy_AdmitsSummary(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(100000, 0)
+	p := &SelectionPolicy{
+		MaxTimeToDeadline:    time.Hour,
+		ExcludeCreators:      []string{"Spammer"},
+		ExcludeTitleKeywords: []string{"test"},
+	}
+
+	cases := []struct {
+		summary  predictions.PredictionSummary
+		expected bool
+	}{
+		{predictions.PredictionSummary{Title: "Rain tomorrow", Deadline: now.Add(time.Minute)}, true},
+		{predictions.PredictionSummary{Title: "Rain next year", Deadline: now.Add(2 * time.Hour)}, false},
+		{predictions.PredictionSummary{Title: "Rain tomorrow", Creator: "spammer", Deadline: now}, false},
+		{predictions.PredictionSummary{Title: "A TEST prediction", Deadline: now}, false},
+	}
+	for _, c := range cases {
+		if admitted := p.admitsSummary(&c.summary, now); admitted != c.expected {
+			t.Errorf("Expected admission of %+v to be %v, was %v", c.summary, c.expected, admitted)
+		}
+	}
+
+	zero := &SelectionPolicy{}
+	if !zero.admitsSummary(&predictions.PredictionSummary{Title: "test", Deadline: now.Add(1000 * time.Hour)}, now) {
+		t.Error("Expected zero policy to admit every prediction")
+	}
+}
+
+func TestSelectionPolicy_AdmitsExample(t *testing.T) {
+	t.Parallel()
+
+	p := &SelectionPolicy{MinDistinctAssignments: 2}
+	if p.admitsExample(1) {
+		t.Error("Expected example with 1 distinct user not to be admitted")
+	}
+	if !p.admitsExample(2) {
+		t.Error("Expected example with 2 distinct users to be admitted")
+	}
+
+	zero := &SelectionPolicy{}
+	if !zero.admitsExample(0) {
+		t.Error("Expected zero policy to admit an example without assignments")
+	}
+}
+
+func TestSelectionPolicy_SelectExamples(t *testing.T) {
+	t.Parallel()
+
+	examples := data.ExamplePredictions{
+		{PredictionSummary: &predictions.PredictionSummary{Id: 1, WagerCount: 3}},
+		{PredictionSummary: &predictions.PredictionSummary{Id: 3, WagerCount: 5}},
+		{PredictionSummary: &predictions.PredictionSummary{Id: 4, WagerCount: 7}},
+	}
+
+	p := &SelectionPolicy{
+		RankByWagerCount: true,
+		MaxExamples:      2,
+	}
+	selected := p.selectExamples(examples)
+
+	var ids []int64
+	for _, e := range selected {
+		ids = append(ids, e.Id)
+	}
+	if !reflect.DeepEqual(ids, []int64{4, 3}) {
+		t.Errorf("Expected to select examples [4 3], selected %v", ids)
+	}
+
+	zero := &SelectionPolicy{}
+	if selected := zero.selectExamples(examples); !reflect.DeepEqual(selected, examples) {
+		t.Error("Expected zero policy to select every example in order")
+	}
+}
@@ -21,3 +21,16 @@ type PredictionSource interface {
 	RetrievePredictionListPage(context.Context, int64) ([]*predictions.PredictionSummary, *predictions.PredictionListPageInfo, error)
 	AllPredictionResponses(context.Context, []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error)
 }
+
+type PredictionMaker interface {
+	PredictWithVersion(ctx context.Context, predictions []float64) (p float64, version string, err error)
+}
+
+type PageLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+type TransactionalStore interface {
+	PersistentStore
+	Transact(ctx context.Context, f func(ctx context.Context) error) error
+}
@@ -0,0 +1,335 @@
+package pbook
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/moonbird-predictor-frontend/scoring"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+const storeTrackRecordKind = "TrackRecord"
+const storeTrackRecordKey = "record"
+const storeTrackRecordMonthKind = "TrackRecordMonth"
+
+const trackRecordMonthFormat = "2006-01"
+
+const defaultCalibrationBins = 10
+
+// Predictions still unjudged this long after their deadline are assumed never to be,
+// and their outcomes are no longer retrieved.
+const defaultOutcomeWait = 365 * 24 * time.Hour
+
+// TrackRecorder scores archived example snapshots once their predictions have resolved.
+type TrackRecorder struct {
+	Archive          *Archive
+	PredictionSource PredictionSource
+	PersistentStore  PersistentStore
+	CalibrationBins  int
+	OutcomeWait      time.Duration
+	NowFunc          func() time.Time
+}
+
+type trackRecordState struct {
+	Record data2.TrackRecord
+
+	// Months lists the months of the deadlines of the predictions we've tracked forecasts for.
+	// Each month's forecasts and outcomes are stored in their own entity, so no one entity grows without bound.
+	Months []string
+
+	// LastSnapshot is when the most recently taken snapshot already read was taken,
+	// so later updates only read snapshots added, or replaced, since.
+	LastSnapshot time.Time
+}
+
+// trackRecordMonth holds the forecasts for predictions with deadlines in one month, and their outcomes.
+type trackRecordMonth struct {
+	// Outcomes of resolved predictions, so we don't need to fetch them again.
+	Outcomes map[int64]bool
+
+	// Forecasts holds each version's forecast for each prediction, from the last snapshot taken before its deadline,
+	// so a prediction listed for many days is scored once per version. It's keyed by version, then prediction ID.
+	Forecasts map[string]map[int64]trackedForecast
+}
+
+type trackedForecast struct {
+	Taken       time.Time
+	Deadline    time.Time
+	Probability float64
+	Baseline    float64
+}
+
+func (tr *TrackRecorder) GetTrackRecord(ctx context.Context) (*data2.TrackRecord, error) {
+	state, err := tr.getState(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return &state.Record, nil
+}
+
+func (tr *TrackRecorder) UpdateTrackRecord(ctx context.Context) (*data2.TrackRecord, error) {
+	l := ctxlogrus.Get(ctx)
+	now := tr.now()
+
+	state, err := tr.getState(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	months := make(map[string]*trackRecordMonth)
+	for _, month := range state.Months {
+		months[month], err = tr.getMonth(ctx, month)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+	}
+	changed := make(map[string]bool)
+
+	infos, err := tr.Archive.ListSnapshots(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	// Snapshots are listed newest first; read the oldest first, so later forecasts replace earlier ones.
+	read := 0
+	lastSnapshot := state.LastSnapshot
+	for i := len(infos) - 1; i >= 0; i-- {
+		info := infos[i]
+		if !info.Taken.After(state.LastSnapshot) {
+			continue
+		}
+
+		snapshot, err := tr.Archive.GetSnapshot(ctx, info.Date)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		addSnapshot(months, changed, snapshot)
+		if snapshot.Taken.After(lastSnapshot) {
+			lastSnapshot = snapshot.Taken
+		}
+		read++
+	}
+	state.LastSnapshot = lastSnapshot
+	l.Infof("Read %d new example snapshots", read)
+
+	// A prediction's outcome is retrieved once its deadline has passed, until it's resolved or we give up on it.
+	giveUp := now.Add(-tr.outcomeWait())
+	pending := make(map[int64]map[string]bool)
+	for month, m := range months {
+		for _, forecasts := range m.Forecasts {
+			for id, f := range forecasts {
+				if _, ok := m.Outcomes[id]; ok || !f.Deadline.Before(now) || f.Deadline.Before(giveUp) {
+					continue
+				}
+				if pending[id] == nil {
+					pending[id] = make(map[string]bool)
+				}
+				pending[id][month] = true
+			}
+		}
+	}
+
+	if len(pending) > 0 {
+		var summaries []*predictions.PredictionSummary
+		for id := range pending {
+			summaries = append(summaries, &predictions.PredictionSummary{Id: id})
+		}
+		sort.Slice(summaries, func(i, j int) bool {
+			return summaries[i].Id < summaries[j].Id
+		})
+
+		l.Infof("Retrieving outcomes of %d archived predictions past their deadline...", len(summaries))
+		updated, _, err := tr.PredictionSource.AllPredictionResponses(ctx, summaries)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		for _, s := range updated {
+			if s.Outcome != predictions.Right && s.Outcome != predictions.Wrong {
+				continue
+			}
+			for month := range pending[s.Id] {
+				months[month].Outcomes[s.Id] = s.Outcome == predictions.Right
+				changed[month] = true
+			}
+		}
+	}
+
+	state.Months = state.Months[:0]
+	for month := range months {
+		state.Months = append(state.Months, month)
+	}
+	sort.Strings(state.Months)
+
+	state.Record = tr.score(state.Months, months, now)
+
+	for _, month := range state.Months {
+		if !changed[month] {
+			continue
+		}
+		err = tr.PersistentStore.Set(ctx, storeTrackRecordMonthKind, month, nil, months[month])
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+	}
+
+	err = tr.PersistentStore.Set(ctx, storeTrackRecordKind, storeTrackRecordKey, nil, state)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return &state.Record, nil
+}
+
+// addSnapshot records the snapshot's forecasts in the month of their deadline, where they were made before it,
+// and no later forecast by the same version is already recorded, noting which months it changed.
+func addSnapshot(months map[string]*trackRecordMonth, changed map[string]bool, snapshot *data2.ExampleSnapshot) {
+	for _, entry := range snapshot.Examples {
+		if entry.ResultErr != "" || len(entry.Assignments) == 0 || !snapshot.Taken.Before(entry.Deadline) {
+			continue
+		}
+
+		version := entry.ModelVersion
+		if version == "" {
+			version = snapshot.ModelVersion
+		}
+
+		month := entry.Deadline.UTC().Format(trackRecordMonthFormat)
+		m := months[month]
+		if m == nil {
+			m = newTrackRecordMonth()
+			months[month] = m
+		}
+
+		forecasts := m.Forecasts[version]
+		if forecasts == nil {
+			forecasts = make(map[int64]trackedForecast)
+			m.Forecasts[version] = forecasts
+		}
+		if existing, ok := forecasts[entry.Id]; ok && existing.Taken.After(snapshot.Taken) {
+			continue
+		}
+		forecasts[entry.Id] = trackedForecast{
+			Taken:       snapshot.Taken,
+			Deadline:    entry.Deadline,
+			Probability: entry.Result,
+			Baseline:    meanAssignment(entry.Assignments),
+		}
+		changed[month] = true
+	}
+}
+
+func (tr *TrackRecorder) score(monthOrder []string, months map[string]*trackRecordMonth, now time.Time) data2.TrackRecord {
+	bins := tr.CalibrationBins
+	if bins <= 0 {
+		bins = defaultCalibrationBins
+	}
+
+	var allMoonbird, allBaseline []scoring.Forecast
+	moonbird := make(map[string][]scoring.Forecast)
+	baseline := make(map[string][]scoring.Forecast)
+	for _, month := range monthOrder {
+		m := months[month]
+		for version, versionForecasts := range m.Forecasts {
+			// Scores don't depend on order, but calibration bins are filled in order, so keep it stable.
+			var ids []int64
+			for id := range versionForecasts {
+				ids = append(ids, id)
+			}
+			sort.Slice(ids, func(i, j int) bool {
+				return ids[i] < ids[j]
+			})
+
+			for _, id := range ids {
+				outcome, ok := m.Outcomes[id]
+				if !ok {
+					continue
+				}
+
+				f := versionForecasts[id]
+				mf := scoring.Forecast{Probability: f.Probability, Outcome: outcome}
+				bf := scoring.Forecast{Probability: f.Baseline, Outcome: outcome}
+				moonbird[version] = append(moonbird[version], mf)
+				baseline[version] = append(baseline[version], bf)
+				allMoonbird = append(allMoonbird, mf)
+				allBaseline = append(allBaseline, bf)
+			}
+		}
+	}
+
+	record := data2.TrackRecord{
+		Updated: now,
+		Overall: data2.VersionTrackRecord{
+			Count:    len(allMoonbird),
+			Moonbird: scoring.Score(allMoonbird, bins),
+			Baseline: scoring.Score(allBaseline, bins),
+		},
+	}
+	for version := range moonbird {
+		record.Versions = append(record.Versions, data2.VersionTrackRecord{
+			ModelVersion: version,
+			Count:        len(moonbird[version]),
+			Moonbird:     scoring.Score(moonbird[version], bins),
+			Baseline:     scoring.Score(baseline[version], bins),
+		})
+	}
+	sort.Slice(record.Versions, func(i, j int) bool {
+		return record.Versions[i].ModelVersion < record.Versions[j].ModelVersion
+	})
+	return record
+}
+
+func (tr *TrackRecorder) getState(ctx context.Context) (*trackRecordState, error) {
+	state := new(trackRecordState)
+	_, err := tr.PersistentStore.Get(ctx, storeTrackRecordKind, storeTrackRecordKey, state)
+	if err != nil && errors.Cause(err) != data.ErrNoSuchEntity {
+		return nil, errors.Wrap(err, "")
+	}
+	return state, nil
+}
+
+func (tr *TrackRecorder) getMonth(ctx context.Context, month string) (*trackRecordMonth, error) {
+	m := new(trackRecordMonth)
+	_, err := tr.PersistentStore.Get(ctx, storeTrackRecordMonthKind, month, m)
+	if err != nil && errors.Cause(err) != data.ErrNoSuchEntity {
+		return nil, errors.Wrap(err, "")
+	}
+	if m.Outcomes == nil {
+		m.Outcomes = make(map[int64]bool)
+	}
+	if m.Forecasts == nil {
+		m.Forecasts = make(map[string]map[int64]trackedForecast)
+	}
+	return m, nil
+}
+
+func newTrackRecordMonth() *trackRecordMonth {
+	return &trackRecordMonth{
+		Outcomes:  make(map[int64]bool),
+		Forecasts: make(map[string]map[int64]trackedForecast),
+	}
+}
+
+func (tr *TrackRecorder) outcomeWait() time.Duration {
+	if tr.OutcomeWait > 0 {
+		return tr.OutcomeWait
+	}
+	return defaultOutcomeWait
+}
+
+func (tr *TrackRecorder) now() time.Time {
+	if tr.NowFunc != nil {
+		return tr.NowFunc()
+	}
+	return time.Now()
+}
+
+func meanAssignment(assignments []float64) float64 {
+	var total float64
+	for _, a := range assignments {
+		total += a
+	}
+	return total / float64(len(assignments))
+}
@@ -0,0 +1,412 @@
+package pbook
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	"github.com/jbeshir/moonbird-auth-frontend/testhelpers"
+	data2 "github.com/jbeshir/moonbird-predictor-frontend/data"
+	testhelpers2 "github.com/jbeshir/moonbird-predictor-frontend/testhelpers"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"github.com/pkg/errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestTrackRecordStore keeps entities in memory as JSON, as a real store would serialize them,
+// counting how many of each kind are written.
+func newTestTrackRecordStore(t *testing.T) (*testhelpers.PersistentStore, map[string]int) {
+	entities := make(map[string][]byte)
+	writes := make(map[string]int)
+
+	ps := testhelpers.NewPersistentStore(t)
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		if kind != storeTrackRecordKind && kind != storeTrackRecordMonthKind {
+			t.Errorf("Unexpected retrieval of %s/%s", kind, key)
+		}
+		content, ok := entities[kind+"/"+key]
+		if !ok {
+			return nil, data.ErrNoSuchEntity
+		}
+		return nil, json.Unmarshal(content, v)
+	}
+	ps.SetFunc = func(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error {
+		content, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		entities[kind+"/"+key] = content
+		writes[kind]++
+		return nil
+	}
+	return ps, writes
+}
+
+func TestTrackRecorder_UpdateTrackRecord(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(100000, 0)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	archive := &Archive{PersistentStore: newTestArchiveStore(t)}
+	c := context.Background()
+	snapshots := []*data2.ExampleSnapshot{
+		{
+			Date:         "2019-01-01",
+			Taken:        now.Add(-3 * time.Hour),
+			ModelVersion: "v500",
+			Examples: []data2.ExampleSnapshotEntry{
+				{ExamplePrediction: data2.ExamplePrediction{PredictionSummary: &predictions.PredictionSummary{Id: 7, Deadline: past}, Assignments: []float64{0.6, 1}}, Result: 0.9},
+				{ExamplePrediction: data2.ExamplePrediction{PredictionSummary: &predictions.PredictionSummary{Id: 9, Deadline: past}, Assignments: []float64{0.5}}, Result: 0.4},
+			},
+		},
+		{
+			Date:         "2019-01-02",
+			Taken:        now.Add(-2 * time.Hour),
+			ModelVersion: "v600",
+			Examples: []data2.ExampleSnapshotEntry{
+				{ExamplePrediction: data2.ExamplePrediction{PredictionSummary: &predictions.PredictionSummary{Id: 7, Deadline: past}, Assignments: []float64{0.6}}, Result: 0.7, ModelVersion: "v700"},
+				{ExamplePrediction: data2.ExamplePrediction{PredictionSummary: &predictions.PredictionSummary{Id: 11, Deadline: past}, Assignments: []float64{0.5}}, Result: 0.5},
+				{ExamplePrediction: data2.ExamplePrediction{PredictionSummary: &predictions.PredictionSummary{Id: 13, Deadline: future}, Assignments: []float64{0.5}}, Result: 0.5},
+			},
+		},
+	}
+	for _, s := range snapshots {
+		if err := archive.SaveSnapshot(c, s); err != nil {
+			t.Fatalf("Unexpected error saving snapshot: %s", err)
+		}
+	}
+
+	ps, _ := newTestTrackRecordStore(t)
+
+	retrieveCount := 0
+	s := testhelpers2.NewPredictionSource(t)
+	s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+		retrieveCount++
+		var ids []int64
+		for _, s := range summaries {
+			ids = append(ids, s.Id)
+		}
+		if !reflect.DeepEqual(ids, []int64{7, 9, 11}) {
+			t.Errorf("Expected to retrieve outcomes of predictions past their deadline, got %v", ids)
+		}
+		return []*predictions.PredictionSummary{
+			{Id: 7, Outcome: predictions.Right},
+			{Id: 9, Outcome: predictions.Wrong},
+			{Id: 11, Outcome: predictions.Unknown},
+		}, nil, nil
+	}
+
+	tr := &TrackRecorder{
+		Archive:          archive,
+		PredictionSource: s,
+		PersistentStore:  ps,
+		CalibrationBins:  2,
+		NowFunc: func() time.Time {
+			return now
+		},
+	}
+
+	record, err := tr.UpdateTrackRecord(c)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+
+	if record.Overall.Count != 3 {
+		t.Errorf("Expected 3 scored forecasts overall, got %d", record.Overall.Count)
+	}
+	expectedBrier := (0.01 + 0.16 + 0.09) / 3
+	if math.Abs(record.Overall.Moonbird.Brier-expectedBrier) > 1e-9 {
+		t.Errorf("Expected overall Moonbird Brier score of %g, got %g", expectedBrier, record.Overall.Moonbird.Brier)
+	}
+	expectedBaselineBrier := (0.04 + 0.25 + 0.16) / 3
+	if math.Abs(record.Overall.Baseline.Brier-expectedBaselineBrier) > 1e-9 {
+		t.Errorf("Expected overall baseline Brier score of %g, got %g", expectedBaselineBrier, record.Overall.Baseline.Brier)
+	}
+	if len(record.Overall.Moonbird.Calibration) != 2 {
+		t.Errorf("Expected 2 calibration bins, got %d", len(record.Overall.Moonbird.Calibration))
+	}
+
+	var versions []string
+	for _, v := range record.Versions {
+		versions = append(versions, v.ModelVersion)
+	}
+	if !reflect.DeepEqual(versions, []string{"v500", "v700"}) {
+		t.Errorf("Expected scores for versions [v500 v700], got %v", versions)
+	}
+
+	// Resolved outcomes are remembered, so only the still-unresolved prediction is fetched again.
+	s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+		if len(summaries) != 1 || summaries[0].Id != 11 {
+			t.Errorf("Expected to retrieve only the unresolved prediction, got %d predictions", len(summaries))
+		}
+		return []*predictions.PredictionSummary{{Id: 11, Outcome: predictions.Right}}, nil, nil
+	}
+	record, err = tr.UpdateTrackRecord(c)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	if record.Overall.Count != 4 {
+		t.Errorf("Expected 4 scored forecasts overall after further resolution, got %d", record.Overall.Count)
+	}
+
+	got, err := tr.GetTrackRecord(c)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	if !got.Updated.Equal(record.Updated) {
+		t.Errorf("Expected stored track record to be updated at %s, was %s", record.Updated, got.Updated)
+	}
+	got.Updated = record.Updated
+	if !reflect.DeepEqual(got, record) {
+		t.Error("Expected stored track record to match the one returned by the update")
+	}
+}
+
+func TestTrackRecorder_UpdateTrackRecord_SourceErr(t *testing.T) {
+	t.Parallel()
+
+	archive := &Archive{PersistentStore: newTestArchiveStore(t)}
+	c := context.Background()
+	err := archive.SaveSnapshot(c, &data2.ExampleSnapshot{
+		Date:  "2019-01-01",
+		Taken: time.Unix(1000, 0),
+		Examples: []data2.ExampleSnapshotEntry{
+			{ExamplePrediction: data2.ExamplePrediction{PredictionSummary: &predictions.PredictionSummary{Id: 7, Deadline: time.Unix(2000, 0)}, Assignments: []float64{0.5}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error saving snapshot: %s", err)
+	}
+
+	ps := testhelpers.NewPersistentStore(t)
+	ps.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		return nil, data.ErrNoSuchEntity
+	}
+
+	s := testhelpers2.NewPredictionSource(t)
+	s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+		return nil, nil, errors.New("nope")
+	}
+
+	tr := &TrackRecorder{
+		Archive:          archive,
+		PredictionSource: s,
+		PersistentStore:  ps,
+		NowFunc: func() time.Time {
+			return time.Unix(3000, 0)
+		},
+	}
+	_, err = tr.UpdateTrackRecord(c)
+	if err == nil {
+		t.Error("Expected error return from track recorder, got nil error")
+	}
+}
+
+func TestTrackRecorder_UpdateTrackRecord_OncePerVersion(t *testing.T) {
+	t.Parallel()
+
+	deadline := time.Unix(10000, 0)
+	entry := func(result float64) data2.ExampleSnapshotEntry {
+		return data2.ExampleSnapshotEntry{
+			ExamplePrediction: data2.ExamplePrediction{PredictionSummary: &predictions.PredictionSummary{Id: 7, Deadline: deadline}, Assignments: []float64{0.5}},
+			Result:            result,
+		}
+	}
+
+	archiveStore := newTestArchiveStore(t)
+	archiveGetFunc := archiveStore.GetFunc
+	snapshotReads := 0
+	archiveStore.GetFunc = func(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error) {
+		if kind == storeSnapshotKind {
+			snapshotReads++
+		}
+		return archiveGetFunc(ctx, kind, key, v)
+	}
+	archive := &Archive{PersistentStore: archiveStore}
+
+	c := context.Background()
+	snapshots := []*data2.ExampleSnapshot{
+		{Date: "2019-01-01", Taken: time.Unix(1000, 0), ModelVersion: "v500", Examples: []data2.ExampleSnapshotEntry{entry(0.6)}},
+		{Date: "2019-01-02", Taken: time.Unix(2000, 0), ModelVersion: "v500", Examples: []data2.ExampleSnapshotEntry{entry(0.8)}},
+		// Taken after the deadline, so not a forecast.
+		{Date: "2019-01-03", Taken: time.Unix(20000, 0), ModelVersion: "v500", Examples: []data2.ExampleSnapshotEntry{entry(1)}},
+	}
+	for _, s := range snapshots {
+		if err := archive.SaveSnapshot(c, s); err != nil {
+			t.Fatalf("Unexpected error saving snapshot: %s", err)
+		}
+	}
+
+	ps, _ := newTestTrackRecordStore(t)
+
+	s := testhelpers2.NewPredictionSource(t)
+	s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+		return []*predictions.PredictionSummary{{Id: 7, Outcome: predictions.Right}}, nil, nil
+	}
+
+	tr := &TrackRecorder{
+		Archive:          archive,
+		PredictionSource: s,
+		PersistentStore:  ps,
+		NowFunc: func() time.Time {
+			return time.Unix(30000, 0)
+		},
+	}
+
+	record, err := tr.UpdateTrackRecord(c)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	if record.Overall.Count != 1 {
+		t.Fatalf("Expected the prediction to be scored once, got %d forecasts", record.Overall.Count)
+	}
+	if math.Abs(record.Overall.Moonbird.Brier-0.04) > 1e-9 {
+		t.Errorf("Expected the last forecast before the deadline to be scored, got Brier score %g", record.Overall.Moonbird.Brier)
+	}
+	if snapshotReads != 3 {
+		t.Errorf("Expected each snapshot to be read once, got %d reads", snapshotReads)
+	}
+
+	// Only snapshots added since are read again.
+	err = archive.SaveSnapshot(c, &data2.ExampleSnapshot{Date: "2019-01-04", Taken: time.Unix(21000, 0), ModelVersion: "v500"})
+	if err != nil {
+		t.Fatalf("Unexpected error saving snapshot: %s", err)
+	}
+	record, err = tr.UpdateTrackRecord(c)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	if snapshotReads != 4 {
+		t.Errorf("Expected only the new snapshot to be read, got %d reads in total", snapshotReads)
+	}
+	if record.Overall.Count != 1 {
+		t.Errorf("Expected the prediction to still be scored once, got %d forecasts", record.Overall.Count)
+	}
+}
+
+func TestTrackRecorder_UpdateTrackRecord_Months(t *testing.T) {
+	t.Parallel()
+
+	january := time.Date(2019, time.January, 20, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2019, time.February, 20, 0, 0, 0, 0, time.UTC)
+	entry := func(id int64, deadline time.Time) data2.ExampleSnapshotEntry {
+		return data2.ExampleSnapshotEntry{
+			ExamplePrediction: data2.ExamplePrediction{PredictionSummary: &predictions.PredictionSummary{Id: id, Deadline: deadline}, Assignments: []float64{0.5}},
+			Result:            0.8,
+		}
+	}
+
+	archive := &Archive{PersistentStore: newTestArchiveStore(t)}
+	c := context.Background()
+	err := archive.SaveSnapshot(c, &data2.ExampleSnapshot{
+		Date:         "2019-01-01",
+		Taken:        time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC),
+		ModelVersion: "v500",
+		Examples:     []data2.ExampleSnapshotEntry{entry(7, january), entry(9, february)},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error saving snapshot: %s", err)
+	}
+
+	ps, writes := newTestTrackRecordStore(t)
+
+	s := testhelpers2.NewPredictionSource(t)
+	s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+		var updated []*predictions.PredictionSummary
+		for _, s := range summaries {
+			updated = append(updated, &predictions.PredictionSummary{Id: s.Id, Outcome: predictions.Right})
+		}
+		return updated, nil, nil
+	}
+
+	now := time.Date(2019, time.February, 1, 0, 0, 0, 0, time.UTC)
+	tr := &TrackRecorder{
+		Archive:          archive,
+		PredictionSource: s,
+		PersistentStore:  ps,
+		NowFunc: func() time.Time {
+			return now
+		},
+	}
+
+	record, err := tr.UpdateTrackRecord(c)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	if record.Overall.Count != 1 {
+		t.Errorf("Expected only the January prediction to be scored, got %d forecasts", record.Overall.Count)
+	}
+	if writes[storeTrackRecordMonthKind] != 2 {
+		t.Errorf("Expected forecasts to be stored in an entity per deadline month, got %d writes", writes[storeTrackRecordMonthKind])
+	}
+
+	// Only the month whose prediction resolved is written again.
+	now = time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC)
+	record, err = tr.UpdateTrackRecord(c)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	if record.Overall.Count != 2 {
+		t.Errorf("Expected both predictions to be scored, got %d forecasts", record.Overall.Count)
+	}
+	if writes[storeTrackRecordMonthKind] != 3 {
+		t.Errorf("Expected only the February entity to be written again, got %d writes in total", writes[storeTrackRecordMonthKind])
+	}
+}
+
+func TestTrackRecorder_UpdateTrackRecord_GiveUp(t *testing.T) {
+	t.Parallel()
+
+	archive := &Archive{PersistentStore: newTestArchiveStore(t)}
+	c := context.Background()
+	err := archive.SaveSnapshot(c, &data2.ExampleSnapshot{
+		Date:  "2019-01-01",
+		Taken: time.Unix(1000, 0),
+		Examples: []data2.ExampleSnapshotEntry{
+			{ExamplePrediction: data2.ExamplePrediction{PredictionSummary: &predictions.PredictionSummary{Id: 7, Deadline: time.Unix(2000, 0)}, Assignments: []float64{0.5}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error saving snapshot: %s", err)
+	}
+
+	ps, _ := newTestTrackRecordStore(t)
+
+	retrieveCount := 0
+	s := testhelpers2.NewPredictionSource(t)
+	s.AllPredictionResponsesFunc = func(ctx context.Context, summaries []*predictions.PredictionSummary) ([]*predictions.PredictionSummary, []*predictions.PredictionResponse, error) {
+		retrieveCount++
+		return []*predictions.PredictionSummary{{Id: 7, Outcome: predictions.Unknown}}, nil, nil
+	}
+
+	now := time.Unix(3000, 0)
+	tr := &TrackRecorder{
+		Archive:          archive,
+		PredictionSource: s,
+		PersistentStore:  ps,
+		OutcomeWait:      2000 * time.Second,
+		NowFunc: func() time.Time {
+			return now
+		},
+	}
+
+	if _, err := tr.UpdateTrackRecord(c); err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	if retrieveCount != 1 {
+		t.Errorf("Expected the outcome to be retrieved once past the deadline, retrieved %d times", retrieveCount)
+	}
+
+	// Once we've waited long enough for it to be judged, we stop asking.
+	now = time.Unix(5000, 0)
+	if _, err := tr.UpdateTrackRecord(c); err != nil {
+		t.Fatalf("Expected err to be nil, was %s", err)
+	}
+	if retrieveCount != 1 {
+		t.Errorf("Expected the outcome not to be retrieved after giving up on it, retrieved %d times", retrieveCount)
+	}
+}
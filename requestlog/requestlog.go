@@ -0,0 +1,162 @@
+// Package requestlog gives each request an ID, carried in its logs and echoed to the caller,
+// and writes a structured access log line once each request is handled.
+package requestlog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"github.com/jbeshir/moonbird-predictor-frontend/tracing"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Header carries the request ID, both from a caller or load balancer which already assigned one, and back to the caller.
+const Header = "X-Request-Id"
+
+// maxIDLength limits IDs taken from callers, so they can't fill our logs.
+const maxIDLength = 128
+
+type requestKey struct{}
+
+// request is what Handler knows of a request, shared with the context maker for it.
+type request struct {
+	id    string
+	route string
+
+	mu     sync.Mutex
+	logger *logrus.Entry
+}
+
+// IDFromContext returns the ID of the request being handled, or "" if it has none.
+func IDFromContext(ctx context.Context) string {
+	req, _ := ctx.Value(requestKey{}).(*request)
+	if req == nil {
+		return ""
+	}
+	return req.id
+}
+
+// Handler gives each request to h an ID, taking the caller's if valid, and sets it on the response.
+// Once h is done, it logs the request's route, status and latency, with the logger from the request's context,
+// if a ContextMaker from this package made one.
+func Handler(route string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(Header)
+		if !validID(id) {
+			id = newID()
+		}
+		req := &request{id: id, route: route}
+		w.Header().Set(Header, id)
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), requestKey{}, req)))
+
+		fields := logrus.Fields{
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"status":     sw.status,
+			"bytes":      sw.bytes,
+			"latency_ms": float64(time.Since(start)) / float64(time.Millisecond),
+		}
+		if sc := tracing.SpanFromContext(r.Context()).SpanContext(); sc.TraceID.IsValid() {
+			fields["trace_id"] = sc.TraceID.String()
+		}
+		req.getLogger().WithFields(fields).Info("Request handled")
+	})
+}
+
+func (req *request) getLogger() *logrus.Entry {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+
+	if req.logger == nil {
+		return logrus.NewEntry(logrus.StandardLogger()).WithFields(logrus.Fields{
+			"request_id": req.id,
+			"route":      req.route,
+		})
+	}
+	return req.logger
+}
+
+func (req *request) setLogger(l *logrus.Entry) {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+
+	if req.logger == nil {
+		req.logger = l
+	}
+}
+
+// ContextMaker adds the request ID and route to the log fields of contexts its ContextMaker makes,
+// for requests passed through Handler. Other requests' contexts are left as they were made.
+type ContextMaker struct {
+	ContextMaker controllers.ContextMaker
+}
+
+func (cm *ContextMaker) MakeContext(r *http.Request) (context.Context, error) {
+	ctx, err := cm.ContextMaker.MakeContext(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req, _ := r.Context().Value(requestKey{}).(*request)
+	if req == nil {
+		return ctx, nil
+	}
+
+	ctx = ctxlogrus.WithFields(ctx, logrus.Fields{
+		"request_id": req.id,
+		"route":      req.route,
+	})
+	ctx = context.WithValue(ctx, requestKey{}, req)
+	req.setLogger(ctxlogrus.Get(ctx))
+	return ctx, nil
+}
+
+func validID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for _, c := range id {
+		isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlnum && c != '-' && c != '_' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func newID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
@@ -0,0 +1,140 @@
+package requestlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testContextMaker struct {
+	Logger *logrus.Logger
+}
+
+func (cm *testContextMaker) MakeContext(r *http.Request) (context.Context, error) {
+	return ctxlogrus.WithLogger(r.Context(), logrus.NewEntry(cm.Logger)), nil
+}
+
+func newTestLogger() (*logrus.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	l := logrus.New()
+	l.Out = &buf
+	l.Formatter = &logrus.JSONFormatter{}
+	return l, &buf
+}
+
+func readLogLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var line map[string]interface{}
+		if err := dec.Decode(&line); err != nil {
+			t.Fatalf("Expected JSON log lines, got %s", err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	logger, buf := newTestLogger()
+	cm := &ContextMaker{ContextMaker: &testContextMaker{Logger: logger}}
+
+	var handledID string
+	h := Handler("/examples/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := cm.MakeContext(r)
+		if err != nil {
+			t.Fatalf("Expected nil err, got %s", err)
+		}
+		handledID = IDFromContext(ctx)
+		ctx = ctxlogrus.WithFields(ctx, logrus.Fields{"controller": "ExampleDetail"})
+		ctxlogrus.Get(ctx).Info("Handling")
+
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("not found"))
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/examples/12", nil))
+
+	id := w.Header().Get(Header)
+	if len(id) != 32 {
+		t.Errorf("Expected a generated request ID in the response, got %q", id)
+	}
+	if handledID != id {
+		t.Errorf("Expected the handler's context to have request ID %q, got %q", id, handledID)
+	}
+
+	lines := readLogLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("Expected the handler's log line and an access log line, got %v", lines)
+	}
+	if lines[0]["request_id"] != id || lines[0]["controller"] != "ExampleDetail" {
+		t.Errorf("Expected the handler's log line to have the request ID alongside its controller, got %v", lines[0])
+	}
+
+	access := lines[1]
+	if access["request_id"] != id || access["route"] != "/examples/" || access["path"] != "/examples/12" || access["method"] != "GET" {
+		t.Errorf("Expected the access log line to identify the request, got %v", access)
+	}
+	if access["status"] != float64(http.StatusNotFound) || access["bytes"] != float64(len("not found")) {
+		t.Errorf("Expected the access log line to have the response's status and size, got %v", access)
+	}
+	if _, ok := access["latency_ms"].(float64); !ok {
+		t.Errorf("Expected the access log line to have the latency, got %v", access)
+	}
+}
+
+func TestHandler_CallerID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		id       string
+		accepted bool
+	}{
+		{"valid", "abc-123_x.y", true},
+		{"invalid characters", "abc 123\n", false},
+		{"too long", string(bytes.Repeat([]byte("a"), maxIDLength+1)), false},
+	}
+
+	for _, test := range tests {
+		h := Handler("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(Header, test.id)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		got := w.Header().Get(Header)
+		if test.accepted && got != test.id {
+			t.Errorf("%s: expected the caller's request ID %q, got %q", test.name, test.id, got)
+		}
+		if !test.accepted && (got == test.id || got == "") {
+			t.Errorf("%s: expected a generated request ID in place of %q, got %q", test.name, test.id, got)
+		}
+	}
+}
+
+func TestContextMaker_WithoutHandler(t *testing.T) {
+	t.Parallel()
+
+	logger, _ := newTestLogger()
+	cm := &ContextMaker{ContextMaker: &testContextMaker{Logger: logger}}
+
+	ctx, err := cm.MakeContext(httptest.NewRequest("GET", "/healthz", nil))
+	if err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+	if id := IDFromContext(ctx); id != "" {
+		t.Errorf("Expected no request ID, got %q", id)
+	}
+	if _, ok := ctxlogrus.Get(ctx).Data["request_id"]; ok {
+		t.Error("Expected no request ID log field")
+	}
+}
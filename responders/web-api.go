@@ -0,0 +1,41 @@
+package responders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"net/http"
+)
+
+type WebApiResponder struct {
+	ExposeErrors bool
+}
+
+func (r *WebApiResponder) OnContextError(w http.ResponseWriter, err error) {
+	if r.ExposeErrors {
+		http.Error(w, fmt.Sprintf("Internal Server Error: %s", err), 500)
+	} else {
+		http.Error(w, "Internal Server Error", 500)
+	}
+}
+
+func (r *WebApiResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	l := ctxlogrus.Get(ctx)
+	l.Error(err)
+
+	if r.ExposeErrors {
+		http.Error(w, fmt.Sprintf("Internal Server Error: %s", err), 500)
+	} else {
+		http.Error(w, "Internal Server Error", 500)
+	}
+}
+
+func (r *WebApiResponder) OnNotFound(w http.ResponseWriter) {
+	http.Error(w, "Not Found", 404)
+}
+
+func (r *WebApiResponder) OnSuccess(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,87 @@
+package responders
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebApiResponder_OnContextError(t *testing.T) {
+	t.Parallel()
+
+	r := &WebApiResponder{
+		ExposeErrors: false,
+	}
+
+	recorder := httptest.NewRecorder()
+	r.OnContextError(recorder, errors.New("bluh"))
+
+	result := recorder.Result()
+	if result.StatusCode != 500 {
+		t.Errorf("Expected a status code of 500, got %d", result.StatusCode)
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	if string(content) != "Internal Server Error\n" {
+		t.Errorf("Expected a body of 'Internal Server Error\n', got '%s'", content)
+	}
+}
+
+func TestWebApiResponder_OnError_ExposeErrors(t *testing.T) {
+	t.Parallel()
+
+	r := &WebApiResponder{
+		ExposeErrors: true,
+	}
+
+	recorder := httptest.NewRecorder()
+	r.OnError(context.Background(), recorder, errors.New("bluh"))
+
+	result := recorder.Result()
+	if result.StatusCode != 500 {
+		t.Errorf("Expected a status code of 500, got %d", result.StatusCode)
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	if string(content) != "Internal Server Error: bluh\n" {
+		t.Errorf("Expected a body of 'Internal Server Error: bluh\n', got '%s'", content)
+	}
+}
+
+func TestWebApiResponder_OnSuccess(t *testing.T) {
+	t.Parallel()
+
+	r := &WebApiResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnSuccess(recorder, struct{ Foo int }{Foo: 3})
+
+	result := recorder.Result()
+	if result.StatusCode != 200 {
+		t.Errorf("Expected a status code of 200, got %d", result.StatusCode)
+	}
+	if result.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected a content type of application/json, got %s", result.Header.Get("Content-Type"))
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	if string(content) != "{\"Foo\":3}\n" {
+		t.Errorf("Expected a body of '{\"Foo\":3}\n', got '%s'", content)
+	}
+}
+
+func TestWebApiResponder_OnNotFound(t *testing.T) {
+	t.Parallel()
+
+	r := &WebApiResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnNotFound(recorder)
+
+	result := recorder.Result()
+	if result.StatusCode != 404 {
+		t.Errorf("Expected a status code of 404, got %d", result.StatusCode)
+	}
+}
@@ -0,0 +1,188 @@
+package responders
+
+import (
+	"context"
+	"fmt"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"html/template"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// Reliability diagrams are drawn into a square plot area of this size, in SVG user units,
+// offset by a margin leaving space for the axis labels.
+const (
+	chartSize   = 300.0
+	chartMargin = 40.0
+)
+
+var calibrationTemplate = template.Must(template.New("calibration").Funcs(template.FuncMap{
+	"ReliabilityChart": newReliabilityChart,
+}).Parse(
+	`{{define "chart"}}<div class="calibration-chart">
+	<div class="calibration-chart-title">{{.Title}}</div>
+	<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}">
+		<rect x="{{.Left}}" y="{{.Top}}" width="{{.Size}}" height="{{.Size}}" class="calibration-plot"/>
+		{{range .Ticks}}<line x1="{{.X}}" y1="{{$.Bottom}}" x2="{{.X}}" y2="{{$.Top}}" class="calibration-grid"/>
+		<line x1="{{$.Left}}" y1="{{.Y}}" x2="{{$.Right}}" y2="{{.Y}}" class="calibration-grid"/>
+		<text x="{{.X}}" y="{{$.TickLabelY}}" text-anchor="middle" class="calibration-label">{{.Label}}</text>
+		<text x="{{$.TickLabelX}}" y="{{.Y}}" text-anchor="end" dominant-baseline="middle" class="calibration-label">{{.Label}}</text>
+		{{end}}<line x1="{{.Left}}" y1="{{.Bottom}}" x2="{{.Right}}" y2="{{.Top}}" class="calibration-diagonal"/>
+		{{if .Line}}<polyline points="{{.Line}}" class="calibration-line"/>{{end}}
+		{{range .Points}}<circle cx="{{.X}}" cy="{{.Y}}" r="{{.R}}" class="calibration-point"><title>{{.Title}}</title></circle>
+		<text x="{{.X}}" y="{{.LabelY}}" text-anchor="middle" class="calibration-count">{{.Count}}</text>
+		{{end}}<text x="{{.MidX}}" y="{{.Height}}" text-anchor="middle" class="calibration-label">Predicted probability</text>
+		<text x="10" y="{{.MidY}}" transform="rotate(-90 10 {{.MidY}})" text-anchor="middle" class="calibration-label">Observed frequency</text>
+	</svg>
+</div>{{end}}<html>
+<head>
+	<link href="https://fonts.googleapis.com/css?family=Roboto|Roboto+Slab" rel="stylesheet">
+	<link rel="stylesheet" type="text/css" href="/static/moonbird.css" />
+</head>
+<body class="predict-page">
+<h1>Moonbird Calibration</h1>
+<div class="admin-panel">
+	<div>How often predictions came true, against the probability given, for {{if .Overall}}all model versions, as model {{.ModelVersion}} has no resolved predictions yet{{else}}model {{.ModelVersion}}{{end}}. Based on {{.Record.Count}} resolved predictions from the <a href="/track-record">track record</a>. A perfectly calibrated forecaster's points lie on the diagonal; each point is labelled with the number of predictions in its bin.</div>
+	<div class="calibration-charts">
+		{{template "chart" (ReliabilityChart "Moonbird" .Record.Moonbird.Calibration)}}
+		{{template "chart" (ReliabilityChart "Naive averaging" .Record.Baseline.Calibration)}}
+	</div>
+</div>
+</body>
+</html>`))
+
+type reliabilityChart struct {
+	Title  string
+	Points []reliabilityPoint
+	Ticks  []reliabilityTick
+	Line   string
+}
+
+type reliabilityPoint struct {
+	X, Y, R, LabelY float64
+	Count           int
+	Title           string
+}
+
+type reliabilityTick struct {
+	X, Y  float64
+	Label string
+}
+
+func newReliabilityChart(title string, bins []data.CalibrationBin) *reliabilityChart {
+	c := &reliabilityChart{
+		Title: title,
+	}
+
+	maxCount := 0
+	for _, b := range bins {
+		if b.Count > maxCount {
+			maxCount = b.Count
+		}
+	}
+
+	for _, b := range bins {
+		if b.Count == 0 {
+			continue
+		}
+
+		// Scale point area with the number of predictions in the bin.
+		p := reliabilityPoint{
+			X:     c.scaleX(b.MeanPredicted),
+			Y:     c.scaleY(b.ObservedFrequency),
+			R:     3 + 7*math.Sqrt(float64(b.Count)/float64(maxCount)),
+			Count: b.Count,
+			Title: fmt.Sprintf("%.1f-%.1f: %d predictions, mean %.3f, observed %.3f",
+				b.Lower, b.Upper, b.Count, b.MeanPredicted, b.ObservedFrequency),
+		}
+		p.LabelY = p.Y - p.R - 3
+		c.Points = append(c.Points, p)
+
+		if c.Line != "" {
+			c.Line += " "
+		}
+		c.Line += fmt.Sprintf("%.1f,%.1f", p.X, p.Y)
+	}
+
+	for _, v := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		c.Ticks = append(c.Ticks, reliabilityTick{
+			X:     c.scaleX(v),
+			Y:     c.scaleY(v),
+			Label: strconv.FormatFloat(v, 'f', -1, 64),
+		})
+	}
+
+	return c
+}
+
+func (c *reliabilityChart) scaleX(v float64) float64 {
+	return c.Left() + v*chartSize
+}
+
+func (c *reliabilityChart) scaleY(v float64) float64 {
+	return c.Bottom() - v*chartSize
+}
+
+func (c *reliabilityChart) Size() float64 {
+	return chartSize
+}
+
+func (c *reliabilityChart) Width() float64 {
+	return chartSize + 2*chartMargin
+}
+
+func (c *reliabilityChart) Height() float64 {
+	return chartSize + 2*chartMargin
+}
+
+func (c *reliabilityChart) Left() float64 {
+	return chartMargin
+}
+
+func (c *reliabilityChart) Right() float64 {
+	return chartMargin + chartSize
+}
+
+func (c *reliabilityChart) Top() float64 {
+	return chartMargin
+}
+
+func (c *reliabilityChart) Bottom() float64 {
+	return chartMargin + chartSize
+}
+
+func (c *reliabilityChart) MidX() float64 {
+	return chartMargin + chartSize/2
+}
+
+func (c *reliabilityChart) MidY() float64 {
+	return chartMargin + chartSize/2
+}
+
+func (c *reliabilityChart) TickLabelX() float64 {
+	return chartMargin - 5
+}
+
+func (c *reliabilityChart) TickLabelY() float64 {
+	return chartMargin + chartSize + 15
+}
+
+type WebCalibrationResponder struct{}
+
+func (_ *WebCalibrationResponder) OnContextError(w http.ResponseWriter, err error) {
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebCalibrationResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	l := ctxlogrus.Get(ctx)
+	l.Error(err)
+
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebCalibrationResponder) OnResult(w http.ResponseWriter, r *controllers.CalibrationResult) {
+	calibrationTemplate.Execute(w, r)
+}
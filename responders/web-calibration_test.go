@@ -0,0 +1,123 @@
+package responders
+
+import (
+	"context"
+	"errors"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/moonbird-predictor-frontend/scoring"
+	"golang.org/x/net/html"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebCalibrationResponder_OnError(t *testing.T) {
+	t.Parallel()
+
+	r := &WebCalibrationResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnError(context.Background(), recorder, errors.New("bluh"))
+
+	result := recorder.Result()
+	if result.StatusCode != 500 {
+		t.Errorf("Expected a status code of 500, got %d", result.StatusCode)
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	if string(content) != "Internal Server Error\n" {
+		t.Errorf("Expected a body of 'Internal Server Error\n', got '%s'", content)
+	}
+}
+
+func TestWebCalibrationResponder_OnResult(t *testing.T) {
+	t.Parallel()
+
+	r := &WebCalibrationResponder{}
+
+	moonbird := []scoring.Forecast{
+		{Probability: 0.8, Outcome: true},
+		{Probability: 0.85, Outcome: true},
+		{Probability: 0.3, Outcome: false},
+	}
+	baseline := []scoring.Forecast{
+		{Probability: 0.7, Outcome: true},
+		{Probability: 0.75, Outcome: true},
+		{Probability: 0.5, Outcome: false},
+	}
+	result := &controllers.CalibrationResult{
+		ModelVersion: "v600",
+		Record: data.VersionTrackRecord{
+			ModelVersion: "v600",
+			Count:        3,
+			Moonbird:     scoring.Score(moonbird, 5),
+			Baseline:     scoring.Score(baseline, 5),
+		},
+	}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, result)
+
+	response := recorder.Result()
+	if response.StatusCode != 200 {
+		t.Errorf("Expected a status code of 200, got %d", response.StatusCode)
+	}
+
+	pageHtml, _ := html.Parse(response.Body)
+	page := goquery.NewDocumentFromNode(pageHtml)
+
+	charts := page.Find(".calibration-chart")
+	if len(charts.Nodes) != 2 {
+		t.Fatalf("Expected page to contain 2 charts, found %d", len(charts.Nodes))
+	}
+	if len(page.Find("script").Nodes) != 0 {
+		t.Error("Expected page to contain no scripts")
+	}
+
+	// Moonbird's forecasts fall into two bins; the baseline's into two others.
+	expectedCounts := [][]string{{"1", "2"}, {"1", "2"}}
+	charts.Each(func(i int, chart *goquery.Selection) {
+		if len(chart.Find("svg").Nodes) != 1 {
+			t.Errorf("Expected chart %d to contain an svg element", i)
+		}
+		counts := chart.Find(".calibration-count")
+		if len(counts.Nodes) != len(expectedCounts[i]) {
+			t.Errorf("Expected chart %d to contain %d points, found %d", i, len(expectedCounts[i]), len(counts.Nodes))
+			return
+		}
+		counts.Each(func(j int, count *goquery.Selection) {
+			if count.Text() != expectedCounts[i][j] {
+				t.Errorf("Expected chart %d point %d to have count '%s', showed '%s'", i, j, expectedCounts[i][j], count.Text())
+			}
+		})
+	})
+}
+
+func TestNewReliabilityChart(t *testing.T) {
+	t.Parallel()
+
+	bins := []data.CalibrationBin{
+		{Lower: 0, Upper: 0.5, Count: 4, MeanPredicted: 0.25, ObservedFrequency: 0.5},
+		{Lower: 0.5, Upper: 1, Count: 0},
+	}
+	c := newReliabilityChart("Test", bins)
+
+	if len(c.Points) != 1 {
+		t.Fatalf("Expected empty bins to be skipped, got %d points", len(c.Points))
+	}
+	p := c.Points[0]
+	if p.X != chartMargin+0.25*chartSize {
+		t.Errorf("Expected x of %f, got %f", chartMargin+0.25*chartSize, p.X)
+	}
+	if p.Y != chartMargin+0.5*chartSize {
+		t.Errorf("Expected y of %f, got %f", chartMargin+0.5*chartSize, p.Y)
+	}
+	if p.R != 10 {
+		t.Errorf("Expected the largest bin to have radius 10, got %f", p.R)
+	}
+	if len(c.Ticks) != 5 {
+		t.Errorf("Expected 5 ticks, got %d", len(c.Ticks))
+	}
+}
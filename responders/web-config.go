@@ -0,0 +1,40 @@
+package responders
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"html/template"
+	"net/http"
+)
+
+var configTemplate = template.Must(template.New("config").Parse(
+	`<html>
+<head>
+	<link href="https://fonts.googleapis.com/css?family=Roboto|Roboto+Slab" rel="stylesheet">
+	<link rel="stylesheet" type="text/css" href="/static/moonbird.css" />
+</head>
+<body class="predict-page">
+<h1>Configuration</h1>
+<div class="admin-panel">
+	<pre class="config-content">{{.Content}}</pre>
+</div>
+</body>
+</html>`))
+
+type WebConfigResponder struct{}
+
+func (_ *WebConfigResponder) OnContextError(w http.ResponseWriter, err error) {
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebConfigResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	l := ctxlogrus.Get(ctx)
+	l.Error(err)
+
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebConfigResponder) OnResult(w http.ResponseWriter, r *controllers.ConfigResult) {
+	configTemplate.Execute(w, r)
+}
@@ -0,0 +1,55 @@
+package responders
+
+import (
+	"context"
+	"errors"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"golang.org/x/net/html"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebConfigResponder_OnError(t *testing.T) {
+	t.Parallel()
+
+	r := &WebConfigResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnError(context.Background(), recorder, errors.New("bluh"))
+
+	result := recorder.Result()
+	if result.StatusCode != 500 {
+		t.Errorf("Expected a status code of 500, got %d", result.StatusCode)
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	if string(content) != "Internal Server Error\n" {
+		t.Errorf("Expected a body of 'Internal Server Error\n', got '%s'", content)
+	}
+}
+
+func TestWebConfigResponder_OnResult(t *testing.T) {
+	t.Parallel()
+
+	r := &WebConfigResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, &controllers.ConfigResult{
+		Content: "mode: standalone\nauth:\n  admin_password: <REDACTED>\n",
+	})
+
+	result := recorder.Result()
+	if result.StatusCode != 200 {
+		t.Errorf("Expected a status code of 200, got %d", result.StatusCode)
+	}
+
+	pageHtml, _ := html.Parse(result.Body)
+	page := goquery.NewDocumentFromNode(pageHtml)
+
+	want := "mode: standalone\nauth:\n  admin_password: <REDACTED>\n"
+	if content := page.Find(".config-content").Text(); content != want {
+		t.Errorf("Expected config content %q, showed %q", want, content)
+	}
+}
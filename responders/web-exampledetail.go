@@ -0,0 +1,80 @@
+package responders
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+var exampleDetailTemplate = template.Must(template.New("example-detail").Funcs(template.FuncMap{
+	"FormatTime": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format("2006-01-02 15:04")
+	},
+}).Parse(
+	`<html>
+<head>
+	<link href="https://fonts.googleapis.com/css?family=Roboto|Roboto+Slab" rel="stylesheet">
+	<link rel="stylesheet" type="text/css" href="/static/moonbird.css" />
+</head>
+<body class="predict-page">
+<h1>Moonbird Predictor</h1>
+{{with .Example}}<div class="admin-panel">
+	<div class="example-detail-title"><a href="{{if lt .Id 0}}/questions#question{{.Id}}{{else}}https://predictionbook.com/predictions/{{.Id}}{{end}}" class="example-detail-link">{{.Title}}</a></div>
+	<table class="admin-table">
+		<tr><th>Creator</th><td class="example-detail-creator">{{.Creator}}</td></tr>
+		<tr><th>Created</th><td>{{FormatTime .Created}}</td></tr>
+		<tr><th>Deadline</th><td>{{FormatTime .Deadline}}</td></tr>
+		<tr><th>Wagers</th><td>{{.WagerCount}}</td></tr>
+	</table>
+</div>{{end}}
+<div class="admin-panel">
+	<table class="admin-table">
+		<tr><th>Moonbird</th><td>{{if .ResultErr}}<span class="example-detail-error">{{.ResultErr}}</span>{{else}}<span class="example-detail-result">{{printf "%.3f" .Result}}</span>{{end}}</td></tr>
+		<tr><th>Mean</th><td class="example-detail-mean">{{printf "%.3f" .Mean}}</td></tr>
+		<tr><th>Median</th><td class="example-detail-median">{{printf "%.3f" .Median}}</td></tr>
+		<tr><th>PredictionBook mean</th><td>{{printf "%.3f" .Example.MeanConfidence}}</td></tr>
+	</table>
+</div>
+<div class="admin-panel">
+	<table class="admin-table">
+		<tr><th>User</th><th>Time</th><th>Probability</th></tr>
+		{{range .Example.Responses}}<tr class="example-detail-assignment"><td>{{.User}}</td><td>{{FormatTime .Time}}</td><td>{{printf "%.2f" .Confidence}}</td></tr>
+		{{end}}
+	</table>
+</div>
+{{if .History}}<div class="admin-panel">
+	<table class="admin-table">
+		<tr><th>Updated</th><th>Assignments</th><th>Moonbird</th></tr>
+		{{range .History}}<tr class="example-detail-history"><td>{{FormatTime .Updated}}</td><td>{{.AssignmentCount}}</td><td>{{if .ResultErr}}<span class="example-detail-error">{{.ResultErr}}</span>{{else}}{{printf "%.3f" .Result}}{{end}}</td></tr>
+		{{end}}
+	</table>
+</div>{{end}}
+</body>
+</html>`))
+
+type WebExampleDetailResponder struct{}
+
+func (_ *WebExampleDetailResponder) OnContextError(w http.ResponseWriter, err error) {
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebExampleDetailResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	l := ctxlogrus.Get(ctx)
+	l.Error(err)
+
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebExampleDetailResponder) OnNotFound(w http.ResponseWriter) {
+	http.Error(w, "Not Found", 404)
+}
+
+func (_ *WebExampleDetailResponder) OnResult(w http.ResponseWriter, r *controllers.ExampleDetailResult) {
+	exampleDetailTemplate.Execute(w, r)
+}
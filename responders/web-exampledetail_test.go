@@ -0,0 +1,102 @@
+package responders
+
+import (
+	"context"
+	"errors"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"golang.org/x/net/html"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebExampleDetailResponder_OnError(t *testing.T) {
+	t.Parallel()
+
+	r := &WebExampleDetailResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnError(context.Background(), recorder, errors.New("bluh"))
+
+	result := recorder.Result()
+	if result.StatusCode != 500 {
+		t.Errorf("Expected a status code of 500, got %d", result.StatusCode)
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	if string(content) != "Internal Server Error\n" {
+		t.Errorf("Expected a body of 'Internal Server Error\n', got '%s'", content)
+	}
+}
+
+func TestWebExampleDetailResponder_OnNotFound(t *testing.T) {
+	t.Parallel()
+
+	r := &WebExampleDetailResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnNotFound(recorder)
+
+	result := recorder.Result()
+	if result.StatusCode != 404 {
+		t.Errorf("Expected a status code of 404, got %d", result.StatusCode)
+	}
+}
+
+func TestWebExampleDetailResponder_OnResult(t *testing.T) {
+	t.Parallel()
+
+	r := &WebExampleDetailResponder{}
+
+	detailResult := &controllers.ExampleDetailResult{
+		Example: data.ExamplePrediction{
+			PredictionSummary: &predictions.PredictionSummary{
+				Id:      7,
+				Title:   "bluh",
+				Creator: "alice",
+			},
+			Assignments: []float64{0.3, 0.5},
+			Responses: []data.ExampleResponse{
+				{User: "alice", Time: time.Unix(1000, 0), Confidence: 0.3},
+				{User: "bob", Time: time.Unix(2000, 0), Confidence: 0.5},
+			},
+		},
+		Result: 0.42,
+		Mean:   0.4,
+		Median: 0.4,
+		History: []controllers.ExampleHistoryPoint{
+			{Updated: time.Unix(1000, 0), AssignmentCount: 1, Result: 0.3},
+		},
+	}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, detailResult)
+
+	result := recorder.Result()
+	if result.StatusCode != 200 {
+		t.Errorf("Expected a status code of 200, got %d", result.StatusCode)
+	}
+
+	pageHtml, _ := html.Parse(result.Body)
+	page := goquery.NewDocumentFromNode(pageHtml)
+
+	if link, _ := page.Find(".example-detail-link").Attr("href"); link != "https://predictionbook.com/predictions/7" {
+		t.Errorf("Example link had incorrect href: %s", link)
+	}
+	if creator := page.Find(".example-detail-creator").Text(); creator != "alice" {
+		t.Errorf("Expected creator 'alice', showed '%s'", creator)
+	}
+	if combined := page.Find(".example-detail-result").Text(); combined != "0.420" {
+		t.Errorf("Expected combined result '0.420', showed '%s'", combined)
+	}
+	if assignments := len(page.Find(".example-detail-assignment").Nodes); assignments != 2 {
+		t.Errorf("Expected page to contain 2 assignments, found %d", assignments)
+	}
+	if history := len(page.Find(".example-detail-history").Nodes); history != 1 {
+		t.Errorf("Expected page to contain 1 history point, found %d", history)
+	}
+}
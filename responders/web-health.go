@@ -0,0 +1,24 @@
+package responders
+
+import (
+	"encoding/json"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"net/http"
+)
+
+// WebHealthResponder reports health as JSON, with a 503 status if any check failed,
+// so load balancers and monitors need only look at the status code.
+type WebHealthResponder struct{}
+
+func (r *WebHealthResponder) OnContextError(w http.ResponseWriter, err error) {
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (r *WebHealthResponder) OnResult(w http.ResponseWriter, result *controllers.HealthResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if result.Status != controllers.HealthStatusOk {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,72 @@
+package responders
+
+import (
+	"errors"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebHealthResponder_OnContextError(t *testing.T) {
+	t.Parallel()
+
+	r := &WebHealthResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnContextError(recorder, errors.New("bluh"))
+
+	result := recorder.Result()
+	if result.StatusCode != 500 {
+		t.Errorf("Expected a status code of 500, got %d", result.StatusCode)
+	}
+}
+
+func TestWebHealthResponder_OnResult_Ok(t *testing.T) {
+	t.Parallel()
+
+	r := &WebHealthResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, &controllers.HealthResult{Status: controllers.HealthStatusOk})
+
+	result := recorder.Result()
+	if result.StatusCode != 200 {
+		t.Errorf("Expected a status code of 200, got %d", result.StatusCode)
+	}
+	if result.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected a content type of application/json, got %s", result.Header.Get("Content-Type"))
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	if string(content) != "{\"status\":\"ok\"}\n" {
+		t.Errorf("Expected a body of '{\"status\":\"ok\"}\n', got '%s'", content)
+	}
+}
+
+func TestWebHealthResponder_OnResult_Fail(t *testing.T) {
+	t.Parallel()
+
+	r := &WebHealthResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, &controllers.HealthResult{
+		Status: controllers.HealthStatusFail,
+		Checks: []controllers.HealthCheckResult{
+			{Name: "cache", Status: controllers.HealthStatusOk, DurationMs: 1},
+			{Name: "store", Status: controllers.HealthStatusFail, Error: "bluh", DurationMs: 2},
+		},
+	})
+
+	result := recorder.Result()
+	if result.StatusCode != 503 {
+		t.Errorf("Expected a status code of 503, got %d", result.StatusCode)
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	want := `{"status":"fail","checks":[{"name":"cache","status":"ok","duration_ms":1},` +
+		`{"name":"store","status":"fail","error":"bluh","duration_ms":2}]}` + "\n"
+	if string(content) != want {
+		t.Errorf("Expected a body of '%s', got '%s'", want, content)
+	}
+}
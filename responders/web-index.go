@@ -29,7 +29,7 @@ var indexTemplate = template.Must(template.New("index").Funcs(template.FuncMap{
 	</div>
 	{{range .ExampleList}}
 		<div class="example">
-			<a href="https://predictionbook.com/predictions/{{.Id}}" class="example-link">{{.Title}}</a>
+			<a href="/examples/{{.Id}}" class="example-link">{{.Title}}</a>
 			{{if .Result}}<span class="example-result">{{printf "%.3f" .Result}}</span>{{end}}
 			{{if .ResultErr}}<span class="example-result-error">{{.ResultErr}}</span>{{end}}
 		</div>
@@ -214,7 +214,7 @@ func TestWebIndexResponder_OnResult_ExampleList(t *testing.T) {
 	}
 
 	firstExampleLink, _ := goquery.NewDocumentFromNode(examples.Nodes[0]).Find("a.example-link").Attr("href")
-	if firstExampleLink != "https://predictionbook.com/predictions/3" {
+	if firstExampleLink != "/examples/3" {
 		t.Errorf("First example link had incorrect href: %s", firstExampleLink)
 	}
 
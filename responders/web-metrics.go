@@ -0,0 +1,26 @@
+package responders
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"net/http"
+)
+
+type WebMetricsResponder struct{}
+
+func (_ *WebMetricsResponder) OnContextError(w http.ResponseWriter, err error) {
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebMetricsResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	l := ctxlogrus.Get(ctx)
+	l.Error(err)
+
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebMetricsResponder) OnResult(w http.ResponseWriter, r *controllers.MetricsResult) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(r.Content)
+}
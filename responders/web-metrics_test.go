@@ -0,0 +1,54 @@
+package responders
+
+import (
+	"context"
+	"errors"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebMetricsResponder_OnError(t *testing.T) {
+	t.Parallel()
+
+	r := &WebMetricsResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnError(context.Background(), recorder, errors.New("bluh"))
+
+	result := recorder.Result()
+	if result.StatusCode != 500 {
+		t.Errorf("Expected a status code of 500, got %d", result.StatusCode)
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	if string(content) != "Internal Server Error\n" {
+		t.Errorf("Expected a body of 'Internal Server Error\n', got '%s'", content)
+	}
+}
+
+func TestWebMetricsResponder_OnResult(t *testing.T) {
+	t.Parallel()
+
+	r := &WebMetricsResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, &controllers.MetricsResult{
+		Content: []byte("# TYPE test_total counter\ntest_total 3\n"),
+	})
+
+	result := recorder.Result()
+	if result.StatusCode != 200 {
+		t.Errorf("Expected a status code of 200, got %d", result.StatusCode)
+	}
+	wantType := "text/plain; version=0.0.4; charset=utf-8"
+	if result.Header.Get("Content-Type") != wantType {
+		t.Errorf("Expected a content type of %s, got %s", wantType, result.Header.Get("Content-Type"))
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	if string(content) != "# TYPE test_total counter\ntest_total 3\n" {
+		t.Errorf("Expected the metrics as the body, got '%s'", content)
+	}
+}
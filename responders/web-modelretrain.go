@@ -0,0 +1,64 @@
+package responders
+
+import (
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+var modelRetrainTemplate = template.Must(template.New("model-retrain").Funcs(template.FuncMap{
+	"FormatTime": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02 15:04:05")
+	},
+}).Parse(
+	`<html>
+<head>
+	<link href="https://fonts.googleapis.com/css?family=Roboto|Roboto+Slab" rel="stylesheet">
+	<link rel="stylesheet" type="text/css" href="/static/moonbird.css" />
+</head>
+<body class="predict-page">
+<h1>Retrain Model</h1>
+{{if .Started}}<div class="admin-panel">
+	<div class="retrain-started">Retrain of model {{.Options.Cutoff.Unix}} started; it is listed with the training runs once under way.</div>
+	<a href="/admin/training-runs">View training runs</a>
+</div>
+{{else if .NeedConfirmation}}<form class="admin-panel retrain-confirm" method="POST" action="/admin/ml-retrain">
+	<div>Start a retrain with these parameters?</div>
+	<table class="admin-table">
+		<tr><th>Cutoff</th><td>{{FormatTime .Options.Cutoff}} (model {{.Options.Cutoff.Unix}})</td></tr>
+		<tr><th>Base model</th><td>{{if .Options.FullRebuild}}none, full rebuild{{else if .Options.BaseModel}}{{.Options.BaseModel}}{{else}}latest{{end}}</td></tr>
+		<tr><th>Promote</th><td>{{if .Options.SkipPromotion}}no{{else}}yes{{end}}</td></tr>
+	</table>
+	<input type="hidden" name="base-model" value="{{.Input.BaseModelStr}}">
+	<input type="hidden" name="cutoff" value="{{.Input.CutoffStr}}">
+	{{if .Input.SkipPromotion}}<input type="hidden" name="skip-promotion" value="yes">{{end}}
+	{{if .Input.FullRebuild}}<input type="hidden" name="full-rebuild" value="yes">{{end}}
+	<input type="hidden" name="confirm" value="yes">
+	<input type="submit" value="Confirm retrain">
+	<a href="/admin/ml-retrain">Cancel</a>
+</form>
+{{else}}<form class="admin-panel retrain-form" method="POST" action="/admin/ml-retrain">
+	{{if .StartErr}}<div class="retrain-start-error">Unable to start retrain: {{.StartErr}}</div>{{end}}
+	{{range .ValidationErrs}}<div class="retrain-validation-error">{{.}}</div>{{end}}
+	<table class="admin-table">
+		<tr><th>Base model</th><td><input type="text" name="base-model" value="{{.Input.BaseModelStr}}" placeholder="Latest"></td></tr>
+		<tr><th>Cutoff (UTC)</th><td><input type="datetime-local" name="cutoff" value="{{.Input.CutoffStr}}"></td></tr>
+		<tr><th>Skip promotion</th><td><input type="checkbox" name="skip-promotion" value="yes"{{if .Input.SkipPromotion}} checked{{end}}></td></tr>
+		<tr><th>Full rebuild</th><td><input type="checkbox" name="full-rebuild" value="yes"{{if .Input.FullRebuild}} checked{{end}}></td></tr>
+	</table>
+	<input type="submit" value="Review retrain">
+</form>
+{{end}}
+</body>
+</html>`))
+
+type WebModelRetrainResponder struct{}
+
+func (_ *WebModelRetrainResponder) OnContextError(w http.ResponseWriter, err error) {
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebModelRetrainResponder) OnResult(w http.ResponseWriter, r *controllers.ModelRetrainManualResult) {
+	modelRetrainTemplate.Execute(w, r)
+}
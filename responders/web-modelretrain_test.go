@@ -0,0 +1,132 @@
+package responders
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"golang.org/x/net/html"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebModelRetrainResponder_OnResult_Form(t *testing.T) {
+	t.Parallel()
+
+	r := &WebModelRetrainResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, &controllers.ModelRetrainManualResult{
+		Input: &controllers.ModelRetrainManualInput{
+			Submitted:    true,
+			BaseModelStr: "bluh",
+		},
+		ValidationErrs: []string{"Base model must be a positive model number."},
+	})
+
+	result := recorder.Result()
+	if result.StatusCode != 200 {
+		t.Errorf("Expected a status code of 200, got %d", result.StatusCode)
+	}
+
+	pageHtml, _ := html.Parse(result.Body)
+	page := goquery.NewDocumentFromNode(pageHtml)
+
+	if forms := len(page.Find(".retrain-form").Nodes); forms != 1 {
+		t.Errorf("Expected page to contain 1 retrain form, found %d", forms)
+	}
+	if errs := len(page.Find(".retrain-validation-error").Nodes); errs != 1 {
+		t.Errorf("Expected page to contain 1 validation error, found %d", errs)
+	}
+	baseModel, _ := page.Find("input[name=base-model]").Attr("value")
+	if baseModel != "bluh" {
+		t.Errorf("Expected base model input to retain 'bluh', contained '%s'", baseModel)
+	}
+}
+
+func TestWebModelRetrainResponder_OnResult_Confirm(t *testing.T) {
+	t.Parallel()
+
+	r := &WebModelRetrainResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, &controllers.ModelRetrainManualResult{
+		Input: &controllers.ModelRetrainManualInput{
+			Submitted:     true,
+			BaseModelStr:  "123",
+			SkipPromotion: true,
+		},
+		Options: &data.RetrainOptions{
+			Cutoff:        time.Unix(500, 0),
+			BaseModel:     123,
+			SkipPromotion: true,
+		},
+		NeedConfirmation: true,
+	})
+
+	pageHtml, _ := html.Parse(recorder.Result().Body)
+	page := goquery.NewDocumentFromNode(pageHtml)
+
+	if forms := len(page.Find(".retrain-confirm").Nodes); forms != 1 {
+		t.Errorf("Expected page to contain 1 confirmation form, found %d", forms)
+	}
+	confirm, _ := page.Find("input[name=confirm]").Attr("value")
+	if confirm != "yes" {
+		t.Errorf("Expected confirmation form to confirm, had confirm value '%s'", confirm)
+	}
+	if skips := len(page.Find("input[name=skip-promotion]").Nodes); skips != 1 {
+		t.Errorf("Expected confirmation form to carry skip promotion, found %d fields", skips)
+	}
+	if rebuilds := len(page.Find("input[name=full-rebuild]").Nodes); rebuilds != 0 {
+		t.Errorf("Expected confirmation form not to carry full rebuild, found %d fields", rebuilds)
+	}
+}
+
+func TestWebModelRetrainResponder_OnResult_Started(t *testing.T) {
+	t.Parallel()
+
+	r := &WebModelRetrainResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, &controllers.ModelRetrainManualResult{
+		Input:   &controllers.ModelRetrainManualInput{Submitted: true, Confirmed: true},
+		Options: &data.RetrainOptions{Cutoff: time.Unix(500, 0)},
+		Started: true,
+	})
+
+	pageHtml, _ := html.Parse(recorder.Result().Body)
+	page := goquery.NewDocumentFromNode(pageHtml)
+
+	if started := page.Find(".retrain-started").Text(); !strings.Contains(started, "Retrain of model 500 started") {
+		t.Errorf("Expected page to report the retrain of model 500 started, showed '%s'", started)
+	}
+	if forms := len(page.Find("form").Nodes); forms != 0 {
+		t.Errorf("Expected page not to show a form, found %d forms", forms)
+	}
+}
+
+func TestWebModelRetrainResponder_OnResult_StartErr(t *testing.T) {
+	t.Parallel()
+
+	r := &WebModelRetrainResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, &controllers.ModelRetrainManualResult{
+		Input: &controllers.ModelRetrainManualInput{
+			Submitted: true,
+			Confirmed: true,
+		},
+		StartErr: "bluh",
+	})
+
+	pageHtml, _ := html.Parse(recorder.Result().Body)
+	page := goquery.NewDocumentFromNode(pageHtml)
+
+	if forms := len(page.Find(".retrain-form").Nodes); forms != 1 {
+		t.Errorf("Expected page to contain 1 retrain form, found %d", forms)
+	}
+	if text := page.Find(".retrain-start-error").Text(); !strings.Contains(text, "bluh") {
+		t.Errorf("Expected page to report the error starting the retrain, got '%s'", text)
+	}
+}
@@ -0,0 +1,101 @@
+package responders
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+var questionsTemplate = template.Must(template.New("questions").Funcs(template.FuncMap{
+	"FormatDate": func(t time.Time) string {
+		if t.IsZero() {
+			return "none"
+		}
+		return t.UTC().Format("2006-01-02")
+	},
+	"IsResolved": func(o data.QuestionOutcome) bool {
+		return o != data.Unresolved
+	},
+	"IsYes": func(o data.QuestionOutcome) bool {
+		return o == data.ResolvedYes
+	},
+}).Parse(
+	`<html>
+<head>
+	<link href="https://fonts.googleapis.com/css?family=Roboto|Roboto+Slab" rel="stylesheet">
+	<link rel="stylesheet" type="text/css" href="/static/moonbird.css" />
+</head>
+<body class="predict-page">
+<h1>Our Questions</h1>
+<form class="admin-panel question-create" method="POST" action="/questions">
+	{{range .ValidationErrs}}<div class="question-validation-error">{{.}}</div>{{end}}
+	<input type="hidden" name="action" value="create">
+	<input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+	<table class="admin-table">
+		<tr><th>Question</th><td><input type="text" name="title" placeholder="Will it happen?" class="prediction-text-input"></td></tr>
+		<tr><th>Deadline (UTC)</th><td><input type="date" name="deadline" required></td></tr>
+	</table>
+	<input type="submit" value="Ask question">
+</form>
+{{$csrfToken := .CSRFToken}}{{range .Questions}}<div class="admin-panel question" id="question{{.Id}}">
+	<div class="question-title">{{.Title}}</div>
+	<table class="admin-table">
+		<tr><th>Asked by</th><td>{{.Creator}}</td></tr>
+		<tr><th>Deadline</th><td>{{FormatDate .Deadline}}</td></tr>
+		<tr><th>Forecasts</th><td class="question-forecast-count">{{.ForecastCount}}</td></tr>
+		{{if .ForecastCount}}<tr><th>Mean probability</th><td>{{printf "%.3f" .MeanProbability}}</td></tr>{{end}}
+		{{if .UserForecast}}<tr><th>Your forecast</th><td class="question-user-forecast">{{printf "%.3f" .UserForecast.Probability}}</td></tr>{{end}}
+	</table>
+	{{if IsResolved .Outcome}}<div class="question-outcome">Resolved {{if IsYes .Outcome}}yes{{else}}no{{end}}</div>
+	{{else}}<form class="question-forecast" method="POST" action="/questions">
+		<input type="hidden" name="action" value="forecast">
+		<input type="hidden" name="csrf_token" value="{{$csrfToken}}">
+		<input type="hidden" name="question" value="{{.Id}}">
+		<input type="number" name="probability" min="0" max="1" step="0.01" placeholder="0.5">
+		<input type="submit" value="{{if .UserForecast}}Update forecast{{else}}Forecast{{end}}">
+	</form>
+	{{if .CanResolve}}<form class="question-resolve" method="POST" action="/questions">
+		<input type="hidden" name="action" value="resolve">
+		<input type="hidden" name="csrf_token" value="{{$csrfToken}}">
+		<input type="hidden" name="question" value="{{.Id}}">
+		<select name="outcome"><option value="yes">Yes</option><option value="no">No</option></select>
+		<input type="submit" value="Resolve">
+	</form>{{end}}{{end}}
+</div>
+{{end}}
+</body>
+</html>`))
+
+type WebQuestionsResponder struct{}
+
+func (_ *WebQuestionsResponder) OnContextError(w http.ResponseWriter, err error) {
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebQuestionsResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	l := ctxlogrus.Get(ctx)
+	l.Error(err)
+
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebQuestionsResponder) OnUnauthenticated(w http.ResponseWriter) {
+	http.Error(w, "Unauthorized", 401)
+}
+
+func (_ *WebQuestionsResponder) OnResult(w http.ResponseWriter, r *controllers.QuestionsResult) {
+	if r.NewCSRFToken {
+		http.SetCookie(w, &http.Cookie{
+			Name:     controllers.CSRFCookieName,
+			Value:    r.CSRFToken,
+			Path:     "/questions",
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		})
+	}
+	questionsTemplate.Execute(w, r)
+}
@@ -0,0 +1,115 @@
+package responders
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"golang.org/x/net/html"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebQuestionsResponder_OnUnauthenticated(t *testing.T) {
+	t.Parallel()
+
+	r := &WebQuestionsResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnUnauthenticated(recorder)
+
+	result := recorder.Result()
+	if result.StatusCode != 401 {
+		t.Errorf("Expected a status code of 401, got %d", result.StatusCode)
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	if string(content) != "Unauthorized\n" {
+		t.Errorf("Expected a body of 'Unauthorized\n', got '%s'", content)
+	}
+}
+
+func TestWebQuestionsResponder_OnResult(t *testing.T) {
+	t.Parallel()
+
+	r := &WebQuestionsResponder{}
+
+	userForecast := &data.Forecast{User: "bob", Probability: 0.25}
+	result := &controllers.QuestionsResult{
+		User:           "bob",
+		Input:          &controllers.QuestionsInput{},
+		ValidationErrs: []string{"Questions must have a title."},
+		Questions: []controllers.QuestionView{
+			{
+				InternalQuestion: data.InternalQuestion{
+					Question: data.Question{Id: -3, Title: "Someone else's"},
+				},
+			},
+			{
+				InternalQuestion: data.InternalQuestion{
+					Question: data.Question{Id: -2, Title: "Open", ForecastCount: 2, MeanProbability: 0.5},
+				},
+				UserForecast: userForecast,
+				CanResolve:   true,
+			},
+			{
+				InternalQuestion: data.InternalQuestion{
+					Question: data.Question{Id: -1, Title: "Resolved", Outcome: data.ResolvedNo},
+				},
+			},
+		},
+		CSRFToken:    "abc123",
+		NewCSRFToken: true,
+	}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, result)
+
+	response := recorder.Result()
+	if response.StatusCode != 200 {
+		t.Errorf("Expected a status code of 200, got %d", response.StatusCode)
+	}
+
+	pageHtml, _ := html.Parse(response.Body)
+	page := goquery.NewDocumentFromNode(pageHtml)
+
+	if errs := len(page.Find(".question-validation-error").Nodes); errs != 1 {
+		t.Errorf("Expected 1 validation error, found %d", errs)
+	}
+	if questions := len(page.Find(".question").Nodes); questions != 3 {
+		t.Fatalf("Expected 3 questions, found %d", questions)
+	}
+
+	cookies := response.Cookies()
+	if len(cookies) != 1 || cookies[0].Name != controllers.CSRFCookieName || cookies[0].Value != "abc123" || !cookies[0].HttpOnly {
+		t.Errorf("Expected the new CSRF token to be set as a cookie, got %v", cookies)
+	}
+	page.Find("form").Each(func(i int, form *goquery.Selection) {
+		if token, _ := form.Find("input[name=csrf_token]").Attr("value"); token != "abc123" {
+			t.Errorf("Expected form %d to include the CSRF token, got '%s'", i, token)
+		}
+	})
+
+	if len(page.Find("#question-3 .question-resolve").Nodes) != 0 {
+		t.Error("Expected no resolve form on a question the user can't resolve")
+	}
+
+	open := page.Find("#question-2")
+	if forecast := open.Find(".question-user-forecast").Text(); forecast != "0.250" {
+		t.Errorf("Expected user forecast '0.250', showed '%s'", forecast)
+	}
+	if len(open.Find(".question-forecast").Nodes) != 1 || len(open.Find(".question-resolve").Nodes) != 1 {
+		t.Error("Expected open question to have forecast and resolve forms")
+	}
+	if id, _ := open.Find(".question-forecast input[name=question]").Attr("value"); id != "-2" {
+		t.Errorf("Expected forecast form for question -2, got '%s'", id)
+	}
+
+	resolved := page.Find("#question-1")
+	if outcome := resolved.Find(".question-outcome").Text(); outcome != "Resolved no" {
+		t.Errorf("Expected outcome 'Resolved no', showed '%s'", outcome)
+	}
+	if len(resolved.Find("form").Nodes) != 0 {
+		t.Error("Expected resolved question to have no forms")
+	}
+}
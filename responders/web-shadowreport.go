@@ -0,0 +1,77 @@
+package responders
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+var shadowReportTemplate = template.Must(template.New("shadow-report").Funcs(template.FuncMap{
+	"FormatTime": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02 15:04:05")
+	},
+	"VersionName": func(version string) string {
+		if version == "" {
+			return "default"
+		}
+		return version
+	},
+	"Divergent": func(c data.ShadowComparison) bool {
+		return c.Err == "" && c.Divergence() > data.ShadowDivergenceThreshold
+	},
+}).Parse(
+	`<html>
+<head>
+	<link href="https://fonts.googleapis.com/css?family=Roboto|Roboto+Slab" rel="stylesheet">
+	<link rel="stylesheet" type="text/css" href="/static/moonbird.css" />
+</head>
+<body class="predict-page">
+<h1>Shadow Predictions</h1>
+{{range .Summaries}}<div class="admin-panel shadow-summary">
+	<h2 class="shadow-primary-version">Live version: {{VersionName .PrimaryVersion}}</h2>
+	<table class="admin-table">
+		<tr><th>Compared</th><td class="shadow-count">{{.Count}}</td></tr>
+		<tr><th>Shadow errors</th><td class="shadow-error-count">{{.ErrorCount}}</td></tr>
+		<tr><th>Mean divergence</th><td>{{printf "%.4f" .MeanDivergence}}</td></tr>
+		<tr><th>Max divergence</th><td>{{printf "%.4f" .MaxDivergence}}</td></tr>
+		<tr><th>Divergent</th><td class="shadow-divergent-count">{{.DivergentCount}}</td></tr>
+	</table>
+</div>
+{{end}}{{if .Comparisons}}<div class="admin-panel">
+	<table class="admin-table">
+		<tr><th>Time</th><th>Input hash</th><th>Live version</th><th>Shadow</th><th>Live</th><th>Shadow result</th><th>Divergence</th></tr>
+		{{range .Comparisons}}<tr class="shadow-comparison{{if .Err}} shadow-error{{else if Divergent .}} shadow-divergent{{end}}">
+			<td>{{FormatTime .Time}}</td>
+			<td>{{.InputHash}}</td>
+			<td>{{VersionName .PrimaryVersion}}</td>
+			<td>{{.ShadowVersion}}</td>
+			<td>{{printf "%.4f" .Primary}}</td>
+			{{if .Err}}<td colspan="2">{{.Err}}</td>{{else}}<td>{{printf "%.4f" .Shadow}}</td><td>{{printf "%.4f" .Divergence}}</td>{{end}}
+		</tr>
+		{{end}}
+	</table>
+</div>
+{{else}}<div class="admin-panel">No shadow predictions recorded.</div>{{end}}
+</body>
+</html>`))
+
+type WebShadowReportResponder struct{}
+
+func (_ *WebShadowReportResponder) OnContextError(w http.ResponseWriter, err error) {
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebShadowReportResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	l := ctxlogrus.Get(ctx)
+	l.Error(err)
+
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebShadowReportResponder) OnResult(w http.ResponseWriter, r *controllers.ShadowReportResult) {
+	shadowReportTemplate.Execute(w, r)
+}
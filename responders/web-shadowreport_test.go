@@ -0,0 +1,83 @@
+package responders
+
+import (
+	"context"
+	"errors"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"golang.org/x/net/html"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebShadowReportResponder_OnError(t *testing.T) {
+	t.Parallel()
+
+	r := &WebShadowReportResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnError(context.Background(), recorder, errors.New("bluh"))
+
+	result := recorder.Result()
+	if result.StatusCode != 500 {
+		t.Errorf("Expected a status code of 500, got %d", result.StatusCode)
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	if string(content) != "Internal Server Error\n" {
+		t.Errorf("Expected a body of 'Internal Server Error\n', got '%s'", content)
+	}
+}
+
+func TestWebShadowReportResponder_OnResult(t *testing.T) {
+	t.Parallel()
+
+	r := &WebShadowReportResponder{}
+
+	comparisons := []data.ShadowComparison{
+		{InputHash: "a", Primary: 0.3, Shadow: 0.5},
+		{InputHash: "b", Primary: 0.3, Shadow: 0.31},
+		{InputHash: "c", Primary: 0.3, Err: "bluh"},
+		{InputHash: "d", PrimaryVersion: "v700", Primary: 0.4, Shadow: 0.4},
+	}
+	reportResult := &controllers.ShadowReportResult{
+		Summaries:   data.SummarizeShadowComparisonsByPrimary(comparisons),
+		Comparisons: comparisons,
+	}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, reportResult)
+
+	result := recorder.Result()
+	if result.StatusCode != 200 {
+		t.Errorf("Expected a status code of 200, got %d", result.StatusCode)
+	}
+
+	pageHtml, _ := html.Parse(result.Body)
+	page := goquery.NewDocumentFromNode(pageHtml)
+
+	if rows := len(page.Find(".shadow-comparison").Nodes); rows != 4 {
+		t.Errorf("Expected page to contain 4 comparisons, found %d", rows)
+	}
+	if divergent := len(page.Find(".shadow-divergent").Nodes); divergent != 1 {
+		t.Errorf("Expected page to mark 1 comparison divergent, found %d", divergent)
+	}
+	if errored := len(page.Find(".shadow-error").Nodes); errored != 1 {
+		t.Errorf("Expected page to mark 1 comparison errored, found %d", errored)
+	}
+	summaries := page.Find(".shadow-summary")
+	if len(summaries.Nodes) != 2 {
+		t.Fatalf("Expected a summary for each live version, found %d", len(summaries.Nodes))
+	}
+	if version := summaries.First().Find(".shadow-primary-version").Text(); version != "Live version: default" {
+		t.Errorf("Expected the default version's summary first, showed '%s'", version)
+	}
+	if count := summaries.First().Find(".shadow-count").Text(); count != "2" {
+		t.Errorf("Expected default version's summary to show 2 compared, showed '%s'", count)
+	}
+	if version := summaries.Last().Find(".shadow-primary-version").Text(); version != "Live version: v700" {
+		t.Errorf("Expected the canary version's summary, showed '%s'", version)
+	}
+}
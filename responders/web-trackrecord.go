@@ -0,0 +1,61 @@
+package responders
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+var trackRecordTemplate = template.Must(template.New("track-record").Funcs(template.FuncMap{
+	"FormatTime": func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return t.UTC().Format("2006-01-02 15:04")
+	},
+}).Parse(
+	`<html>
+<head>
+	<link href="https://fonts.googleapis.com/css?family=Roboto|Roboto+Slab" rel="stylesheet">
+	<link rel="stylesheet" type="text/css" href="/static/moonbird.css" />
+</head>
+<body class="predict-page">
+<h1>Moonbird Track Record</h1>
+<div class="admin-panel">
+	<div>Moonbird's predictions for the examples shown on the front page, scored against how they resolved, and compared to simply averaging the probabilities people assigned. Lower scores are better. Last updated {{FormatTime .Updated}}. See also the <a href="/calibration">calibration chart</a>.</div>
+	<table class="admin-table">
+		<tr><th>Model</th><th>Resolved</th><th>Moonbird Brier</th><th>Average Brier</th><th>Moonbird log loss</th><th>Average log loss</th></tr>
+		{{with .Overall}}<tr class="track-record-overall"><td>All</td><td class="track-record-count">{{.Count}}</td><td>{{printf "%.4f" .Moonbird.Brier}}</td><td>{{printf "%.4f" .Baseline.Brier}}</td><td>{{printf "%.4f" .Moonbird.LogLoss}}</td><td>{{printf "%.4f" .Baseline.LogLoss}}</td></tr>{{end}}
+		{{range .Versions}}<tr class="track-record-version"><td>{{.ModelVersion}}</td><td class="track-record-count">{{.Count}}</td><td>{{printf "%.4f" .Moonbird.Brier}}</td><td>{{printf "%.4f" .Baseline.Brier}}</td><td>{{printf "%.4f" .Moonbird.LogLoss}}</td><td>{{printf "%.4f" .Baseline.LogLoss}}</td></tr>
+		{{end}}
+	</table>
+</div>
+<div class="admin-panel">
+	<table class="admin-table">
+		<tr><th>Predicted</th><th>Moonbird count</th><th>Moonbird observed</th><th>Average count</th><th>Average observed</th></tr>
+		{{$baseline := .Overall.Baseline.Calibration}}{{range $i, $bin := .Overall.Moonbird.Calibration}}<tr class="track-record-bin"><td>{{printf "%.1f" $bin.Lower}}&ndash;{{printf "%.1f" $bin.Upper}}</td><td>{{$bin.Count}}</td><td>{{if $bin.Count}}{{printf "%.3f" $bin.ObservedFrequency}}{{end}}</td>{{with index $baseline $i}}<td>{{.Count}}</td><td>{{if .Count}}{{printf "%.3f" .ObservedFrequency}}{{end}}</td>{{end}}</tr>
+		{{end}}
+	</table>
+</div>
+</body>
+</html>`))
+
+type WebTrackRecordResponder struct{}
+
+func (_ *WebTrackRecordResponder) OnContextError(w http.ResponseWriter, err error) {
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebTrackRecordResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	l := ctxlogrus.Get(ctx)
+	l.Error(err)
+
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebTrackRecordResponder) OnResult(w http.ResponseWriter, r *data.TrackRecord) {
+	trackRecordTemplate.Execute(w, r)
+}
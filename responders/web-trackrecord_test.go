@@ -0,0 +1,72 @@
+package responders
+
+import (
+	"context"
+	"errors"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/moonbird-predictor-frontend/scoring"
+	"golang.org/x/net/html"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebTrackRecordResponder_OnError(t *testing.T) {
+	t.Parallel()
+
+	r := &WebTrackRecordResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnError(context.Background(), recorder, errors.New("bluh"))
+
+	result := recorder.Result()
+	if result.StatusCode != 500 {
+		t.Errorf("Expected a status code of 500, got %d", result.StatusCode)
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	if string(content) != "Internal Server Error\n" {
+		t.Errorf("Expected a body of 'Internal Server Error\n', got '%s'", content)
+	}
+}
+
+func TestWebTrackRecordResponder_OnResult(t *testing.T) {
+	t.Parallel()
+
+	r := &WebTrackRecordResponder{}
+
+	forecasts := []scoring.Forecast{{Probability: 0.8, Outcome: true}, {Probability: 0.3, Outcome: false}}
+	record := &data.TrackRecord{
+		Overall: data.VersionTrackRecord{
+			Count:    2,
+			Moonbird: scoring.Score(forecasts, 5),
+			Baseline: scoring.Score(forecasts, 5),
+		},
+		Versions: []data.VersionTrackRecord{
+			{ModelVersion: "v500", Count: 1},
+			{ModelVersion: "v600", Count: 1},
+		},
+	}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, record)
+
+	result := recorder.Result()
+	if result.StatusCode != 200 {
+		t.Errorf("Expected a status code of 200, got %d", result.StatusCode)
+	}
+
+	pageHtml, _ := html.Parse(result.Body)
+	page := goquery.NewDocumentFromNode(pageHtml)
+
+	if count := page.Find(".track-record-overall .track-record-count").Text(); count != "2" {
+		t.Errorf("Expected overall count '2', showed '%s'", count)
+	}
+	if versions := len(page.Find(".track-record-version").Nodes); versions != 2 {
+		t.Errorf("Expected page to contain 2 versions, found %d", versions)
+	}
+	if bins := len(page.Find(".track-record-bin").Nodes); bins != 5 {
+		t.Errorf("Expected page to contain 5 calibration bins, found %d", bins)
+	}
+}
@@ -0,0 +1,68 @@
+package responders
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+var trainingRunsTemplate = template.Must(template.New("training-runs").Funcs(template.FuncMap{
+	"FormatTime": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format("2006-01-02 15:04:05")
+	},
+}).Parse(
+	`<html>
+<head>
+	<link href="https://fonts.googleapis.com/css?family=Roboto|Roboto+Slab" rel="stylesheet">
+	<link rel="stylesheet" type="text/css" href="/static/moonbird.css" />
+</head>
+<body class="predict-page">
+<h1>Training Runs</h1>
+{{if .Runs}}{{range .Runs}}<div class="admin-panel training-run">
+	<div class="training-run-title">Model {{.Model}} <span class="training-run-outcome training-run-{{.Outcome}}">{{.Outcome}}</span></div>
+	<table class="admin-table">
+		<tr><th>Base model</th><td>{{.BaseModel}}</td></tr>
+		<tr><th>Started</th><td>{{FormatTime .Started}}</td></tr>
+		<tr><th>Finished</th><td>{{FormatTime .Finished}}</td></tr>
+		<tr><th>Duration</th><td>{{.Duration}}</td></tr>
+		<tr><th>Promoted version</th><td class="training-run-promoted">{{.PromotedVersion}}</td></tr>
+		<tr><th>Potentially resolved</th><td>{{.PotentiallyResolvedCount}}</td></tr>
+		<tr><th>Newly resolved</th><td>{{.ResolvedCount}}</td></tr>
+		<tr><th>Still unresolved</th><td>{{.UnresolvedCount}}</td></tr>
+		<tr><th>Outstanding</th><td>{{.OutstandingCount}}</td></tr>
+		<tr><th>Responses</th><td>{{.ResponseCount}}</td></tr>
+		<tr><th>Train / CV / test</th><td>{{.TrainCount}} / {{.CvCount}} / {{.TestCount}}</td></tr>
+	</table>
+	<table class="admin-table training-run-stages">
+		<tr><th>Stage</th><th>Started</th><th>Duration</th><th>Error</th></tr>
+		{{range .Stages}}<tr class="training-run-stage"><td>{{.Name}}</td><td>{{FormatTime .Started}}</td><td>{{.Duration}}</td><td class="training-run-stage-error">{{.Err}}</td></tr>
+		{{end}}
+	</table>
+	{{if .Err}}<div class="training-run-error">{{.Err}}</div>{{end}}
+</div>
+{{end}}{{else}}<div class="admin-panel">No training runs recorded.</div>{{end}}
+</body>
+</html>`))
+
+type WebTrainingRunsResponder struct{}
+
+func (_ *WebTrainingRunsResponder) OnContextError(w http.ResponseWriter, err error) {
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebTrainingRunsResponder) OnError(ctx context.Context, w http.ResponseWriter, err error) {
+	l := ctxlogrus.Get(ctx)
+	l.Error(err)
+
+	http.Error(w, "Internal Server Error", 500)
+}
+
+func (_ *WebTrainingRunsResponder) OnResult(w http.ResponseWriter, r *controllers.TrainingRunsResult) {
+	trainingRunsTemplate.Execute(w, r)
+}
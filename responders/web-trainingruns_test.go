@@ -0,0 +1,92 @@
+package responders
+
+import (
+	"context"
+	"errors"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"golang.org/x/net/html"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebTrainingRunsResponder_OnError(t *testing.T) {
+	t.Parallel()
+
+	r := &WebTrainingRunsResponder{}
+
+	recorder := httptest.NewRecorder()
+	r.OnError(context.Background(), recorder, errors.New("bluh"))
+
+	result := recorder.Result()
+	if result.StatusCode != 500 {
+		t.Errorf("Expected a status code of 500, got %d", result.StatusCode)
+	}
+
+	content, _ := ioutil.ReadAll(result.Body)
+	if string(content) != "Internal Server Error\n" {
+		t.Errorf("Expected a body of 'Internal Server Error\n', got '%s'", content)
+	}
+}
+
+func TestWebTrainingRunsResponder_OnResult(t *testing.T) {
+	t.Parallel()
+
+	r := &WebTrainingRunsResponder{}
+
+	runsResult := &controllers.TrainingRunsResult{
+		Runs: []data.TrainingRunReport{
+			{
+				Model:           500,
+				Started:         time.Unix(500, 0),
+				Finished:        time.Unix(600, 0),
+				Outcome:         data.TrainingRunSucceeded,
+				PromotedVersion: "v500",
+				Stages: []data.TrainingRunStage{
+					{Name: "train", Started: time.Unix(500, 0), Finished: time.Unix(550, 0)},
+					{Name: "promote", Started: time.Unix(550, 0), Finished: time.Unix(600, 0)},
+				},
+			},
+			{
+				Model:   400,
+				Outcome: data.TrainingRunFailed,
+				Err:     "job failed",
+				Stages: []data.TrainingRunStage{
+					{Name: "train", Err: "job failed"},
+				},
+			},
+		},
+	}
+
+	recorder := httptest.NewRecorder()
+	r.OnResult(recorder, runsResult)
+
+	result := recorder.Result()
+	if result.StatusCode != 200 {
+		t.Errorf("Expected a status code of 200, got %d", result.StatusCode)
+	}
+
+	pageHtml, _ := html.Parse(result.Body)
+	page := goquery.NewDocumentFromNode(pageHtml)
+
+	runs := page.Find(".training-run")
+	if len(runs.Nodes) != 2 {
+		t.Fatalf("Expected page to contain 2 training runs, found %d", len(runs.Nodes))
+	}
+
+	firstRun := goquery.NewDocumentFromNode(runs.Nodes[0])
+	if stages := len(firstRun.Find(".training-run-stage").Nodes); stages != 2 {
+		t.Errorf("Expected first run to show 2 stages, found %d", stages)
+	}
+	if promoted := firstRun.Find(".training-run-promoted").Text(); promoted != "v500" {
+		t.Errorf("Expected first run to show promoted version 'v500', showed '%s'", promoted)
+	}
+
+	secondRun := goquery.NewDocumentFromNode(runs.Nodes[1])
+	if runErr := secondRun.Find(".training-run-error").Text(); runErr != "job failed" {
+		t.Errorf("Expected second run to show error 'job failed', showed '%s'", runErr)
+	}
+}
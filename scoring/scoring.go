@@ -0,0 +1,96 @@
+package scoring
+
+import (
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"math"
+)
+
+// Probabilities are clamped this far from 0 and 1 when computing log loss,
+// so a single confident miss doesn't make the loss infinite.
+const logLossEpsilon = 1e-15
+
+type Forecast struct {
+	Probability float64
+	Outcome     bool
+}
+
+func Brier(forecasts []Forecast) float64 {
+	if len(forecasts) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, f := range forecasts {
+		d := f.Probability - outcomeValue(f.Outcome)
+		total += d * d
+	}
+	return total / float64(len(forecasts))
+}
+
+func LogLoss(forecasts []Forecast) float64 {
+	if len(forecasts) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, f := range forecasts {
+		p := math.Min(math.Max(f.Probability, logLossEpsilon), 1-logLossEpsilon)
+		if f.Outcome {
+			total -= math.Log(p)
+		} else {
+			total -= math.Log(1 - p)
+		}
+	}
+	return total / float64(len(forecasts))
+}
+
+// Calibration divides [0, 1] into equal-width bins, and reports the mean forecast probability
+// and the frequency of the outcome among forecasts in each. A probability of exactly 1 falls in the last bin.
+func Calibration(forecasts []Forecast, bins int) []data.CalibrationBin {
+	if bins <= 0 {
+		return nil
+	}
+
+	result := make([]data.CalibrationBin, bins)
+	for i := range result {
+		result[i].Lower = float64(i) / float64(bins)
+		result[i].Upper = float64(i+1) / float64(bins)
+	}
+
+	for _, f := range forecasts {
+		i := int(f.Probability * float64(bins))
+		if i >= bins {
+			i = bins - 1
+		}
+		if i < 0 {
+			i = 0
+		}
+
+		result[i].Count++
+		result[i].MeanPredicted += f.Probability
+		result[i].ObservedFrequency += outcomeValue(f.Outcome)
+	}
+
+	for i := range result {
+		if result[i].Count > 0 {
+			result[i].MeanPredicted /= float64(result[i].Count)
+			result[i].ObservedFrequency /= float64(result[i].Count)
+		}
+	}
+	return result
+}
+
+func Score(forecasts []Forecast, bins int) data.ForecastScores {
+	return data.ForecastScores{
+		Brier:       Brier(forecasts),
+		LogLoss:     LogLoss(forecasts),
+		Calibration: Calibration(forecasts, bins),
+	}
+}
+
+func outcomeValue(outcome bool) float64 {
+	if outcome {
+		return 1
+	}
+	return 0
+}
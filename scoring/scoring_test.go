@@ -0,0 +1,67 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestBrier(t *testing.T) {
+	t.Parallel()
+
+	forecasts := []Forecast{
+		{Probability: 0.8, Outcome: true},
+		{Probability: 0.4, Outcome: false},
+	}
+	if b := Brier(forecasts); !approxEqual(b, (0.04+0.16)/2) {
+		t.Errorf("Expected Brier score of 0.1, got %g", b)
+	}
+	if b := Brier(nil); b != 0 {
+		t.Errorf("Expected Brier score of 0 for no forecasts, got %g", b)
+	}
+}
+
+func TestLogLoss(t *testing.T) {
+	t.Parallel()
+
+	forecasts := []Forecast{
+		{Probability: 0.8, Outcome: true},
+		{Probability: 0.4, Outcome: false},
+	}
+	expected := -(math.Log(0.8) + math.Log(0.6)) / 2
+	if l := LogLoss(forecasts); !approxEqual(l, expected) {
+		t.Errorf("Expected log loss of %g, got %g", expected, l)
+	}
+
+	if l := LogLoss([]Forecast{{Probability: 0, Outcome: true}}); math.IsInf(l, 0) || math.IsNaN(l) {
+		t.Errorf("Expected finite log loss for a certain miss, got %g", l)
+	}
+}
+
+func TestCalibration(t *testing.T) {
+	t.Parallel()
+
+	forecasts := []Forecast{
+		{Probability: 0.1, Outcome: false},
+		{Probability: 0.2, Outcome: true},
+		{Probability: 0.9, Outcome: true},
+		{Probability: 1, Outcome: true},
+	}
+	bins := Calibration(forecasts, 4)
+	if len(bins) != 4 {
+		t.Fatalf("Expected 4 bins, got %d", len(bins))
+	}
+
+	if bins[0].Count != 2 || !approxEqual(bins[0].MeanPredicted, 0.15) || !approxEqual(bins[0].ObservedFrequency, 0.5) {
+		t.Errorf("Unexpected first bin: %+v", bins[0])
+	}
+	if bins[1].Count != 0 || bins[2].Count != 0 {
+		t.Errorf("Expected middle bins to be empty, got %+v, %+v", bins[1], bins[2])
+	}
+	if bins[3].Count != 2 || !approxEqual(bins[3].ObservedFrequency, 1) || bins[3].Upper != 1 {
+		t.Errorf("Unexpected last bin: %+v", bins[3])
+	}
+}
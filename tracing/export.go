@@ -0,0 +1,219 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const scopeName = "github.com/jbeshir/moonbird-predictor-frontend/tracing"
+
+// SpanData is a finished span, as exported.
+type SpanData struct {
+	ServiceName string
+	Context     SpanContext
+	ParentID    SpanID
+	Name        string
+	Kind        SpanKind
+	Start       time.Time
+	End         time.Time
+	Attributes  []Attribute
+	Err         string
+}
+
+func (s *Span) data(serviceName string) SpanData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return SpanData{
+		ServiceName: serviceName,
+		Context:     s.context,
+		ParentID:    s.parentID,
+		Name:        s.name,
+		Kind:        s.kind,
+		Start:       s.start,
+		End:         s.end,
+		Attributes:  append([]Attribute(nil), s.attributes...),
+		Err:         s.err,
+	}
+}
+
+// WriterExporter writes each batch of spans as a line of OTLP JSON, such as to stdout.
+type WriterExporter struct {
+	Writer io.Writer
+
+	mu sync.Mutex
+}
+
+func (e *WriterExporter) Export(ctx context.Context, spans []SpanData) error {
+	content, err := MarshalOTLP(spans)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.Writer.Write(append(content, '\n'))
+	return errors.Wrap(err, "")
+}
+
+// HTTPExporter posts each batch of spans as OTLP JSON to a collector's traces endpoint,
+// such as http://localhost:4318/v1/traces for a local OpenTelemetry collector.
+type HTTPExporter struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+func (e *HTTPExporter) Export(ctx context.Context, spans []SpanData) error {
+	content, err := MarshalOTLP(spans)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	req, err := http.NewRequest("POST", e.Endpoint, bytes.NewReader(content))
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("collector responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// The OTLP JSON encoding, as specified by the OpenTelemetry protocol.
+// IDs are hex, and 64-bit integers are strings.
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Status codes, numbered as in OTLP.
+const otlpStatusError = 2
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+// MarshalOTLP encodes spans as an OTLP JSON export request, grouped by service.
+func MarshalOTLP(spans []SpanData) ([]byte, error) {
+	var traces otlpTraces
+	byService := make(map[string]int)
+	for _, s := range spans {
+		i, ok := byService[s.ServiceName]
+		if !ok {
+			i = len(traces.ResourceSpans)
+			byService[s.ServiceName] = i
+			traces.ResourceSpans = append(traces.ResourceSpans, otlpResourceSpans{
+				Resource: otlpResource{
+					Attributes: []otlpAttribute{toOTLPAttribute(String("service.name", s.ServiceName))},
+				},
+				ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}}},
+			})
+		}
+
+		scopeSpans := &traces.ResourceSpans[i].ScopeSpans[0]
+		scopeSpans.Spans = append(scopeSpans.Spans, toOTLPSpan(s))
+	}
+
+	content, err := json.Marshal(&traces)
+	return content, errors.Wrap(err, "")
+}
+
+func toOTLPSpan(s SpanData) otlpSpan {
+	span := otlpSpan{
+		TraceID:           s.Context.TraceID.String(),
+		SpanID:            s.Context.SpanID.String(),
+		Name:              s.Name,
+		Kind:              s.Kind,
+		StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+	}
+	if s.ParentID.IsValid() {
+		span.ParentSpanID = s.ParentID.String()
+	}
+	for _, a := range s.Attributes {
+		span.Attributes = append(span.Attributes, toOTLPAttribute(a))
+	}
+	if s.Err != "" {
+		span.Status = otlpStatus{Code: otlpStatusError, Message: s.Err}
+	}
+	return span
+}
+
+func toOTLPAttribute(a Attribute) otlpAttribute {
+	var v otlpValue
+	switch value := a.Value.(type) {
+	case string:
+		v.StringValue = &value
+	case int64:
+		s := strconv.FormatInt(value, 10)
+		v.IntValue = &s
+	case float64:
+		v.DoubleValue = &value
+	case bool:
+		v.BoolValue = &value
+	}
+	return otlpAttribute{Key: a.Key, Value: v}
+}
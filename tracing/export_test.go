@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSpanData() []SpanData {
+	return []SpanData{
+		{
+			ServiceName: "test-service",
+			Context: SpanContext{
+				TraceID: TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+				SpanID:  SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+				Sampled: true,
+			},
+			ParentID:   SpanID{0x01},
+			Name:       "PredictionMaker.PredictVersion",
+			Kind:       SpanKindInternal,
+			Start:      time.Unix(1, 0),
+			End:        time.Unix(2, 500),
+			Attributes: []Attribute{String("model.version", "v2"), Int("assignments", 3), Float("p", 0.5), Bool("cached", true)},
+			Err:        "bluh",
+		},
+	}
+}
+
+const testSpanJSON = `{"resourceSpans":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"test-service"}}]},` +
+	`"scopeSpans":[{"scope":{"name":"github.com/jbeshir/moonbird-predictor-frontend/tracing"},"spans":[{` +
+	`"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"00f067aa0ba902b7","parentSpanId":"0100000000000000",` +
+	`"name":"PredictionMaker.PredictVersion","kind":1,"startTimeUnixNano":"1000000000","endTimeUnixNano":"2000000500",` +
+	`"attributes":[{"key":"model.version","value":{"stringValue":"v2"}},{"key":"assignments","value":{"intValue":"3"}},` +
+	`{"key":"p","value":{"doubleValue":0.5}},{"key":"cached","value":{"boolValue":true}}],` +
+	`"status":{"code":2,"message":"bluh"}}]}]}]}`
+
+func TestMarshalOTLP(t *testing.T) {
+	t.Parallel()
+
+	content, err := MarshalOTLP(testSpanData())
+	if err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+	if string(content) != testSpanJSON {
+		t.Errorf("Expected:\n%s\ngot:\n%s", testSpanJSON, content)
+	}
+}
+
+func TestWriterExporter_Export(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	e := &WriterExporter{Writer: &buf}
+	if err := e.Export(context.Background(), testSpanData()); err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+	if buf.String() != testSpanJSON+"\n" {
+		t.Errorf("Expected a line of OTLP JSON, got:\n%s", buf.String())
+	}
+}
+
+func TestHTTPExporter_Export(t *testing.T) {
+	t.Parallel()
+
+	var received []byte
+	status := 200
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a POST of JSON to /v1/traces, got %s %s of %s", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		received, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	e := &HTTPExporter{Endpoint: srv.URL + "/v1/traces"}
+	if err := e.Export(context.Background(), testSpanData()); err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(received, &decoded); err != nil || !strings.Contains(string(received), "PredictionMaker.PredictVersion") {
+		t.Errorf("Expected the collector to receive the spans as JSON, got %s", received)
+	}
+
+	status = 503
+	if err := e.Export(context.Background(), testSpanData()); err == nil {
+		t.Error("Expected an error when the collector fails, got nil")
+	}
+}
+
+func TestTracer_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	tr, e := newTestTracer(t, 1)
+	ctx := WithTracer(context.Background(), tr)
+	for i := 0; i < 3; i++ {
+		_, span := Start(ctx, "work")
+		span.End()
+	}
+
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+	if len(e.exported()) != 3 {
+		t.Errorf("Expected 3 spans exported on shutdown, got %d", len(e.exported()))
+	}
+
+	// Further flushes and shutdowns do nothing.
+	if err := tr.Flush(context.Background()); err != nil {
+		t.Errorf("Expected nil err from flush after shutdown, got %s", err)
+	}
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected nil err from second shutdown, got %s", err)
+	}
+}
@@ -0,0 +1,141 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// traceparentHeader carries the trace across processes, as specified by W3C Trace Context.
+const traceparentHeader = "traceparent"
+
+// Extract returns the span context sent in the request headers, if there is a valid one.
+func Extract(h http.Header) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(h.Get(traceparentHeader)), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	// Version 00 has exactly four fields; later versions may add more.
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return SpanContext{}, false
+	}
+	if !sc.TraceID.IsValid() || !sc.SpanID.IsValid() {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, true
+}
+
+// Inject sets the request headers to continue the current span's trace, if there is one.
+func Inject(ctx context.Context, h http.Header) {
+	s := SpanFromContext(ctx)
+	if s == nil {
+		return
+	}
+
+	flags := "00"
+	if s.context.Sampled {
+		flags = "01"
+	}
+	h.Set(traceparentHeader, "00-"+s.context.TraceID.String()+"-"+s.context.SpanID.String()+"-"+flags)
+}
+
+// Handler traces each request to h as a span named for its route, continuing any trace the caller sent.
+// The span is current in the request's context, for contexts made from it. With a nil tracer, it returns h.
+func Handler(t *Tracer, route string, h http.Handler) http.Handler {
+	if t == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parent, _ := Extract(r.Header)
+		ctx, span := startSpan(r.Context(), t, parent, r.Method+" "+route, SpanKindServer, []Attribute{
+			String("http.method", r.Method),
+			String("http.route", route),
+			String("http.target", r.URL.Path),
+		})
+		defer span.End()
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(sw, r.WithContext(ctx))
+
+		span.SetAttributes(Int("http.status_code", int64(sw.status)))
+		if sw.status >= 500 {
+			span.SetError(errStatus(sw.status))
+		}
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+type errStatus int
+
+func (e errStatus) Error() string {
+	return http.StatusText(int(e))
+}
+
+// Transport traces each request made through it as a client span, and sends the trace on with it.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx, span := StartKind(r.Context(), r.Method+" "+r.URL.Host, SpanKindClient,
+		String("http.method", r.Method),
+		String("http.url", r.URL.Scheme+"://"+r.URL.Host+r.URL.Path))
+	if span == nil {
+		return base.RoundTrip(r)
+	}
+	defer span.End()
+
+	// RoundTrippers mustn't modify the request they're given, so the header is set on a copy.
+	r = r.WithContext(ctx)
+	r.Header = cloneHeader(r.Header)
+	Inject(ctx, r.Header)
+
+	resp, err := base.RoundTrip(r)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+	span.SetAttributes(Int("http.status_code", int64(resp.StatusCode)))
+	if resp.StatusCode >= 400 {
+		span.SetError(errStatus(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+func cloneHeader(h http.Header) http.Header {
+	clone := make(http.Header, len(h))
+	for k, v := range h {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
+}
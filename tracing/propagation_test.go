@@ -0,0 +1,149 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header  string
+		valid   bool
+		sampled bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false, false},
+	}
+
+	for _, test := range tests {
+		h := make(http.Header)
+		h.Set("traceparent", test.header)
+		sc, ok := Extract(h)
+		if ok != test.valid {
+			t.Errorf("%q: expected valid %v, got %v", test.header, test.valid, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || sc.Sampled != test.sampled {
+			t.Errorf("%q: got wrong span context %+v", test.header, sc)
+		}
+	}
+}
+
+func TestInject(t *testing.T) {
+	t.Parallel()
+
+	tr, _ := newTestTracer(t, 1)
+	defer tr.Shutdown(context.Background())
+
+	h := make(http.Header)
+	Inject(context.Background(), h)
+	if h.Get("traceparent") != "" {
+		t.Errorf("Expected no traceparent without a span, got %s", h.Get("traceparent"))
+	}
+
+	ctx, span := Start(WithTracer(context.Background(), tr), "work")
+	Inject(ctx, h)
+	sc, ok := Extract(h)
+	if !ok || sc != span.SpanContext() {
+		t.Errorf("Expected the injected header to extract as the span, got %s", h.Get("traceparent"))
+	}
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	tr, e := newTestTracer(t, 0)
+	defer tr.Shutdown(context.Background())
+
+	var handlerSpan *Span
+	h := Handler(tr, "/examples/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = SpanFromContext(r.Context())
+		http.Error(w, "Internal Server Error", 500)
+	}))
+
+	// The caller sampled the trace, so we do too, despite our own ratio of 0.
+	r := httptest.NewRequest("GET", "/examples/3", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, r)
+
+	if recorder.Code != 500 {
+		t.Errorf("Expected the handler's status to be passed through, got %d", recorder.Code)
+	}
+	if handlerSpan == nil {
+		t.Fatal("Expected a span in the request context, got none")
+	}
+
+	flush(t, tr)
+	spans := e.exported()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	s := spans[0]
+	if s.Name != "GET /examples/" || s.Kind != SpanKindServer {
+		t.Errorf("Expected a server span named for the route, got %s of kind %d", s.Name, s.Kind)
+	}
+	if s.Context.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || s.ParentID.String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected the span to continue the caller's trace, got %+v", s.Context)
+	}
+	if s.Err == "" {
+		t.Error("Expected the 500 response to mark the span as failed")
+	}
+
+	mux := http.NewServeMux()
+	if Handler(nil, "/", mux) != mux {
+		t.Error("Expected no wrapping without a tracer")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	tr, e := newTestTracer(t, 1)
+	defer tr.Shutdown(context.Background())
+
+	var received http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{}}
+	ctx, parent := Start(WithTracer(context.Background(), tr), "parent")
+	req, _ := http.NewRequest("GET", srv.URL+"/v1/models", nil)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	if req.Header.Get("traceparent") != "" {
+		t.Error("Expected the caller's request to be left unmodified")
+	}
+	sc, ok := Extract(received)
+	if !ok || sc.TraceID != parent.SpanContext().TraceID || sc.SpanID == parent.SpanContext().SpanID {
+		t.Errorf("Expected the server to receive the client span's context, got %q", received.Get("traceparent"))
+	}
+
+	flush(t, tr)
+	spans := e.exported()
+	if len(spans) != 2 || spans[0].Kind != SpanKindClient || spans[0].ParentID != parent.SpanContext().SpanID {
+		t.Errorf("Expected a client span under the parent, got %+v", spans)
+	}
+}
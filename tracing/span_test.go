@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (e *testExporter) Export(ctx context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *testExporter) exported() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+func newTestTracer(t *testing.T, sampleRatio float64) (*Tracer, *testExporter) {
+	e := &testExporter{}
+	tr := NewTracer("test-service", e, sampleRatio)
+	return tr, e
+}
+
+func flush(t *testing.T, tr *Tracer) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tr.Flush(ctx); err != nil {
+		t.Fatalf("Expected nil err from Flush, got %s", err)
+	}
+}
+
+func TestStart_NoTracer(t *testing.T) {
+	t.Parallel()
+
+	ctx, span := Start(context.Background(), "work")
+	if span != nil {
+		t.Errorf("Expected a nil span without a tracer, got %+v", span)
+	}
+	if SpanFromContext(ctx) != nil {
+		t.Error("Expected no span in the context")
+	}
+
+	// A nil span must be safe to use as any other.
+	span.SetAttributes(String("key", "value"))
+	span.SetError(errors.New("bluh"))
+	span.End()
+}
+
+func TestStart_Nested(t *testing.T) {
+	t.Parallel()
+
+	tr, e := newTestTracer(t, 1)
+	defer tr.Shutdown(context.Background())
+
+	ctx := WithTracer(context.Background(), tr)
+	ctx, root := Start(ctx, "root", String("key", "value"))
+	_, child := Start(ctx, "child")
+	child.SetAttributes(Int("count", 3))
+	child.SetError(errors.New("bluh"))
+	child.End()
+	root.End()
+	root.End()
+
+	flush(t, tr)
+	spans := e.exported()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	gotChild, gotRoot := spans[0], spans[1]
+	if gotRoot.Name != "root" || gotChild.Name != "child" {
+		t.Errorf("Expected child then root to be exported, got %s then %s", gotChild.Name, gotRoot.Name)
+	}
+	if gotChild.Context.TraceID != gotRoot.Context.TraceID {
+		t.Error("Expected child to share its parent's trace")
+	}
+	if gotChild.ParentID != gotRoot.Context.SpanID || gotRoot.ParentID.IsValid() {
+		t.Error("Expected child to be parented by root, and root to have no parent")
+	}
+	if gotChild.Err != "bluh" || gotRoot.Err != "" {
+		t.Errorf("Expected only the child to have failed, got %q and %q", gotChild.Err, gotRoot.Err)
+	}
+	if len(gotChild.Attributes) != 1 || gotChild.Attributes[0] != Int("count", 3) {
+		t.Errorf("Expected child attributes to be recorded, got %v", gotChild.Attributes)
+	}
+	if gotRoot.ServiceName != "test-service" || gotRoot.End.Before(gotRoot.Start) {
+		t.Errorf("Expected a timed span for the service, got %+v", gotRoot)
+	}
+}
+
+func TestStart_Unsampled(t *testing.T) {
+	t.Parallel()
+
+	tr, e := newTestTracer(t, 0)
+	defer tr.Shutdown(context.Background())
+
+	ctx := WithTracer(context.Background(), tr)
+	ctx, root := Start(ctx, "root")
+	_, child := Start(ctx, "child")
+	if child == nil || child.SpanContext().TraceID != root.SpanContext().TraceID || child.SpanContext().Sampled {
+		t.Error("Expected an unsampled child span continuing the trace")
+	}
+	child.End()
+	root.End()
+
+	flush(t, tr)
+	if len(e.exported()) != 0 {
+		t.Errorf("Expected no spans to be exported, got %v", e.exported())
+	}
+}
@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	defaultQueueSize     = 2048
+	defaultBatchSize     = 512
+	defaultFlushInterval = 5 * time.Second
+)
+
+// Exporter sends finished spans somewhere they can be viewed.
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+}
+
+// Tracer starts traces, and exports their finished spans in batches from the background.
+// Spans are dropped, rather than slowing requests, if the exporter falls behind.
+type Tracer struct {
+	serviceName string
+	exporter    Exporter
+	sampleRatio float64
+
+	queue   chan *Span
+	flushes chan chan struct{}
+	done    chan struct{}
+
+	stopOnce sync.Once
+}
+
+// NewTracer starts a tracer exporting to exporter, sampling sampleRatio of new traces.
+// Traces continued from another process are sampled if they were there.
+func NewTracer(serviceName string, exporter Exporter, sampleRatio float64) *Tracer {
+	t := &Tracer{
+		serviceName: serviceName,
+		exporter:    exporter,
+		sampleRatio: sampleRatio,
+		queue:       make(chan *Span, defaultQueueSize),
+		flushes:     make(chan chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+func (t *Tracer) sample(id TraceID) bool {
+	return traceIDFraction(id) < t.sampleRatio
+}
+
+func (t *Tracer) enqueue(s *Span) {
+	select {
+	case t.queue <- s:
+	default:
+		log.Printf("Trace export queue full, dropping span %s", s.name)
+	}
+}
+
+// Flush exports every span finished so far, waiting until done or ctx is.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+
+	flushed := make(chan struct{})
+	select {
+	case t.flushes <- flushed:
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "")
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "")
+	}
+}
+
+// Shutdown flushes every span finished so far, and stops exporting.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+
+	err := t.Flush(ctx)
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+	return err
+}
+
+func (t *Tracer) run() {
+	ticker := time.NewTicker(defaultFlushInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	export := func() {
+		if len(batch) > 0 {
+			t.export(batch)
+			batch = nil
+		}
+	}
+
+	for {
+		select {
+		case s := <-t.queue:
+			batch = append(batch, s)
+			if len(batch) >= defaultBatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case flushed := <-t.flushes:
+			// Take everything queued before the flush was asked for.
+			for len(t.queue) > 0 {
+				batch = append(batch, <-t.queue)
+			}
+			export()
+			close(flushed)
+		case <-t.done:
+			return
+		}
+	}
+}
+
+func (t *Tracer) export(batch []*Span) {
+	spans := make([]SpanData, len(batch))
+	for i, s := range batch {
+		spans[i] = s.data(t.serviceName)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := t.exporter.Export(ctx, spans); err != nil {
+		log.Printf("Unable to export %d spans: %s", len(spans), err)
+	}
+}
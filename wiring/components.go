@@ -0,0 +1,302 @@
+package wiring
+
+import (
+	"github.com/jbeshir/moonbird-auth-frontend/aengine"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"github.com/jbeshir/moonbird-predictor-frontend/forecasting"
+	"github.com/jbeshir/moonbird-predictor-frontend/localstore"
+	"github.com/jbeshir/moonbird-predictor-frontend/mlclient"
+	"github.com/jbeshir/moonbird-predictor-frontend/pbook"
+	"github.com/jbeshir/moonbird-predictor-frontend/requestlog"
+	"github.com/jbeshir/moonbird-predictor-frontend/responders"
+	"github.com/jbeshir/moonbird-predictor-frontend/tracing"
+	"github.com/jbeshir/predictionbook-extractor/htmlfetcher"
+	"github.com/jbeshir/predictionbook-extractor/predictions"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/ml/v1"
+	"google.golang.org/api/storage/v1"
+	"google.golang.org/appengine/memcache"
+	"net/http"
+	"time"
+)
+
+// Components are the services making up the predictor, wired together for a platform.
+// The server and the moonbird command share them, so both act on the same stores the same way.
+type Components struct {
+	Platform *Platform
+	Metrics  *Metrics
+
+	// Tracer is nil if tracing is off.
+	Tracer *tracing.Tracer
+
+	ForecastSource    forecasting.Source
+	MergedSource      forecasting.Source
+	InternalQuestions *forecasting.InternalQuestions
+	ExampleLister     *pbook.Lister
+	ExampleCache      CacheStore
+	ExampleArchive    *pbook.Archive
+	TrackRecorder     *pbook.TrackRecorder
+	PredictionCache   CacheStore
+	PredictionMaker   *mlclient.PredictionMaker
+	ShadowLog         *mlclient.ShadowLog
+	Trainer           *mlclient.Trainer
+	ExamplesUpdate    *controllers.ExamplesUpdate
+}
+
+func NewComponents(p *Platform) *Components {
+	cfg := p.Config
+	c := &Components{
+		Platform: p,
+		Metrics:  NewMetrics(),
+		Tracer:   newTracer(cfg.Tracing),
+	}
+
+	c.ForecastSource = &forecasting.PredictionBook{
+		Source: predictions.NewSource(
+			htmlfetcher.NewFetcher(
+				rate.NewLimiter(rate.Limit(cfg.PredictionBook.RequestsPerSecond), cfg.PredictionBook.Burst),
+				cfg.PredictionBook.Concurrency),
+			cfg.PredictionBook.URL),
+	}
+	if cfg.Source.Dir != "" {
+		c.ForecastSource = &forecasting.FileSource{
+			FileStore: &localstore.FileStore{Dir: cfg.Source.Dir},
+			Format:    forecasting.DumpFormat(cfg.Source.Format),
+		}
+	}
+	c.InternalQuestions = &forecasting.InternalQuestions{
+		PersistentStore: c.newPersistentStore(cfg.Storage.QuestionsPrefix),
+	}
+	c.MergedSource = &forecasting.MergedSource{
+		External: c.ForecastSource,
+		Internal: c.InternalQuestions,
+	}
+	pbSource := &forecasting.SourceAdapter{
+		Source: c.MergedSource,
+	}
+
+	trainingSource := &forecasting.SourceAdapter{
+		Source: c.ForecastSource,
+	}
+	if cfg.Trainer.TrainOnInternalQuestions {
+		trainingSource = pbSource
+	}
+
+	mlEngine := mlclient.MLEngine{
+		Project:        cfg.MLEngine.Project,
+		Region:         cfg.MLEngine.Region,
+		RuntimeVersion: cfg.MLEngine.RuntimeVersion,
+	}
+
+	c.PredictionCache = c.newCacheStore(cfg.Storage.PredictionsPrefix, aengine.BinaryMemcacheCodec)
+	c.PredictionMaker = &mlclient.PredictionMaker{
+		CacheStorage:    c.PredictionCache,
+		HttpClientMaker: c.newClientMaker(ml.CloudPlatformScope),
+		MLEngine:        mlEngine,
+		CanaryVersion:   cfg.Predictor.CanaryVersion,
+		CanaryFraction:  cfg.Predictor.CanaryFraction,
+		Metrics:         c.Metrics,
+	}
+
+	// App Engine instances stop without telling us, so only standalone comparisons are buffered, and flushed as we stop.
+	c.ShadowLog = &mlclient.ShadowLog{
+		PersistentStore: c.newPersistentStore(cfg.Storage.ModelsPrefix),
+		Buffered:        c.Platform.Standalone,
+	}
+	if shadowVersion := cfg.Predictor.ShadowVersion; shadowVersion != "" {
+		c.PredictionMaker.Shadow = &mlclient.VersionPredictor{
+			PredictionMaker: &mlclient.PredictionMaker{
+				CacheStorage:    c.PredictionMaker.CacheStorage,
+				HttpClientMaker: c.PredictionMaker.HttpClientMaker,
+				MLEngine:        mlEngine,
+			},
+			Version: shadowVersion,
+		}
+		c.PredictionMaker.ShadowName = shadowVersion
+		c.PredictionMaker.ShadowRecorder = c.ShadowLog
+		c.PredictionMaker.Background = c.Platform.Background
+	}
+
+	c.ExampleCache = c.newCacheStore(cfg.Storage.ExamplesPrefix, memcache.Gob)
+	c.ExampleLister = &pbook.Lister{
+		PredictionSource: pbSource,
+		CacheStore:       c.ExampleCache,
+		PersistentStore:  c.newPersistentStore(cfg.Storage.ExamplesPrefix),
+		HistoryStore:     c.newPersistentStore(cfg.Storage.ExamplesPrefix),
+		PredictionMaker:  c.PredictionMaker,
+		TargetExamples:   cfg.Examples.TargetExamples,
+		MaxPages:         cfg.Examples.MaxPages,
+		PageLimiter:      rate.NewLimiter(rate.Every(cfg.Examples.PageInterval), 1),
+		Selection: pbook.SelectionPolicy{
+			MinDistinctAssignments: cfg.Examples.MinDistinctAssignments,
+			MaxTimeToDeadline:      cfg.Examples.MaxTimeToDeadline,
+			ExcludeCreators:        cfg.Examples.ExcludeCreators,
+			ExcludeTitleKeywords:   cfg.Examples.ExcludeTitleKeywords,
+			RankByWagerCount:       cfg.Examples.RankByWagerCount,
+			MaxExamples:            cfg.Examples.MaxExamples,
+		},
+		DuplicateResponses: cfg.Examples.DuplicateResponses,
+	}
+
+	c.Trainer = &mlclient.Trainer{
+		PersistentStore:    c.newPersistentStore(cfg.Storage.ModelsPrefix),
+		FileStore:          p.FileStore,
+		PredictionSource:   trainingSource,
+		ModelPath:          cfg.Trainer.ModelPath,
+		DataPath:           cfg.Trainer.DataPath,
+		SleepFunc:          time.Sleep,
+		NowFunc:            time.Now,
+		LeaseDuration:      cfg.Trainer.LeaseDuration,
+		TrainPackage:       cfg.Trainer.TrainPackage,
+		DuplicateResponses: cfg.Trainer.DuplicateResponses,
+		HttpClientMaker:    c.newClientMaker(ml.CloudPlatformScope, storage.CloudPlatformScope),
+		MLEngine:           mlEngine,
+		Stopping:           p.Stopping,
+		Metrics:            c.Metrics,
+
+		TrainOnInternalQuestions: cfg.Trainer.TrainOnInternalQuestions,
+	}
+	c.ExampleArchive = &pbook.Archive{
+		PersistentStore: c.newPersistentStore(cfg.Storage.ExamplesPrefix),
+	}
+	c.ExamplesUpdate = &controllers.ExamplesUpdate{
+		ExampleLister:   c.ExampleLister,
+		Archive:         c.ExampleArchive,
+		PredictionMaker: c.PredictionMaker,
+		ModelVersioner:  c.Trainer,
+		Metrics:         c.Metrics,
+	}
+
+	c.TrackRecorder = &pbook.TrackRecorder{
+		Archive:          c.ExampleArchive,
+		PredictionSource: pbSource,
+		PersistentStore:  c.newPersistentStore(cfg.Storage.ExamplesPrefix),
+	}
+
+	return c
+}
+
+func (c *Components) newCacheStore(prefix string, codec memcache.Codec) CacheStore {
+	cs := c.Platform.NewCacheStore(prefix, codec)
+	if c.Tracer == nil {
+		return cs
+	}
+	return &tracedCacheStore{CacheStore: cs, Prefix: prefix}
+}
+
+func (c *Components) newPersistentStore(prefix string) PersistentStore {
+	ps := c.Platform.NewPersistentStore(prefix)
+	if c.Tracer == nil {
+		return ps
+	}
+	return &tracedPersistentStore{PersistentStore: ps, Prefix: prefix}
+}
+
+func (c *Components) newClientMaker(scopes ...string) mlclient.HttpClientMaker {
+	var cm mlclient.HttpClientMaker = &aengine.AuthenticatedClientMaker{
+		Scope: scopes,
+	}
+	if c.Tracer == nil {
+		return cm
+	}
+	return &tracedClientMaker{HttpClientMaker: cm}
+}
+
+// RegisterHandlers adds the server's pages to mux, traced if tracing is on.
+func (c *Components) RegisterHandlers(mux *http.ServeMux) {
+	contextMaker := &requestlog.ContextMaker{ContextMaker: c.Platform.ContextMaker}
+	handle := func(pattern string, h http.Handler) {
+		mux.Handle(pattern, tracing.Handler(c.Tracer, pattern, requestlog.Handler(pattern, h)))
+	}
+
+	// Liveness only shows the server is responding; readiness checks what serving predictions depends on.
+	// Neither these nor metrics are traced or access logged, as they're polled too often to be worth it.
+	healthResponder := &responders.WebHealthResponder{}
+	mux.Handle("/healthz", (&controllers.Health{}).HandleFunc(contextMaker, healthResponder))
+	readinessController := &controllers.Health{
+		Checks: []controllers.HealthCheck{
+			{Name: "cache", Checker: &cacheHealth{CacheStore: c.Platform.NewCacheStore(healthPrefix, memcache.Gob)}},
+			{Name: "persistent-store", Checker: &persistentStoreHealth{PersistentStore: c.Platform.NewPersistentStore(healthPrefix)}},
+			{Name: "predictor", Checker: c.PredictionMaker},
+		},
+		Timeout: c.Platform.Config.Health.CheckTimeout,
+	}
+	mux.Handle("/readyz", readinessController.HandleFunc(contextMaker, healthResponder))
+
+	metricsController := &controllers.Metrics{
+		MetricsSource: c.Metrics.Registry,
+	}
+	mux.Handle("/metrics", metricsController.HandleFunc(contextMaker, &responders.WebMetricsResponder{}))
+
+	indexController := &controllers.Index{
+		ExampleLister:   c.ExampleLister,
+		PredictionMaker: c.PredictionMaker,
+	}
+	indexResponder := &responders.WebIndexResponder{}
+	handle("/", indexController.HandleFunc(contextMaker, indexResponder))
+
+	exampleDetailController := &controllers.ExampleDetail{
+		ExampleLister:   c.ExampleLister,
+		PredictionMaker: c.PredictionMaker,
+	}
+	handle("/examples/", exampleDetailController.HandleFunc(contextMaker, &responders.WebExampleDetailResponder{}))
+	handle("/api/examples/", exampleDetailController.HandleApiFunc(contextMaker, &responders.WebApiResponder{}))
+
+	cronResponder := &responders.WebSimpleResponder{
+		ExposeErrors: true,
+	}
+
+	handle("/cron/pb-update", c.ExamplesUpdate.HandleFunc(contextMaker, cronResponder))
+
+	trackRecordUpdateController := &controllers.TrackRecordUpdate{
+		TrackRecorder: c.TrackRecorder,
+	}
+	handle("/cron/track-record", trackRecordUpdateController.HandleFunc(contextMaker, cronResponder))
+	trackRecordController := &controllers.TrackRecord{
+		TrackRecorder: c.TrackRecorder,
+	}
+	handle("/track-record", trackRecordController.HandleFunc(contextMaker, &responders.WebTrackRecordResponder{}))
+	handle("/api/track-record", trackRecordController.HandleApiFunc(contextMaker, &responders.WebApiResponder{}))
+	calibrationController := &controllers.Calibration{
+		TrackRecorder:  c.TrackRecorder,
+		ModelVersioner: c.Trainer,
+	}
+	handle("/calibration", calibrationController.HandleFunc(contextMaker, &responders.WebCalibrationResponder{}))
+
+	questionsController := &controllers.Questions{
+		QuestionStore: c.InternalQuestions,
+		UserService:   c.Platform.UserService,
+	}
+	handle("/questions", questionsController.HandleFunc(contextMaker, &responders.WebQuestionsResponder{}))
+
+	mlRetrainController := &controllers.ModelRetrain{
+		Trainer:         c.Trainer,
+		PredictionCache: c.PredictionCache,
+		Background:      c.Platform.Background,
+		TaskQueue:       c.Platform.TaskQueue,
+	}
+	handle("/cron/ml-retrain", mlRetrainController.HandleFunc(contextMaker, cronResponder))
+	handle(controllers.ModelRetrainTaskPath, mlRetrainController.HandleTaskFunc(contextMaker, cronResponder))
+	handle("/admin/ml-retrain", mlRetrainController.HandleManualFunc(contextMaker, &responders.WebModelRetrainResponder{}))
+
+	adminApiResponder := &responders.WebApiResponder{
+		ExposeErrors: true,
+	}
+
+	trainingRunsController := &controllers.TrainingRuns{
+		RunLister: c.Trainer,
+	}
+	handle("/admin/training-runs", trainingRunsController.HandleFunc(contextMaker, &responders.WebTrainingRunsResponder{}))
+	handle("/admin/api/training-runs", trainingRunsController.HandleApiFunc(contextMaker, adminApiResponder))
+
+	configController := &controllers.Config{
+		ConfigSource: c.Platform.Config,
+	}
+	handle("/admin/config", configController.HandleFunc(contextMaker, &responders.WebConfigResponder{}))
+
+	shadowReportController := &controllers.ShadowReport{
+		ComparisonLister: c.ShadowLog,
+	}
+	handle("/admin/shadow-report", shadowReportController.HandleFunc(contextMaker, &responders.WebShadowReportResponder{}))
+	handle("/admin/api/shadow-report", shadowReportController.HandleApiFunc(contextMaker, adminApiResponder))
+}
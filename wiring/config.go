@@ -0,0 +1,462 @@
+package wiring
+
+import (
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const redactedValue = "REDACTED"
+
+// Config configures every component of the predictor. It is read from a YAML file, and then overridden
+// by any environment variables named in fields' env tags. Fields tagged secret are redacted for display.
+type Config struct {
+	Mode string `yaml:"mode" env:"MOONBIRD_MODE"`
+	Port string `yaml:"port" env:"PORT"`
+
+	AppEngine      AppEngineConfig      `yaml:"appengine"`
+	Server         ServerConfig         `yaml:"server"`
+	Storage        StorageConfig        `yaml:"storage"`
+	Cache          CacheConfig          `yaml:"cache"`
+	Auth           AuthConfig           `yaml:"auth"`
+	PredictionBook PredictionBookConfig `yaml:"predictionbook"`
+	Source         SourceConfig         `yaml:"source"`
+	Examples       ExamplesConfig       `yaml:"examples"`
+	MLEngine       MLEngineConfig       `yaml:"ml_engine"`
+	Predictor      PredictorConfig      `yaml:"predictor"`
+	Trainer        TrainerConfig        `yaml:"trainer"`
+	Health         HealthConfig         `yaml:"health"`
+	Tracing        TracingConfig        `yaml:"tracing"`
+}
+
+type AppEngineConfig struct {
+	Namespace string `yaml:"namespace" env:"APPENGINE_NAMESPACE"`
+}
+
+// ServerConfig configures the HTTP server in standalone mode.
+type ServerConfig struct {
+	ReadTimeout time.Duration `yaml:"read_timeout" env:"READ_TIMEOUT"`
+
+	// WriteTimeout defaults to none, as retrains run within a request.
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"WRITE_TIMEOUT"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"IDLE_TIMEOUT"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	MaxHeaderBytes  int           `yaml:"max_header_bytes" env:"MAX_HEADER_BYTES"`
+
+	// If TLSCertFile and TLSKeyFile are set, HTTPS is served instead of HTTP.
+	TLSCertFile   string `yaml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile    string `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+	TLSMinVersion string `yaml:"tls_min_version" env:"TLS_MIN_VERSION"`
+}
+
+type StorageConfig struct {
+	// DataDir holds the persistent store in standalone mode, and files too if FileStore is "local".
+	DataDir string `yaml:"data_dir" env:"DATA_DIR"`
+
+	// FileStore is "gcs" to keep training data in Cloud Storage, where ML Engine can read it,
+	// or "local" to keep it under DataDir in standalone mode.
+	FileStore    string `yaml:"file_store" env:"FILE_STORE"`
+	Bucket       string `yaml:"bucket" env:"FILE_STORE_BUCKET"`
+	BucketPrefix string `yaml:"bucket_prefix" env:"FILE_STORE_PREFIX"`
+
+	// Prefixes separate each component's entries in the shared stores and caches.
+	ExamplesPrefix    string `yaml:"examples_prefix" env:"EXAMPLES_PREFIX"`
+	QuestionsPrefix   string `yaml:"questions_prefix" env:"QUESTIONS_PREFIX"`
+	ModelsPrefix      string `yaml:"models_prefix" env:"MODELS_PREFIX"`
+	PredictionsPrefix string `yaml:"predictions_prefix" env:"PREDICTIONS_PREFIX"`
+}
+
+// CacheConfig configures the cache in standalone mode; it is kept in memory unless RedisAddr is set.
+type CacheConfig struct {
+	RedisAddr        string `yaml:"redis_addr" env:"REDIS_ADDR"`
+	RedisPassword    string `yaml:"redis_password" env:"REDIS_PASSWORD" secret:"true"`
+	RedisDB          int    `yaml:"redis_db" env:"REDIS_DB"`
+	MemoryMaxEntries int    `yaml:"memory_max_entries" env:"MEMORY_CACHE_MAX_ENTRIES"`
+}
+
+// AuthConfig configures authentication in standalone mode.
+type AuthConfig struct {
+	// UserHeader names the header identifying signed in users, set by an authenticating reverse proxy.
+	// It is unset by default, so no one is signed in unless such a proxy is configured.
+	UserHeader    string `yaml:"user_header" env:"USER_HEADER"`
+	AdminPassword string `yaml:"admin_password" env:"ADMIN_PASSWORD" secret:"true"`
+
+	// AdminUsers may resolve any question, not only their own. On App Engine, the project's admins may.
+	AdminUsers []string `yaml:"admin_users,omitempty" env:"ADMIN_USERS"`
+}
+
+type PredictionBookConfig struct {
+	URL               string  `yaml:"url" env:"PREDICTIONBOOK_URL"`
+	RequestsPerSecond float64 `yaml:"requests_per_second" env:"PREDICTIONBOOK_REQUESTS_PER_SECOND"`
+	Burst             int     `yaml:"burst" env:"PREDICTIONBOOK_BURST"`
+	Concurrency       int     `yaml:"concurrency" env:"PREDICTIONBOOK_CONCURRENCY"`
+}
+
+// SourceConfig replaces PredictionBook with a recording in Dir, if set.
+type SourceConfig struct {
+	Dir    string `yaml:"dir" env:"PREDICTION_SOURCE_DIR"`
+	Format string `yaml:"format" env:"PREDICTION_SOURCE_FORMAT"`
+}
+
+type ExamplesConfig struct {
+	TargetExamples int           `yaml:"target_examples" env:"EXAMPLES_TARGET"`
+	MaxPages       int64         `yaml:"max_pages" env:"EXAMPLES_MAX_PAGES"`
+	PageInterval   time.Duration `yaml:"page_interval" env:"EXAMPLES_PAGE_INTERVAL"`
+
+	// The selection policy is off by default, making an example of every unresolved prediction.
+	MinDistinctAssignments int           `yaml:"min_distinct_assignments" env:"EXAMPLES_MIN_DISTINCT_ASSIGNMENTS"`
+	MaxTimeToDeadline      time.Duration `yaml:"max_time_to_deadline" env:"EXAMPLES_MAX_TIME_TO_DEADLINE"`
+	ExcludeCreators        []string      `yaml:"exclude_creators,omitempty" env:"EXAMPLES_EXCLUDE_CREATORS"`
+	ExcludeTitleKeywords   []string      `yaml:"exclude_title_keywords,omitempty" env:"EXAMPLES_EXCLUDE_TITLE_KEYWORDS"`
+	RankByWagerCount       bool          `yaml:"rank_by_wager_count" env:"EXAMPLES_RANK_BY_WAGER_COUNT"`
+	MaxExamples            int           `yaml:"max_examples" env:"EXAMPLES_MAX"`
+
+	// DuplicateResponses combines each user's repeated assignments to a prediction:
+	// "latest", "first" or "average". By default, every assignment is kept.
+	DuplicateResponses data.DuplicateResponsePolicy `yaml:"duplicate_responses" env:"EXAMPLES_DUPLICATE_RESPONSES"`
+}
+
+// MLEngineConfig locates the model in ML Engine, for both predictions and training.
+type MLEngineConfig struct {
+	Project        string `yaml:"project" env:"ML_ENGINE_PROJECT"`
+	Region         string `yaml:"region" env:"ML_ENGINE_REGION"`
+	RuntimeVersion string `yaml:"runtime_version" env:"ML_ENGINE_RUNTIME_VERSION"`
+}
+
+type PredictorConfig struct {
+	CanaryVersion  string  `yaml:"canary_version" env:"CANARY_VERSION"`
+	CanaryFraction float64 `yaml:"canary_fraction" env:"CANARY_FRACTION"`
+	ShadowVersion  string  `yaml:"shadow_version" env:"SHADOW_VERSION"`
+}
+
+type TrainerConfig struct {
+	ModelPath     string        `yaml:"model_path" env:"TRAINER_MODEL_PATH"`
+	DataPath      string        `yaml:"data_path" env:"TRAINER_DATA_PATH"`
+	TrainPackage  string        `yaml:"train_package" env:"TRAINER_PACKAGE"`
+	LeaseDuration time.Duration `yaml:"lease_duration" env:"TRAINER_LEASE_DURATION"`
+
+	// Internal questions only feed the training data if asked, as they are of unknown quality.
+	TrainOnInternalQuestions bool `yaml:"train_on_internal_questions" env:"TRAIN_ON_INTERNAL_QUESTIONS"`
+
+	// DuplicateResponses combines repeated assignments in training data, as for examples.
+	DuplicateResponses data.DuplicateResponsePolicy `yaml:"duplicate_responses" env:"TRAINER_DUPLICATE_RESPONSES"`
+}
+
+type HealthConfig struct {
+	// CheckTimeout limits each dependency check made by /readyz.
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT"`
+}
+
+// TracingConfig selects where trace spans are exported: "none", "stdout", or "otlp" to post them
+// to an OpenTelemetry collector at Endpoint.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER"`
+	Endpoint    string  `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"`
+	ServiceName string  `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// DefaultConfig returns the configuration used for anything a config file and environment don't set.
+func DefaultConfig() *Config {
+	return &Config{
+		Mode: "appengine",
+		Port: "8080",
+		AppEngine: AppEngineConfig{
+			Namespace: "moonbird-predictor-frontend",
+		},
+		Server: ServerConfig{
+			ReadTimeout:     30 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 5 * time.Minute,
+			MaxHeaderBytes:  1 << 20,
+			TLSMinVersion:   "1.2",
+		},
+		Storage: StorageConfig{
+			DataDir:           "data",
+			FileStore:         "gcs",
+			Bucket:            "moonbird-data",
+			BucketPrefix:      "predictor/",
+			ExamplesPrefix:    "pbook-",
+			QuestionsPrefix:   "questions-",
+			ModelsPrefix:      "model-",
+			PredictionsPrefix: "~",
+		},
+		Cache: CacheConfig{
+			MemoryMaxEntries: 10000,
+		},
+		PredictionBook: PredictionBookConfig{
+			URL:               "https://predictionbook.com",
+			RequestsPerSecond: 1,
+			Burst:             2,
+			Concurrency:       2,
+		},
+		Source: SourceConfig{
+			Format: "csv",
+		},
+		Examples: ExamplesConfig{
+			TargetExamples: 20,
+			MaxPages:       10,
+			PageInterval:   2 * time.Second,
+		},
+		MLEngine: MLEngineConfig{
+			Project:        "moonbird-beshir",
+			Region:         "us-east1",
+			RuntimeVersion: "2.4",
+		},
+		Trainer: TrainerConfig{
+			ModelPath:     "moonbird-models/predictor",
+			DataPath:      "moonbird-data/predictor",
+			TrainPackage:  "gs://moonbird-models/predictor/trainer.tar.gz",
+			LeaseDuration: 10 * time.Minute,
+		},
+		Health: HealthConfig{
+			CheckTimeout: 2 * time.Second,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "http://localhost:4318/v1/traces",
+			ServiceName: "moonbird-predictor-frontend",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// LoadConfig reads the config file at path, if not empty, applies overrides from the environment,
+// and validates the result.
+func LoadConfig(path string) (*Config, error) {
+	var content []byte
+	if path != "" {
+		var err error
+		content, err = ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+	}
+	return parseConfig(content, os.LookupEnv)
+}
+
+func parseConfig(content []byte, lookupEnv func(string) (string, bool)) (*Config, error) {
+	c := DefaultConfig()
+
+	// Unknown keys are rejected, so misspelt settings aren't silently ignored.
+	err := yaml.UnmarshalStrict(content, c)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid config file")
+	}
+
+	err = applyEnv(reflect.ValueOf(c).Elem(), lookupEnv)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	if problems := c.Validate(); len(problems) > 0 {
+		return nil, errors.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return c, nil
+}
+
+// applyEnv sets each field with an env tag from that environment variable, if it is set.
+// Variables set empty clear their field.
+func applyEnv(v reflect.Value, lookupEnv func(string) (string, bool)) error {
+	durationType := reflect.TypeOf(time.Duration(0))
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		fieldType := v.Type().Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, lookupEnv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := fieldType.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		value, ok := lookupEnv(name)
+		if !ok {
+			continue
+		}
+		if value == "" {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+
+		var err error
+		switch {
+		case field.Type() == durationType:
+			var d time.Duration
+			d, err = time.ParseDuration(value)
+			field.SetInt(int64(d))
+		case field.Kind() == reflect.String:
+			field.SetString(value)
+		case field.Kind() == reflect.Int || field.Kind() == reflect.Int64:
+			var n int64
+			n, err = strconv.ParseInt(value, 10, 64)
+			field.SetInt(n)
+		case field.Kind() == reflect.Float64:
+			var f float64
+			f, err = strconv.ParseFloat(value, 64)
+			field.SetFloat(f)
+		case field.Kind() == reflect.Bool:
+			var b bool
+			b, err = strconv.ParseBool(value)
+			field.SetBool(b)
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+			// Lists are comma separated.
+			var items []string
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			field.Set(reflect.ValueOf(items))
+		default:
+			return errors.Errorf("unsupported type for %s", name)
+		}
+		if err != nil {
+			return errors.Errorf("invalid %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// Validate returns a description of each problem with the config, or nothing if it is valid.
+func (c *Config) Validate() (problems []string) {
+	if c.Mode != "appengine" && c.Mode != "standalone" {
+		problems = append(problems, "mode must be appengine or standalone")
+	}
+	if port, err := strconv.Atoi(c.Port); err != nil || port <= 0 || port > 65535 {
+		problems = append(problems, "port must be a port number")
+	}
+
+	if c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 || c.Server.ShutdownTimeout < 0 {
+		problems = append(problems, "server timeouts must not be negative")
+	}
+	if c.Server.MaxHeaderBytes <= 0 {
+		problems = append(problems, "server.max_header_bytes must be positive")
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		problems = append(problems, "server.tls_cert_file and server.tls_key_file must be set together")
+	}
+	if c.Server.TLSMinVersion != "1.2" && c.Server.TLSMinVersion != "1.3" {
+		problems = append(problems, "server.tls_min_version must be 1.2 or 1.3")
+	}
+
+	switch c.Storage.FileStore {
+	case "gcs":
+		if c.Storage.Bucket == "" {
+			problems = append(problems, "storage.bucket is required to use Cloud Storage")
+		}
+	case "local":
+		if c.Mode != "standalone" {
+			problems = append(problems, "storage.file_store may only be local in standalone mode")
+		}
+	default:
+		problems = append(problems, "storage.file_store must be gcs or local")
+	}
+	if c.Mode == "standalone" && c.Storage.DataDir == "" {
+		problems = append(problems, "storage.data_dir is required in standalone mode")
+	}
+
+	if c.Cache.RedisDB < 0 {
+		problems = append(problems, "cache.redis_db must not be negative")
+	}
+	if c.Cache.MemoryMaxEntries <= 0 {
+		problems = append(problems, "cache.memory_max_entries must be positive")
+	}
+
+	if u, err := url.Parse(c.PredictionBook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems = append(problems, "predictionbook.url must be an http or https URL")
+	}
+	if c.PredictionBook.RequestsPerSecond <= 0 {
+		problems = append(problems, "predictionbook.requests_per_second must be positive")
+	}
+	if c.PredictionBook.Burst < 1 || c.PredictionBook.Concurrency < 1 {
+		problems = append(problems, "predictionbook.burst and predictionbook.concurrency must be at least 1")
+	}
+	if c.Source.Format != "csv" && c.Source.Format != "json" {
+		problems = append(problems, "source.format must be csv or json")
+	}
+
+	if c.Examples.TargetExamples <= 0 || c.Examples.MaxPages <= 0 {
+		problems = append(problems, "examples.target_examples and examples.max_pages must be positive")
+	}
+	if c.Examples.PageInterval < 0 || c.Examples.MinDistinctAssignments < 0 || c.Examples.MaxTimeToDeadline < 0 || c.Examples.MaxExamples < 0 {
+		problems = append(problems, "examples.page_interval, examples.min_distinct_assignments, examples.max_time_to_deadline and examples.max_examples must not be negative")
+	}
+	if !c.Examples.DuplicateResponses.Valid() || !c.Trainer.DuplicateResponses.Valid() {
+		problems = append(problems, "examples.duplicate_responses and trainer.duplicate_responses must be empty, latest, first or average")
+	}
+
+	if c.MLEngine.Project == "" || c.MLEngine.Region == "" || c.MLEngine.RuntimeVersion == "" {
+		problems = append(problems, "ml_engine.project, ml_engine.region and ml_engine.runtime_version are required")
+	}
+
+	if c.Predictor.CanaryFraction < 0 || c.Predictor.CanaryFraction > 1 {
+		problems = append(problems, "predictor.canary_fraction must be from 0 to 1")
+	}
+	if c.Predictor.CanaryFraction > 0 && c.Predictor.CanaryVersion == "" {
+		problems = append(problems, "predictor.canary_version is required for a canary fraction")
+	}
+
+	if c.Trainer.ModelPath == "" || c.Trainer.DataPath == "" || c.Trainer.TrainPackage == "" {
+		problems = append(problems, "trainer.model_path, trainer.data_path and trainer.train_package are required")
+	}
+	if c.Trainer.LeaseDuration <= 0 {
+		problems = append(problems, "trainer.lease_duration must be positive")
+	}
+
+	if c.Health.CheckTimeout <= 0 {
+		problems = append(problems, "health.check_timeout must be positive")
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, "tracing.endpoint must be an http or https URL to export to a collector")
+		}
+	default:
+		problems = append(problems, "tracing.exporter must be none, stdout or otlp")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, "tracing.sample_ratio must be from 0 to 1")
+	}
+
+	return problems
+}
+
+// Redacted returns a copy of the config with secrets replaced, safe to display.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redactSecrets(reflect.ValueOf(&redacted).Elem())
+	return &redacted
+}
+
+// RedactedYAML returns the config as YAML, with secrets replaced.
+func (c *Config) RedactedYAML() ([]byte, error) {
+	content, err := yaml.Marshal(c.Redacted())
+	return content, errors.Wrap(err, "")
+}
+
+func redactSecrets(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			redactSecrets(field)
+			continue
+		}
+		if v.Type().Field(i).Tag.Get("secret") == "true" && field.String() != "" {
+			field.SetString(redactedValue)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package wiring
+
+import (
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEnv(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func TestParseConfig_Defaults(t *testing.T) {
+	t.Parallel()
+
+	c, err := parseConfig(nil, testEnv(nil))
+	if err != nil {
+		t.Fatalf("Expected defaults to be valid, got %s", err)
+	}
+	if !reflect.DeepEqual(c, DefaultConfig()) {
+		t.Errorf("Expected default config, got %+v", c)
+	}
+	if c.Auth.UserHeader != "" {
+		t.Errorf("Expected no user header to be trusted by default, got %s", c.Auth.UserHeader)
+	}
+}
+
+func TestParseConfig_FileAndEnv(t *testing.T) {
+	t.Parallel()
+
+	content := []byte(`
+mode: standalone
+server:
+  read_timeout: 10s
+storage:
+  file_store: local
+  examples_prefix: staging-pbook-
+predictionbook:
+  url: https://staging.predictionbook.com
+  requests_per_second: 0.5
+examples:
+  rank_by_wager_count: true
+  duplicate_responses: average
+  exclude_creators: [bob]
+predictor:
+  shadow_version: v3
+`)
+	c, err := parseConfig(content, testEnv(map[string]string{
+		"PORT":            "9090",
+		"READ_TIMEOUT":    "20s",
+		"CANARY_VERSION":  "v2",
+		"CANARY_FRACTION": "0.25",
+		"ADMIN_PASSWORD":  "hunter2",
+		"ADMIN_USERS":     "alice, bob",
+
+		"TRAINER_DUPLICATE_RESPONSES": "latest",
+
+		"EXAMPLES_EXCLUDE_TITLE_KEYWORDS": "test, spam",
+		"EXAMPLES_MAX_TIME_TO_DEADLINE":   "720h",
+		"ML_ENGINE_PROJECT":               "moonbird-staging",
+		"SHADOW_VERSION":                  "",
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if c.Mode != "standalone" || c.Storage.FileStore != "local" || c.Storage.ExamplesPrefix != "staging-pbook-" {
+		t.Errorf("Expected settings from the file, got %+v", c)
+	}
+	if c.PredictionBook.URL != "https://staging.predictionbook.com" || c.PredictionBook.RequestsPerSecond != 0.5 {
+		t.Errorf("Expected PredictionBook settings from the file, got %+v", c.PredictionBook)
+	}
+	if !c.Examples.RankByWagerCount || c.Examples.TargetExamples != 20 {
+		t.Errorf("Expected example settings from the file, with defaults for the rest, got %+v", c.Examples)
+	}
+	if c.Examples.DuplicateResponses != data.AverageResponses || c.Trainer.DuplicateResponses != data.KeepLatestResponse {
+		t.Errorf("Expected duplicate response policies from the file and environment, got %q and %q",
+			c.Examples.DuplicateResponses, c.Trainer.DuplicateResponses)
+	}
+	if !reflect.DeepEqual(c.Examples.ExcludeCreators, []string{"bob"}) || !reflect.DeepEqual(c.Examples.ExcludeTitleKeywords, []string{"test", "spam"}) ||
+		c.Examples.MaxTimeToDeadline != 720*time.Hour {
+		t.Errorf("Expected selection policy from the file and environment, got %+v", c.Examples)
+	}
+	if c.MLEngine.Project != "moonbird-staging" || c.MLEngine.Region != "us-east1" {
+		t.Errorf("Expected ML Engine project from the environment, with the default region, got %+v", c.MLEngine)
+	}
+	if c.Predictor.ShadowVersion != "" {
+		t.Errorf("Expected an empty environment variable to clear the file's setting, got %q", c.Predictor.ShadowVersion)
+	}
+	if c.Port != "9090" || c.Server.ReadTimeout != 20*time.Second {
+		t.Errorf("Expected the environment to override the file, got port %s, read timeout %s", c.Port, c.Server.ReadTimeout)
+	}
+	if c.Predictor.CanaryVersion != "v2" || c.Predictor.CanaryFraction != 0.25 || c.Auth.AdminPassword != "hunter2" {
+		t.Errorf("Expected settings from the environment, got %+v, %+v", c.Predictor, c.Auth)
+	}
+	if !reflect.DeepEqual(c.Auth.AdminUsers, []string{"alice", "bob"}) {
+		t.Errorf("Expected admin users from the environment, got %v", c.Auth.AdminUsers)
+	}
+	if c.Server.IdleTimeout != 2*time.Minute {
+		t.Errorf("Expected default idle timeout, got %s", c.Server.IdleTimeout)
+	}
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		want    string
+	}{
+		{"unknown key", "server:\n  read_timout: 10s\n", nil, "read_timout"},
+		{"bad type", "port: [1]\n", nil, "invalid config file"},
+		{"bad env", "", map[string]string{"REDIS_DB": "one"}, "invalid REDIS_DB"},
+		{"bad mode", "mode: lambda\n", nil, "mode must be"},
+		{"local files on App Engine", "storage:\n  file_store: local\n", nil, "only be local in standalone"},
+		{"half of TLS", "mode: standalone\nserver:\n  tls_cert_file: cert.pem\n", nil, "must be set together"},
+		{"bad URL", "predictionbook:\n  url: predictionbook.com\n", nil, "predictionbook.url"},
+		{"canary without version", "predictor:\n  canary_fraction: 0.5\n", nil, "canary_version is required"},
+		{"bad duplicate response policy", "", map[string]string{"TRAINER_DUPLICATE_RESPONSES": "lastest"}, "trainer.duplicate_responses"},
+		{"no ML Engine project", "", map[string]string{"ML_ENGINE_PROJECT": ""}, "ml_engine.project"},
+		{"bad tracing exporter", "tracing:\n  exporter: jaeger\n", nil, "tracing.exporter"},
+		{"OTLP without endpoint", "tracing:\n  exporter: otlp\n  endpoint: \"\"\n", nil, "tracing.endpoint"},
+		{"bad sample ratio", "", map[string]string{"TRACING_SAMPLE_RATIO": "2"}, "tracing.sample_ratio"},
+		{"several problems", "port: \"0\"\ncache:\n  memory_max_entries: 0\n", nil, "port must be a port number; cache.memory_max_entries"},
+	}
+
+	for _, test := range tests {
+		_, err := parseConfig([]byte(test.content), testEnv(test.env))
+		if err == nil {
+			t.Errorf("%s: expected an error, got nil", test.name)
+		} else if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: expected an error containing %q, got %s", test.name, test.want, err)
+		}
+	}
+}
+
+func TestConfig_RedactedYAML(t *testing.T) {
+	t.Parallel()
+
+	c := DefaultConfig()
+	c.Auth.AdminPassword = "hunter2"
+	c.Cache.RedisAddr = "localhost:6379"
+
+	content, err := c.RedactedYAML()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if strings.Contains(string(content), "hunter2") {
+		t.Errorf("Expected admin password to be redacted, got:\n%s", content)
+	}
+	if !strings.Contains(string(content), "admin_password: REDACTED") {
+		t.Errorf("Expected admin password to be shown as redacted, got:\n%s", content)
+	}
+	if !strings.Contains(string(content), "redis_password: \"\"") {
+		t.Errorf("Expected unset Redis password to be shown as unset, got:\n%s", content)
+	}
+	if !strings.Contains(string(content), "redis_addr: localhost:6379") || !strings.Contains(string(content), "read_timeout: 30s") {
+		t.Errorf("Expected other settings to be shown, got:\n%s", content)
+	}
+	if c.Auth.AdminPassword != "hunter2" {
+		t.Error("Expected redaction to leave the original config unchanged")
+	}
+
+	// The redacted config reads back as the same config, apart from the secrets.
+	parsed, err := parseConfig(content, testEnv(nil))
+	if err != nil {
+		t.Fatalf("Expected redacted config to be valid, got %s", err)
+	}
+	parsed.Auth.AdminPassword = "hunter2"
+	if !reflect.DeepEqual(parsed, c) {
+		t.Errorf("Expected redacted config to read back the same, got %+v", parsed)
+	}
+}
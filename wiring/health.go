@@ -0,0 +1,50 @@
+package wiring
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	healthPrefix    = "health-"
+	healthProbeKind = "HealthProbe"
+	healthProbeKey  = "probe"
+)
+
+// cacheHealth checks the cache by writing a probe entry and reading it back.
+type cacheHealth struct {
+	CacheStore CacheStore
+}
+
+func (h *cacheHealth) CheckHealth(ctx context.Context) error {
+	now := time.Now().UnixNano()
+	err := h.CacheStore.Set(ctx, healthProbeKey, &now)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	var got int64
+	err = h.CacheStore.Get(ctx, healthProbeKey, &got)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	if got != now {
+		return errors.New("cache returned a different probe value than was written")
+	}
+	return nil
+}
+
+// persistentStoreHealth checks the persistent store by reading a probe entity, which need not exist.
+type persistentStoreHealth struct {
+	PersistentStore PersistentStore
+}
+
+func (h *persistentStoreHealth) CheckHealth(ctx context.Context) error {
+	_, err := h.PersistentStore.Get(ctx, healthProbeKind, healthProbeKey, nil)
+	if err != nil && errors.Cause(err) != data.ErrNoSuchEntity {
+		return errors.Wrap(err, "")
+	}
+	return nil
+}
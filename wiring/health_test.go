@@ -0,0 +1,77 @@
+package wiring
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	"github.com/jbeshir/moonbird-predictor-frontend/localstore"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type failingCacheBackend struct {
+	localstore.MemoryCache
+}
+
+func (b *failingCacheBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, errors.New("bluh")
+}
+
+func TestCacheHealth_CheckHealth(t *testing.T) {
+	t.Parallel()
+
+	h := &cacheHealth{
+		CacheStore: &localstore.CacheStore{Backend: &localstore.MemoryCache{}, Prefix: healthPrefix},
+	}
+	if err := h.CheckHealth(context.Background()); err != nil {
+		t.Errorf("Expected nil err, got %s", err)
+	}
+
+	h = &cacheHealth{
+		CacheStore: &localstore.CacheStore{Backend: &failingCacheBackend{}, Prefix: healthPrefix},
+	}
+	if err := h.CheckHealth(context.Background()); err == nil {
+		t.Error("Expected an error from a failing cache, got nil")
+	}
+}
+
+func TestPersistentStoreHealth_CheckHealth(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "moonbird-health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	h := &persistentStoreHealth{
+		PersistentStore: &localstore.PersistentStore{Dir: dir, Prefix: healthPrefix},
+	}
+	if err := h.CheckHealth(context.Background()); err != nil {
+		t.Errorf("Expected a missing probe entity to be healthy, got %s", err)
+	}
+
+	err = h.PersistentStore.Set(context.Background(), healthProbeKind, healthProbeKey, []data.Property{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.CheckHealth(context.Background()); err != nil {
+		t.Errorf("Expected an existing probe entity to be healthy, got %s", err)
+	}
+
+	// A file where the store's directory should be leaves it unreadable.
+	file, err := ioutil.TempFile("", "moonbird-health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	h = &persistentStoreHealth{
+		PersistentStore: &localstore.PersistentStore{Dir: file.Name(), Prefix: healthPrefix},
+	}
+	if err := h.CheckHealth(context.Background()); err == nil {
+		t.Error("Expected an error from an unreadable store, got nil")
+	}
+}
@@ -0,0 +1,90 @@
+package wiring
+
+import (
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/moonbird-predictor-frontend/metrics"
+	"github.com/jbeshir/moonbird-predictor-frontend/mlclient"
+	"time"
+)
+
+// Metrics names and labels what components report about their work, in a registry served at /metrics.
+type Metrics struct {
+	Registry *metrics.Registry
+
+	predictions           *metrics.Counter
+	predictionLatency     *metrics.Histogram
+	predictionAssignments *metrics.Histogram
+
+	examplesUpdates        *metrics.Counter
+	examplesUpdateDuration *metrics.Histogram
+	examples               *metrics.Gauge
+
+	retrains             *metrics.Counter
+	retrainDuration      *metrics.Histogram
+	retrainStageDuration *metrics.Histogram
+}
+
+// Retrain stages can take anywhere from seconds to hours.
+var retrainBuckets = []float64{1, 10, 60, 300, 900, 1800, 3600, 7200, 14400}
+
+func NewMetrics() *Metrics {
+	r := metrics.NewRegistry()
+	return &Metrics{
+		Registry: r,
+
+		predictions: r.NewCounter("moonbird_predictions_total",
+			"Predictions requested, by outcome.", "outcome"),
+		predictionLatency: r.NewHistogram("moonbird_prediction_backend_latency_seconds",
+			"Latency of prediction requests to ML Engine.", metrics.DefaultBuckets),
+		predictionAssignments: r.NewHistogram("moonbird_prediction_assignments",
+			"Number of probability assignments combined in each prediction requested.",
+			[]float64{1, 2, 3, 5, 10, 20, 50, 100}),
+
+		examplesUpdates: r.NewCounter("moonbird_examples_updates_total",
+			"Example updates, by result.", "result"),
+		examplesUpdateDuration: r.NewHistogram("moonbird_examples_update_duration_seconds",
+			"Duration of example updates.", []float64{1, 5, 10, 30, 60, 120, 300, 600}),
+		examples: r.NewGauge("moonbird_examples",
+			"Number of examples found by the last successful update."),
+
+		retrains: r.NewCounter("moonbird_retrains_total",
+			"Retrains finished, by outcome.", "outcome"),
+		retrainDuration: r.NewHistogram("moonbird_retrain_duration_seconds",
+			"Duration of retrains, by outcome.", retrainBuckets, "outcome"),
+		retrainStageDuration: r.NewHistogram("moonbird_retrain_stage_duration_seconds",
+			"Duration of retrain stages, by stage and result.", retrainBuckets, "stage", "result"),
+	}
+}
+
+func (m *Metrics) PredictionMade(outcome mlclient.PredictionOutcome, assignments int) {
+	m.predictions.Inc(string(outcome))
+	m.predictionAssignments.Observe(float64(assignments))
+}
+
+func (m *Metrics) BackendCalled(latency time.Duration) {
+	m.predictionLatency.Observe(latency.Seconds())
+}
+
+func (m *Metrics) ExamplesUpdated(duration time.Duration, examples int, err error) {
+	m.examplesUpdates.Inc(resultLabel(err))
+	m.examplesUpdateDuration.Observe(duration.Seconds())
+	if err == nil {
+		m.examples.Set(float64(examples))
+	}
+}
+
+func (m *Metrics) RetrainStageFinished(stage string, duration time.Duration, err error) {
+	m.retrainStageDuration.Observe(duration.Seconds(), stage, resultLabel(err))
+}
+
+func (m *Metrics) RetrainFinished(outcome data.TrainingRunOutcome, duration time.Duration) {
+	m.retrains.Inc(string(outcome))
+	m.retrainDuration.Observe(duration.Seconds(), string(outcome))
+}
+
+func resultLabel(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
@@ -0,0 +1,46 @@
+package wiring
+
+import (
+	"bytes"
+	"errors"
+	"github.com/jbeshir/moonbird-predictor-frontend/data"
+	"github.com/jbeshir/moonbird-predictor-frontend/mlclient"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	m := NewMetrics()
+	m.PredictionMade(mlclient.PredictionCacheHit, 3)
+	m.PredictionMade(mlclient.PredictionBackendSuccess, 2)
+	m.BackendCalled(200 * time.Millisecond)
+	m.ExamplesUpdated(10*time.Second, 20, nil)
+	m.ExamplesUpdated(time.Second, 0, errors.New("bluh"))
+	m.RetrainStageFinished("train", 20*time.Minute, nil)
+	m.RetrainFinished(data.TrainingRunSucceeded, time.Hour)
+
+	var buf bytes.Buffer
+	if err := m.Registry.WriteText(&buf); err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+
+	for _, want := range []string{
+		`moonbird_predictions_total{outcome="backend_success"} 1`,
+		`moonbird_predictions_total{outcome="cache_hit"} 1`,
+		`moonbird_prediction_backend_latency_seconds_count 1`,
+		`moonbird_prediction_assignments_sum 5`,
+		`moonbird_examples_updates_total{result="error"} 1`,
+		`moonbird_examples_updates_total{result="success"} 1`,
+		`moonbird_examples 20`,
+		`moonbird_retrain_stage_duration_seconds_bucket{stage="train",result="success",le="1800"} 1`,
+		`moonbird_retrains_total{outcome="succeeded"} 1`,
+		`moonbird_retrain_duration_seconds_sum{outcome="succeeded"} 3600`,
+	} {
+		if !strings.Contains(buf.String(), want+"\n") {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package wiring
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"github.com/jbeshir/moonbird-auth-frontend/aengine"
+	"github.com/jbeshir/moonbird-auth-frontend/ctxlogrus"
+	"github.com/jbeshir/moonbird-predictor-frontend/controllers"
+	"github.com/jbeshir/moonbird-predictor-frontend/localstore"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/memcache"
+	"google.golang.org/appengine/taskqueue"
+	"google.golang.org/appengine/user"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Platform provides the stores and services that differ between running on App Engine,
+// and running standalone on our own server.
+type Platform struct {
+	Config     *Config
+	Standalone bool
+
+	ContextMaker       controllers.ContextMaker
+	UserService        controllers.UserService
+	NewCacheStore      func(prefix string, codec memcache.Codec) CacheStore
+	NewPersistentStore func(prefix string) PersistentStore
+	FileStore          FileStore
+	Background         controllers.BackgroundRunner
+	Serve              func()
+
+	// TaskQueue runs work too long for Background as separate requests. It is nil where there is none.
+	TaskQueue controllers.TaskQueue
+
+	// Stopping is closed when the server begins shutting down, so long-running work can wind up.
+	// It is nil if work is never asked to stop early.
+	Stopping <-chan struct{}
+
+	stop           func()
+	waitBackground func(timeout time.Duration) bool
+}
+
+// Stop closes Stopping, if it is not already closed.
+func (p *Platform) Stop() {
+	if p.stop != nil {
+		p.stop()
+	}
+}
+
+// WaitForBackground waits up to the timeout for work given to Background to finish, returning whether it did.
+// Where we can't wait for it, it returns true immediately.
+func (p *Platform) WaitForBackground(timeout time.Duration) bool {
+	if p.waitBackground == nil {
+		return true
+	}
+	return p.waitBackground(timeout)
+}
+
+// NewPlatform selects App Engine, or standalone mode, as configured.
+func NewPlatform(cfg *Config) *Platform {
+	if cfg.Mode == "standalone" {
+		return newStandalonePlatform(cfg)
+	}
+	return newAppEnginePlatform(cfg)
+}
+
+func newAppEnginePlatform(cfg *Config) *Platform {
+	return &Platform{
+		Config: cfg,
+		ContextMaker: &aengine.ContextMaker{
+			Namespace: cfg.AppEngine.Namespace,
+		},
+		UserService: &appEngineUserService{},
+		NewCacheStore: func(prefix string, codec memcache.Codec) CacheStore {
+			return &aengine.CacheStore{
+				Prefix: prefix,
+				Codec:  codec,
+			}
+		},
+		NewPersistentStore: func(prefix string) PersistentStore {
+			return &appEnginePersistentStore{
+				PersistentStore: aengine.PersistentStore{
+					Prefix: prefix,
+				},
+			}
+		},
+		FileStore: &aengine.GcsFileStore{
+			Bucket: cfg.Storage.Bucket,
+			Prefix: cfg.Storage.BucketPrefix,
+		},
+		Background: &appEngineBackgroundRunner{
+			Namespace: cfg.AppEngine.Namespace,
+		},
+		TaskQueue: &appEngineTaskQueue{
+			Queue: "retrain",
+		},
+		Serve: func() {
+			appengine.Main()
+		},
+	}
+}
+
+// appEngineUserService counts App Engine's admins, the project's owners and editors, as our admins.
+type appEngineUserService struct {
+	aengine.UserService
+}
+
+func (us *appEngineUserService) ContextUserIsAdmin(ctx context.Context) bool {
+	return user.IsAdmin(ctx)
+}
+
+// appEnginePersistentStore adds deletion to the datastore store, keying entities as it does.
+type appEnginePersistentStore struct {
+	aengine.PersistentStore
+}
+
+func (ps *appEnginePersistentStore) Delete(ctx context.Context, kind, key string) error {
+	err := datastore.Delete(ctx, datastore.NewKey(ctx, kind, ps.Prefix+key, 0, nil))
+	return errors.Wrap(err, "")
+}
+
+// appEngineBackgroundRunner runs work with App Engine's background context,
+// as API calls can't be made with a request's context once it has been responded to.
+// Work keeps the request's logger.
+type appEngineBackgroundRunner struct {
+	Namespace string
+}
+
+func (br *appEngineBackgroundRunner) RunInBackground(ctx context.Context, f func(ctx context.Context)) {
+	l := ctxlogrus.Get(ctx)
+	bgCtx, err := appengine.Namespace(appengine.BackgroundContext(), br.Namespace)
+	if err != nil {
+		l.Errorf("Unable to start background work: %s", err)
+		return
+	}
+	bgCtx = ctxlogrus.WithLogger(bgCtx, l)
+	go f(bgCtx)
+}
+
+// appEngineTaskQueue adds tasks to a push queue, which queue.yaml targets at the basic scaling cron service,
+// so they may run for hours.
+type appEngineTaskQueue struct {
+	Queue string
+}
+
+func (q *appEngineTaskQueue) Enqueue(ctx context.Context, path string, params url.Values) error {
+	_, err := taskqueue.Add(ctx, taskqueue.NewPOSTTask(path, params), q.Queue)
+	return errors.Wrap(err, "")
+}
+
+// goroutineBackgroundRunner runs work in goroutines, keeping its request context's values but not its cancellation.
+// Wait waits for work still running.
+type goroutineBackgroundRunner struct {
+	wg sync.WaitGroup
+}
+
+func (br *goroutineBackgroundRunner) RunInBackground(ctx context.Context, f func(ctx context.Context)) {
+	br.wg.Add(1)
+	go func() {
+		defer br.wg.Done()
+		f(detachedContext{ctx})
+	}()
+}
+
+// Wait waits up to the timeout for background work to finish, returning whether it did.
+func (br *goroutineBackgroundRunner) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		br.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+// newStandalonePlatform keeps everything under the data directory, and caches in memory,
+// or in Redis if configured. Training data is saved locally if the file store is "local";
+// otherwise to Cloud Storage, where ML Engine can read it.
+func newStandalonePlatform(cfg *Config) *Platform {
+	var cacheBackend localstore.CacheBackend = &localstore.MemoryCache{
+		MaxEntries: cfg.Cache.MemoryMaxEntries,
+	}
+	if cfg.Cache.RedisAddr != "" {
+		cacheBackend = &localstore.RedisCache{
+			Addr:     cfg.Cache.RedisAddr,
+			Password: cfg.Cache.RedisPassword,
+			DB:       cfg.Cache.RedisDB,
+		}
+	}
+
+	var files FileStore = &aengine.GcsFileStore{
+		Bucket: cfg.Storage.Bucket,
+		Prefix: cfg.Storage.BucketPrefix,
+	}
+	if cfg.Storage.FileStore == "local" {
+		files = &localstore.FileStore{
+			Dir: filepath.Join(cfg.Storage.DataDir, "files"),
+		}
+	}
+
+	stopping := make(chan struct{})
+	var stopOnce sync.Once
+	background := &goroutineBackgroundRunner{}
+
+	p := &Platform{
+		Config:     cfg,
+		Standalone: true,
+		ContextMaker: &localstore.ContextMaker{
+			Logger:     logrus.StandardLogger(),
+			UserHeader: cfg.Auth.UserHeader,
+		},
+		UserService: &localstore.UserService{
+			AdminUsers: cfg.Auth.AdminUsers,
+		},
+		NewCacheStore: func(prefix string, codec memcache.Codec) CacheStore {
+			return &localstore.CacheStore{
+				Backend: cacheBackend,
+				Prefix:  prefix,
+			}
+		},
+		NewPersistentStore: func(prefix string) PersistentStore {
+			return &localstore.PersistentStore{
+				Dir:    filepath.Join(cfg.Storage.DataDir, "store"),
+				Prefix: prefix,
+			}
+		},
+		FileStore:  files,
+		Background: background,
+		Stopping:   stopping,
+		stop: func() {
+			stopOnce.Do(func() {
+				close(stopping)
+			})
+		},
+		waitBackground: background.Wait,
+	}
+	p.Serve = func() {
+		handler := requireAdmin(http.DefaultServeMux, cfg.Auth.AdminPassword)
+		serveUntilStopped(newHTTPServer(":"+cfg.Port, handler, cfg.Server), cfg.Server, p.Stop)
+
+		// Background work, such as manual retrains, checkpoints and finishes once stopped.
+		if !p.WaitForBackground(cfg.Server.ShutdownTimeout) {
+			log.Print("Background work still running at shutdown")
+		}
+	}
+	return p
+}
+
+func newHTTPServer(addr string, handler http.Handler, cfg ServerConfig) *http.Server {
+	srv := &http.Server{
+		Addr:           addr,
+		Handler:        handler,
+		ReadTimeout:    cfg.ReadTimeout,
+		WriteTimeout:   cfg.WriteTimeout,
+		IdleTimeout:    cfg.IdleTimeout,
+		MaxHeaderBytes: cfg.MaxHeaderBytes,
+		TLSConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		},
+	}
+	if cfg.TLSMinVersion == "1.3" {
+		srv.TLSConfig.MinVersion = tls.VersionTLS13
+	}
+	return srv
+}
+
+// serveUntilStopped serves, over TLS if configured, until SIGTERM or SIGINT.
+// It then calls stop, and waits up to the shutdown timeout for in-flight requests to finish.
+func serveUntilStopped(srv *http.Server, cfg ServerConfig, stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+
+		sig := <-signals
+		log.Printf("Received %s, shutting down", sig)
+		stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("Unable to finish in-flight requests: %s", err)
+		}
+	}()
+
+	var err error
+	if cfg.TLSCertFile != "" {
+		err = srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+
+	<-shutdownDone
+}
+
+// requireAdmin protects admin and cron pages with HTTP basic auth, as App Engine's admin login does there.
+// Without a password set, they are refused entirely.
+func requireAdmin(h http.Handler, password string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/admin/") || strings.HasPrefix(r.URL.Path, "/cron/") {
+			user, pass, ok := r.BasicAuth()
+			if password == "" || !ok || user != "admin" || subtle.ConstantTimeCompare([]byte(pass), []byte(password)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="moonbird"`)
+				http.Error(w, "Unauthorized", 401)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
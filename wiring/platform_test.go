@@ -0,0 +1,41 @@
+package wiring
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type testContextKey struct{}
+
+func TestGoroutineBackgroundRunner(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), testContextKey{}, "request"))
+	cancel()
+
+	br := &goroutineBackgroundRunner{}
+	release := make(chan struct{})
+	var bgErr error
+	var bgValue interface{}
+	br.RunInBackground(ctx, func(ctx context.Context) {
+		<-release
+		bgErr = ctx.Err()
+		bgValue = ctx.Value(testContextKey{})
+	})
+
+	if br.Wait(10 * time.Millisecond) {
+		t.Error("Expected wait to time out with background work running")
+	}
+	close(release)
+	if !br.Wait(time.Second) {
+		t.Fatal("Expected wait to return once background work finished")
+	}
+
+	if bgErr != nil {
+		t.Errorf("Expected background work's context not to be cancelled with its request's, got %s", bgErr)
+	}
+	if bgValue != "request" {
+		t.Errorf("Expected background work's context to keep its request's values, got %v", bgValue)
+	}
+}
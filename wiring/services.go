@@ -0,0 +1,25 @@
+package wiring
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+)
+
+type CacheStore interface {
+	Get(ctx context.Context, key string, v interface{}) error
+	Set(ctx context.Context, key string, v interface{}) error
+	Delete(ctx context.Context, key string) error
+	Flush(ctx context.Context) error
+}
+
+type PersistentStore interface {
+	Get(ctx context.Context, kind, key string, v interface{}) ([]data.Property, error)
+	Set(ctx context.Context, kind, key string, properties []data.Property, v interface{}) error
+	Delete(ctx context.Context, kind, key string) error
+	Transact(ctx context.Context, f func(ctx context.Context) error) error
+}
+
+type FileStore interface {
+	Load(ctx context.Context, path string) ([]byte, error)
+	Save(ctx context.Context, path string, content []byte) error
+}
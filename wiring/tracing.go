@@ -0,0 +1,120 @@
+package wiring
+
+import (
+	"context"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	"github.com/jbeshir/moonbird-predictor-frontend/mlclient"
+	"github.com/jbeshir/moonbird-predictor-frontend/tracing"
+	"github.com/pkg/errors"
+	"net/http"
+	"os"
+)
+
+// newTracer starts a tracer exporting as configured, or returns nil if tracing is off.
+func newTracer(cfg TracingConfig) *tracing.Tracer {
+	var exporter tracing.Exporter
+	switch cfg.Exporter {
+	case "stdout":
+		exporter = &tracing.WriterExporter{Writer: os.Stdout}
+	case "otlp":
+		exporter = &tracing.HTTPExporter{Endpoint: cfg.Endpoint}
+	default:
+		return nil
+	}
+	return tracing.NewTracer(cfg.ServiceName, exporter, cfg.SampleRatio)
+}
+
+// tracedCacheStore traces each call to a cache store, whichever platform's it is.
+type tracedCacheStore struct {
+	CacheStore CacheStore
+	Prefix     string
+}
+
+func (cs *tracedCacheStore) Get(ctx context.Context, key string, v interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "CacheStore.Get", tracing.String("cache.prefix", cs.Prefix))
+	defer func() {
+		// Misses are expected, so they don't count as failures.
+		span.SetAttributes(tracing.Bool("cache.hit", err == nil))
+		span.End()
+	}()
+	return cs.CacheStore.Get(ctx, key, v)
+}
+
+func (cs *tracedCacheStore) Set(ctx context.Context, key string, v interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "CacheStore.Set", tracing.String("cache.prefix", cs.Prefix))
+	defer endSpan(span, &err)
+	return cs.CacheStore.Set(ctx, key, v)
+}
+
+func (cs *tracedCacheStore) Delete(ctx context.Context, key string) (err error) {
+	ctx, span := tracing.Start(ctx, "CacheStore.Delete", tracing.String("cache.prefix", cs.Prefix))
+	defer endSpan(span, &err)
+	return cs.CacheStore.Delete(ctx, key)
+}
+
+func (cs *tracedCacheStore) Flush(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "CacheStore.Flush")
+	defer endSpan(span, &err)
+	return cs.CacheStore.Flush(ctx)
+}
+
+// tracedPersistentStore traces each call to a persistent store, whichever platform's it is.
+type tracedPersistentStore struct {
+	PersistentStore PersistentStore
+	Prefix          string
+}
+
+func (ps *tracedPersistentStore) Get(ctx context.Context, kind, key string, v interface{}) (properties []data.Property, err error) {
+	ctx, span := tracing.Start(ctx, "PersistentStore.Get",
+		tracing.String("store.prefix", ps.Prefix), tracing.String("store.kind", kind))
+	defer func() {
+		// Missing entities are expected, so they don't count as failures.
+		span.SetAttributes(tracing.Bool("store.found", err == nil))
+		if errors.Cause(err) != data.ErrNoSuchEntity {
+			span.SetError(err)
+		}
+		span.End()
+	}()
+	return ps.PersistentStore.Get(ctx, kind, key, v)
+}
+
+func (ps *tracedPersistentStore) Set(ctx context.Context, kind, key string, properties []data.Property, v interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "PersistentStore.Set",
+		tracing.String("store.prefix", ps.Prefix), tracing.String("store.kind", kind))
+	defer endSpan(span, &err)
+	return ps.PersistentStore.Set(ctx, kind, key, properties, v)
+}
+
+func (ps *tracedPersistentStore) Delete(ctx context.Context, kind, key string) (err error) {
+	ctx, span := tracing.Start(ctx, "PersistentStore.Delete",
+		tracing.String("store.prefix", ps.Prefix), tracing.String("store.kind", kind))
+	defer endSpan(span, &err)
+	return ps.PersistentStore.Delete(ctx, kind, key)
+}
+
+func (ps *tracedPersistentStore) Transact(ctx context.Context, f func(ctx context.Context) error) (err error) {
+	ctx, span := tracing.Start(ctx, "PersistentStore.Transact", tracing.String("store.prefix", ps.Prefix))
+	defer endSpan(span, &err)
+	return ps.PersistentStore.Transact(ctx, f)
+}
+
+// tracedClientMaker traces requests made by its clients, such as to ML Engine, and sends the trace on with them.
+type tracedClientMaker struct {
+	HttpClientMaker mlclient.HttpClientMaker
+}
+
+func (cm *tracedClientMaker) MakeClient(ctx context.Context) (*http.Client, error) {
+	client, err := cm.HttpClientMaker.MakeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	traced := *client
+	traced.Transport = &tracing.Transport{Base: client.Transport}
+	return &traced, nil
+}
+
+func endSpan(span *tracing.Span, err *error) {
+	span.SetError(*err)
+	span.End()
+}
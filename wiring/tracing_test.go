@@ -0,0 +1,81 @@
+package wiring
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/jbeshir/moonbird-auth-frontend/data"
+	"github.com/jbeshir/moonbird-predictor-frontend/localstore"
+	"github.com/jbeshir/moonbird-predictor-frontend/tracing"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestTracedStores(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "moonbird-tracing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var buf bytes.Buffer
+	tracer := tracing.NewTracer("test", &tracing.WriterExporter{Writer: &buf}, 1)
+	ctx := tracing.WithTracer(context.Background(), tracer)
+
+	cs := &tracedCacheStore{
+		CacheStore: &localstore.CacheStore{Backend: &localstore.MemoryCache{}, Prefix: "test-"},
+		Prefix:     "test-",
+	}
+	ps := &tracedPersistentStore{
+		PersistentStore: &localstore.PersistentStore{Dir: dir, Prefix: "test-"},
+		Prefix:          "test-",
+	}
+
+	var v string
+	if err := cs.Get(ctx, "key", &v); err == nil {
+		t.Error("Expected a cache miss, got nil err")
+	}
+	if _, err := ps.Get(ctx, "Kind", "key", &v); errors.Cause(err) != data.ErrNoSuchEntity {
+		t.Errorf("Expected ErrNoSuchEntity, got %v", err)
+	}
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected nil err, got %s", err)
+	}
+
+	var exported struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					Name   string
+					Status struct {
+						Code int
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &exported); err != nil {
+		t.Fatalf("Expected OTLP JSON, got %s: %s", buf.String(), err)
+	}
+	spans := exported.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 || spans[0].Name != "CacheStore.Get" || spans[1].Name != "PersistentStore.Get" {
+		t.Fatalf("Expected a span for each call, got %+v", spans)
+	}
+	for _, s := range spans {
+		if s.Status.Code != 0 {
+			t.Errorf("Expected misses not to mark %s as failed, got status %d", s.Name, s.Status.Code)
+		}
+	}
+}
+
+func TestNewTracer_None(t *testing.T) {
+	t.Parallel()
+
+	if tracer := newTracer(DefaultConfig().Tracing); tracer != nil {
+		t.Errorf("Expected no tracer by default, got %+v", tracer)
+	}
+}